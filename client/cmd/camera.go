@@ -17,6 +17,7 @@ var (
 
 	// Actions
 	isSnapshot     *bool
+	isCollage      *bool
 	isListCameras  *bool
 	isCameraStream *bool
 
@@ -32,6 +33,8 @@ func handleCameraCommand(cmd *cobra.Command, args []string) error {
 	// Handle request actions.
 	if *isListCameras {
 		return handleListCameraCommand()
+	} else if *isSnapshot && *isCollage {
+		return handleCameraCollageCommand()
 	} else if *isSnapshot {
 		return handleCameraSnapshotCommand()
 	} else if *isCameraStream {
@@ -51,6 +54,7 @@ func NewCameraCommand() *cobra.Command {
 	// Action flags.
 	imageOutputFilepath = camCmd.PersistentFlags().String("out", "", "(Optional) Filepath to saved snapshot image. Prints base64-encoded image to stdout if empty")
 	isSnapshot = camCmd.PersistentFlags().Bool("snapshot", false, "Takes a snapshot from existing cameras")
+	isCollage = camCmd.PersistentFlags().Bool("collage", false, "Tiles the snapshot of existing cameras, or of the given camera, into a single image")
 	isListCameras = camCmd.PersistentFlags().BoolP("list", "l", false, "Lists available cameras")
 	cameraIp = camCmd.PersistentFlags().String("ip", "", "(Optional) IP Address of a camera")
 	resultLimit = camCmd.PersistentFlags().Uint64("limit", 10, "Pagination limit from HTTP GET requests")
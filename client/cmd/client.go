@@ -95,6 +95,7 @@ func NewClientCommand(ctx *context.Context) *cobra.Command {
 	// Add client sub-commands.
 	clientCmd.AddCommand(NewClientPingCommand())
 	clientCmd.AddCommand(NewCameraCommand())
+	clientCmd.AddCommand(NewParkingCommand())
 
 	return clientCmd
 }
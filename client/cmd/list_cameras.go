@@ -50,8 +50,10 @@ func handleListCameraCommand() error {
 		}
 
 		log.Printf("== %s ==\n", cam.Name)
+		log.Printf("- Id: %d\n", cam.Id)
 		log.Printf("- IP: %s\n", cam.IP)
 		log.Printf("- Port: %d\n", cam.Port)
+		log.Printf("- Path: %s\n", cam.Path)
 		log.Printf("- ModifiedAt: %s\n", cam.ModifiedAt.Local())
 		log.Printf("- CreatedAt: %s\n", cam.CreatedAt.Local())
 		log.Printf("- Adjustment")
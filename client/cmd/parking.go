@@ -0,0 +1,166 @@
+// clientcmd package provides a client API to invoke parking server endpoints.
+package clientcmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"4bit.api/v0/server/route/parking/interfaces"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Actions
+	isListBands    *bool
+	isListSessions *bool
+
+	// Filtering
+	parkingNodeId *uint64
+	parkingLimit  *uint64
+)
+
+// handleParkingFloorCommand is a helper function for printing the current
+// inferred floor of each vehicle.
+// It returns an error instance reflecting the failure state.
+func handleParkingFloorCommand() error {
+	resBody, err := clientContext.Invoke("parking", http.MethodGet, []byte(""))
+	if err != nil {
+		return err
+	}
+
+	parkingResp := &interfaces.GetParkingResponse{}
+	if err := json.Unmarshal(resBody, parkingResp); err != nil {
+		return fmt.Errorf("failed to deserialize response: %v", err)
+	}
+
+	log.Printf("Found %d vehicles:", len(parkingResp.Vehicles))
+	for _, vehicle := range parkingResp.Vehicles {
+		// Filter on node id, if one was supplied.
+		if *parkingNodeId != 0 && *parkingNodeId != vehicle.NodeId {
+			continue
+		}
+
+		log.Printf("== Node %d ==\n", vehicle.NodeId)
+		if vehicle.Barometer != nil {
+			log.Printf("- Last Reading: %s\n", vehicle.Barometer.Timestamp.Local())
+			log.Printf("- Altitude: %.2f\n", vehicle.Barometer.Altitude)
+		}
+		if vehicle.Estimate != nil {
+			log.Printf("- Floor: %d (%s)\n", vehicle.Estimate.Band.Floor, vehicle.Estimate.Band.Garage.Name)
+			log.Printf("- Confidence: %.0f%%\n", vehicle.Estimate.Confidence*100)
+			if vehicle.Estimate.Reference != nil {
+				log.Printf("- Reference: Node %d\n", vehicle.Estimate.Reference.NodeId)
+			}
+		}
+		if vehicle.Session != nil {
+			log.Printf("- Parked Since: %s\n", vehicle.Session.StartedAt.Local())
+		}
+		if vehicle.Error != "" {
+			log.Printf("- Error: %s\n", vehicle.Error)
+		}
+	}
+
+	return nil
+}
+
+// handleParkingBandsCommand is a helper function for printing the calibrated
+// floor bands.
+// It returns an error instance reflecting the failure state.
+func handleParkingBandsCommand() error {
+	resBody, err := clientContext.Invoke("parking/bands", http.MethodGet, interfaces.ListBandsRequest{
+		NodeId: *parkingNodeId,
+	})
+	if err != nil {
+		return err
+	}
+
+	bandsResp := &interfaces.ListBandsResponse{}
+	if err := json.Unmarshal(resBody, bandsResp); err != nil {
+		return fmt.Errorf("failed to deserialize response: %v", err)
+	}
+
+	log.Printf("Found %d garages:", len(bandsResp.Garages))
+	for _, garage := range bandsResp.Garages {
+		log.Printf("== %s ==\n", garage.Name)
+		log.Printf("- Reference Node: %d\n", garage.ReferenceNodeId)
+
+		for _, band := range bandsResp.Bands {
+			if band.GarageId != garage.Id {
+				continue
+			}
+			log.Printf("- Node %d Floor %d\n", band.NodeId, band.Floor)
+			log.Printf("  - Altitude: %.2f (%.2f-%.2f)\n", band.Altitude, band.MinAltitude, band.MaxAltitude)
+			log.Printf("  - Samples: %d\n", band.Samples)
+			if band.RelativeSamples > 0 {
+				log.Printf("  - Relative Altitude: %.2f (%.2f-%.2f)\n", band.RelativeAltitude, band.MinRelativeAltitude, band.MaxRelativeAltitude)
+				log.Printf("  - Relative Samples: %d\n", band.RelativeSamples)
+			}
+		}
+	}
+
+	return nil
+}
+
+// handleParkingSessionsCommand is a helper function for printing the parking
+// session history.
+// It returns an error instance reflecting the failure state.
+func handleParkingSessionsCommand() error {
+	resBody, err := clientContext.Invoke("parking/sessions", http.MethodGet, interfaces.ListSessionsRequest{
+		NodeId: *parkingNodeId,
+		Limit:  *parkingLimit,
+	})
+	if err != nil {
+		return err
+	}
+
+	sessionsResp := &interfaces.ListSessionsResponse{}
+	if err := json.Unmarshal(resBody, sessionsResp); err != nil {
+		return fmt.Errorf("failed to deserialize response: %v", err)
+	}
+
+	log.Printf("Found %d sessions:", len(sessionsResp.Sessions))
+	for _, session := range sessionsResp.Sessions {
+		log.Printf("== Session %d ==\n", session.Id)
+		log.Printf("- Node: %d\n", session.NodeId)
+		log.Printf("- StartedAt: %s (%s)\n", session.StartedAt.Local(), session.StartReason)
+		if session.EndedAt != nil {
+			log.Printf("- EndedAt: %s\n", session.EndedAt.Local())
+		}
+		if session.Floor != nil {
+			log.Printf("- Floor: %d (%s)\n", *session.Floor, session.Garage.Name)
+			log.Printf("- Confidence: %.0f%%\n", session.Confidence*100)
+		}
+	}
+
+	return nil
+}
+
+// handleParkingCommand is a sub-command callback for handling invoking /parking
+// endpoints on a running server instance.
+// This returns an error instance reflecting the state of failure.
+func handleParkingCommand(cmd *cobra.Command, args []string) error {
+	if *isListBands {
+		return handleParkingBandsCommand()
+	} else if *isListSessions {
+		return handleParkingSessionsCommand()
+	}
+	return handleParkingFloorCommand()
+}
+
+func NewParkingCommand() *cobra.Command {
+	parkingCmd := &cobra.Command{
+		Use:   "parking",
+		Short: "Invokes the /parking API",
+		RunE:  handleParkingCommand,
+	}
+
+	// Action flags.
+	isListBands = parkingCmd.PersistentFlags().Bool("bands", false, "Lists calibrated floor bands")
+	isListSessions = parkingCmd.PersistentFlags().Bool("sessions", false, "Lists the parking session history")
+	parkingNodeId = parkingCmd.PersistentFlags().Uint64("node", 0, "(Optional) Vehicle node id to filter on")
+	parkingLimit = parkingCmd.PersistentFlags().Uint64("limit", 10, "Pagination limit from HTTP GET requests")
+
+	return parkingCmd
+}
@@ -45,14 +45,14 @@ func handleCameraSnapshotCommand() error {
 	}
 
 	// List the results of the snapshot response.
-	log.Printf("Received %d cameras", len(snapCams.Cameras))
-	for camIp, cam := range snapCams.Cameras {
+	log.Printf("Received %d cameras", len(snapCams.CamerasById))
+	for _, cam := range snapCams.CamerasById {
 		// Filter on camera ip, if one was supplied.
-		if *cameraIp != "" && *cameraIp != camIp {
+		if *cameraIp != "" && *cameraIp != cam.IP {
 			continue
 		}
 
-		log.Printf("== %s[%s] ==", cam.Name, camIp)
+		log.Printf("== %s[%d|%s] ==", cam.Name, cam.Id, cam.IP)
 		log.Printf("- Data: %dB", len(cam.Data))
 
 		// Check whether to print the data to stdout or to a file.
@@ -85,3 +85,39 @@ func handleCameraSnapshotCommand() error {
 
 	return nil
 }
+
+// handleCameraCollageCommand is a helper function for handling grabbing a single
+// collage of the snapshots from available cameras.
+// It returns an error instance reflecting the failure state.
+func handleCameraCollageCommand() error {
+	req := interfaces.CollageCameraRequest{}
+	if *cameraIp != "" {
+		req.IPs = []string{*cameraIp}
+	}
+
+	resBytes, err := clientContext.Invoke(
+		"camera/collage",
+		http.MethodGet,
+		req,
+	)
+	if err != nil {
+		return err
+	}
+
+	collageRes := &interfaces.CollageCameraResponse{}
+	if err := json.Unmarshal(resBytes, collageRes); err != nil {
+		return err
+	}
+	log.Printf("- Collage: %dB", len(collageRes.Data))
+
+	// Check whether to print the data to stdout or to a file.
+	if *imageOutputFilepath != "" {
+		log.Println("Saving to ", *imageOutputFilepath)
+		return os.WriteFile(*imageOutputFilepath, collageRes.Data, 0644)
+	}
+
+	log.Println("= base64 encoded data [start] =")
+	log.Println(base64.StdEncoding.EncodeToString(collageRes.Data))
+	log.Println("= base64 encoded data [end] =")
+	return nil
+}
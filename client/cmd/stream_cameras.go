@@ -121,10 +121,10 @@ func streamCamera(wg *sync.WaitGroup, win *gtk.Window, winTermSig chan struct{})
 					continue
 				}
 
-				numCams := len(streamResp.Cameras)
+				numCams := len(streamResp.CamerasById)
 				log.Printf("Received %d cameras:", numCams)
-				for camIp, cam := range streamResp.Cameras {
-					log.Printf("== %s[%s] ==", cam.Name, camIp)
+				for _, cam := range streamResp.CamerasById {
+					log.Printf("== %s[%d|%s] ==", cam.Name, cam.Id, cam.IP)
 					log.Printf("- Data: %dB", len(cam.Data))
 
 					// Decode jpeg image.
@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+
+	"4bit.api/v0/database"
+	"4bit.api/v0/database/sqlite"
+	"github.com/go-pg/pg/v10"
+	"github.com/spf13/cobra"
+)
+
+// Postgres connection flags of a command.
+type postgresFlags struct {
+	postgres_host     *string
+	postgres_port     *uint16
+	postgres_database *string
+	postgres_username *string
+	postgres_password *string
+}
+
+// newPostgresFlags registers the postgres connection flags on the command.
+func newPostgresFlags(cmd *cobra.Command) *postgresFlags {
+	return &postgresFlags{
+		postgres_host:     cmd.PersistentFlags().StringP("postgres_host", "", "localhost", "Postgres database hostname."),
+		postgres_port:     cmd.PersistentFlags().Uint16P("postgres_port", "", 5432, "Postgres database port."),
+		postgres_database: cmd.PersistentFlags().StringP("postgres_database", "", "4bit", "Postgres database to use."),
+		postgres_username: cmd.PersistentFlags().StringP("postgres_username", "", "admin", "Postgres username."),
+		postgres_password: cmd.PersistentFlags().StringP("postgres_password", "", "example", "Postgres password."),
+	}
+}
+
+// options constructs the postgres connection options from the flags.
+func (flags *postgresFlags) options() *pg.Options {
+	return &pg.Options{
+		Addr:     fmt.Sprintf("%s:%d", *flags.postgres_host, *flags.postgres_port),
+		Database: *flags.postgres_database,
+		User:     *flags.postgres_username,
+		Password: *flags.postgres_password,
+	}
+}
+
+// connect establishes a connection with the postgres database, migrating its
+// schemas to the latest version.
+func (flags *postgresFlags) connect() (*pg.DB, error) {
+	db, err := database.NewConnection(flags.options())
+	if err != nil {
+		return nil, fmt.Errorf(
+			"failed to establish a new connection with %s:%d: %v",
+			*flags.postgres_host,
+			*flags.postgres_port,
+			err,
+		)
+	}
+	log.Printf("Postgres connection successful")
+	return db, nil
+}
+
+// Storage backend flags of a command.
+type storageFlags struct {
+	storage     *string
+	sqlite_path *string
+	postgres    *postgresFlags
+}
+
+// newStorageFlags registers the storage backend flags on the command, along
+// with the postgres connection flags.
+func newStorageFlags(cmd *cobra.Command) *storageFlags {
+	return &storageFlags{
+		storage: cmd.PersistentFlags().StringP("storage", "", database.POSTGRES_BACKEND, fmt.Sprintf(
+			"Storage backend, either '%s' or '%s'. Only cameras & nodes are stored with '%s'.",
+			database.POSTGRES_BACKEND,
+			database.SQLITE_BACKEND,
+			database.SQLITE_BACKEND,
+		)),
+		sqlite_path: cmd.PersistentFlags().StringP("sqlite_path", "", "4bit.db", "Path to the sqlite database file."),
+		postgres:    newPostgresFlags(cmd),
+	}
+}
+
+// open opens the selected storage backend, setting up the store instance.
+func (flags *storageFlags) open() error {
+	switch *flags.storage {
+	case database.POSTGRES_BACKEND:
+		db, err := flags.postgres.connect()
+		if err != nil {
+			return err
+		}
+		database.StoreInstance = database.NewPostgresStore(db)
+
+	case database.SQLITE_BACKEND:
+		store, err := sqlite.Open(*flags.sqlite_path)
+		if err != nil {
+			return err
+		}
+		database.StoreInstance = store
+		log.Printf("Sqlite database '%s' opened", *flags.sqlite_path)
+
+	default:
+		return fmt.Errorf(
+			"unknown storage backend '%s', expected '%s' or '%s'",
+			*flags.storage,
+			database.POSTGRES_BACKEND,
+			database.SQLITE_BACKEND,
+		)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+
+	"4bit.api/v0/database"
+	"github.com/spf13/cobra"
+)
+
+// Maintenance flags
+var (
+	maintenance_postgres *postgresFlags
+	orphans_clean        *bool
+)
+
+// printCameraOrphans prints the IDs of each kind of orphan row.
+func printCameraOrphans(orphans *database.CameraOrphans) {
+	fmt.Printf("Camera adjustments without a camera: %v\n", orphans.Adjustments)
+	fmt.Printf("Cameras without an adjustment: %v\n", orphans.Cameras)
+	fmt.Printf("Camera group members without a camera or group: %v\n", orphans.GroupMembers)
+}
+
+func handleOrphansCmd(cmd *cobra.Command, args []string) error {
+	// Orphan rows may prevent migrating, leave the schemas as is.
+	db := database.Connect(maintenance_postgres.options())
+
+	if !*orphans_clean {
+		orphans, err := database.FindCameraOrphans(db)
+		if err != nil {
+			return err
+		}
+		printCameraOrphans(orphans)
+		fmt.Printf("Found %d orphan rows, clean them with --clean\n", orphans.Count())
+		return nil
+	}
+
+	orphans, err := database.CleanCameraOrphans(db)
+	if err != nil {
+		return fmt.Errorf("failed to clean orphan rows: %v", err)
+	}
+	printCameraOrphans(orphans)
+	fmt.Printf("Cleaned %d orphan rows\n", orphans.Count())
+	return nil
+}
+
+// NewMaintenanceCommand creates a maintenance sub-command grouping database
+// upkeep commands.
+func NewMaintenanceCommand() *cobra.Command {
+	maintenanceCmd := &cobra.Command{
+		Use:   "maintenance",
+		Short: "Database maintenance commands.",
+	}
+
+	orphansCmd := &cobra.Command{
+		Use:   "orphans",
+		Short: "Reports camera rows left without the rows they relate to, optionally cleaning them.",
+		RunE:  handleOrphansCmd,
+	}
+	orphans_clean = orphansCmd.Flags().BoolP("clean", "", false, "Cleans the orphan rows, which prevent adding the camera foreign keys.")
+	maintenanceCmd.AddCommand(orphansCmd)
+
+	// Database flags.
+	maintenance_postgres = newPostgresFlags(maintenanceCmd)
+
+	return maintenanceCmd
+}
@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"fmt"
+
+	"4bit.api/v0/database"
+	"github.com/spf13/cobra"
+)
+
+// Migrate flags
+var (
+	migrate_postgres *postgresFlags
+	migrate_to       *uint64
+	migrate_steps    *int
+)
+
+func handleMigrateStatusCmd(cmd *cobra.Command, args []string) error {
+	db := database.Connect(migrate_postgres.options())
+	statuses, err := database.GetMigrationStatus(db)
+	if err != nil {
+		return err
+	}
+
+	for _, status := range statuses {
+		state := "pending"
+		if status.AppliedAt != nil {
+			state = fmt.Sprintf("applied %s", status.AppliedAt.Local().Format("2006-01-02 15:04:05"))
+		}
+		if status.Unknown {
+			state += " (unknown to this binary)"
+		}
+		fmt.Printf("%4d %-24s %s\n", status.Version, status.Name, state)
+	}
+	return nil
+}
+
+func handleMigrateUpCmd(cmd *cobra.Command, args []string) error {
+	db := database.Connect(migrate_postgres.options())
+	migrated, err := database.MigrateUp(db, *migrate_to)
+	for _, migration := range migrated {
+		fmt.Printf("Applied migration %d '%s'\n", migration.Version, migration.Name)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Applied %d migrations\n", len(migrated))
+	return nil
+}
+
+func handleMigrateDownCmd(cmd *cobra.Command, args []string) error {
+	if *migrate_steps < 1 {
+		return fmt.Errorf("invalid steps %d, expected at least 1", *migrate_steps)
+	}
+
+	db := database.Connect(migrate_postgres.options())
+	migrated, err := database.MigrateDown(db, *migrate_steps)
+	for _, migration := range migrated {
+		fmt.Printf("Reverted migration %d '%s'\n", migration.Version, migration.Name)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Reverted %d migrations\n", len(migrated))
+	return nil
+}
+
+// NewMigrateCommand creates a migrate sub-command which reports, applies &
+// reverts versioned schema migrations.
+func NewMigrateCommand() *cobra.Command {
+	migrateCmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Versioned database schema migrations.",
+	}
+
+	statusCmd := &cobra.Command{
+		Use:   "status",
+		Short: "Lists migrations along with whether they were applied.",
+		RunE:  handleMigrateStatusCmd,
+	}
+	migrateCmd.AddCommand(statusCmd)
+
+	upCmd := &cobra.Command{
+		Use:   "up",
+		Short: "Applies pending migrations.",
+		RunE:  handleMigrateUpCmd,
+	}
+	migrate_to = upCmd.Flags().Uint64P("to", "", 0, "Version to migrate up to, defaulting to the latest.")
+	migrateCmd.AddCommand(upCmd)
+
+	downCmd := &cobra.Command{
+		Use:   "down",
+		Short: "Reverts the most recently applied migrations.",
+		RunE:  handleMigrateDownCmd,
+	}
+	migrate_steps = downCmd.Flags().IntP("steps", "", 1, "Number of migrations to revert.")
+	migrateCmd.AddCommand(downCmd)
+
+	// Database flags.
+	migrate_postgres = newPostgresFlags(migrateCmd)
+
+	return migrateCmd
+}
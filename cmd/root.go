@@ -97,6 +97,8 @@ func Execute(version string) error {
 	config.Verbose = *verbose
 
 	rootCmd.AddCommand(NewServerCommand())
+	rootCmd.AddCommand(NewMigrateCommand())
+	rootCmd.AddCommand(NewMaintenanceCommand())
 	rootCmd.AddCommand(NewVersionCommand())
 	return rootCmd.Execute()
 }
@@ -4,55 +4,80 @@ import (
 	"fmt"
 	"log"
 	"strconv"
+	"time"
 
 	"4bit.api/v0/database"
+	"4bit.api/v0/pkg/retention"
+	"4bit.api/v0/pkg/schedule"
 	"4bit.api/v0/server"
 	"4bit.api/v0/server/route/telegram"
-	"github.com/go-pg/pg/v10"
 	dotenv "github.com/joho/godotenv"
 	"github.com/spf13/cobra"
 )
 
+// Telegram flags
+var (
+	telegram_enabled *bool
+)
+
 // Database flags
 var (
-	postgres_host     *string
-	postgres_port     *uint16
-	postgres_database *string
-	postgres_username *string
-	postgres_password *string
+	server_storage *storageFlags
+)
+
+// Retention flags, keyed by sensor type.
+var (
+	retention_interval *time.Duration
+	retention_raw_days = map[string]*uint{}
+	retention_max_days = map[string]*uint{}
 )
 
 func handleServerCmd(cmd *cobra.Command, args []string) error {
-	// Initialize .env.
+	// Initialize .env, falling back on the environment.
 	if err := dotenv.Load(); err != nil {
-		log.Fatalf("failed to load .env file: %v", err)
+		log.Printf("Skipping .env file: %v", err)
 	}
 
 	// Initialize telegram bot.
-	if err := telegram.Init(); err != nil {
-		log.Fatalf("failed to instantiate the telegram bot: %v", err)
+	if err := telegram.Init(*telegram_enabled); err != nil {
+		return fmt.Errorf("failed to instantiate the telegram bot: %v", err)
 	}
 
-	// Establish a connection with the postgres database.
-	if _, err := database.NewConnection(&pg.Options{
-		Addr:     fmt.Sprintf("%s:%d", *postgres_host, *postgres_port),
-		Database: *postgres_database,
-		User:     *postgres_username,
-		Password: *postgres_password,
-	}); err != nil {
-		return fmt.Errorf(
-			"failed to establish a new connection with %s:%d",
-			*postgres_host,
-			*postgres_port,
-		)
+	// Configure the telemetry retention policies.
+	for _, sensor := range retention.SensorNames() {
+		policy := retention.RetentionPolicy{MaxDays: *retention_max_days[sensor]}
+		if rawDays, ok := retention_raw_days[sensor]; ok {
+			policy.RawDays = *rawDays
+		}
+		if err := retention.SetPolicy(sensor, policy); err != nil {
+			return err
+		}
+	}
+
+	// Open the storage backend.
+	if err := server_storage.open(); err != nil {
+		return err
+	}
+
+	// Deliver scheduled reports, which are only stored in postgres.
+	if database.DbInstance != nil {
+		go schedule.Start()
+	}
+
+	// Downsample & prune node telemetry, which is only stored in postgres.
+	if database.DbInstance != nil && *retention_interval > 0 {
+		go retention.Start(*retention_interval)
 	}
-	log.Printf("Postgres connection successful")
 
 	// Extract & construct server options.
 	port, err := strconv.ParseUint(cmd.PersistentFlags().Lookup("port").Value.String(), 10, 16)
 	if err != nil {
 		return fmt.Errorf("failed to parse port: %v", err)
 	}
+	webhookPort, err := strconv.ParseUint(cmd.PersistentFlags().Lookup("webhookPort").Value.String(), 10, 16)
+	if err != nil {
+		return fmt.Errorf("failed to parse webhook port: %v", err)
+	}
 
 	opts := &server.ServerOpts{
 		ServerName:          cmd.PersistentFlags().Lookup("name").Value.String(),
@@ -62,6 +87,8 @@ func handleServerCmd(cmd *cobra.Command, args []string) error {
 		CACrl:               cmd.PersistentFlags().Lookup("caCrl").Value.String(),
 		HostEndpoint:        cmd.PersistentFlags().Lookup("host").Value.String(),
 		PortEndpoint:        uint16(port),
+		WebhookHostEndpoint: cmd.PersistentFlags().Lookup("webhookHost").Value.String(),
+		WebhookPortEndpoint: uint16(webhookPort),
 	}
 	if err := server.Run(rootCtx.Context, opts); err != nil {
 		return fmt.Errorf("failed server command: %v", err)
@@ -87,13 +114,34 @@ func NewServerCommand() *cobra.Command {
 	srvCmd.PersistentFlags().String("name", "localhost", "Server's name'.")
 	srvCmd.PersistentFlags().String("host", "localhost", "Server hostname to serve on.")
 	srvCmd.PersistentFlags().Uint("port", 3000, "Server port to serve on.")
+	srvCmd.PersistentFlags().String("webhookHost", "localhost", "Hostname to serve the telegram webhook on, when TELEGRAM_WEBHOOK_URL is set.")
+	srvCmd.PersistentFlags().Uint("webhookPort", 8443, "Port to serve the telegram webhook on, when TELEGRAM_WEBHOOK_URL is set.")
+
+	// Telegram flags.
+	telegram_enabled = srvCmd.PersistentFlags().BoolP("telegram", "", true, "Enables the telegram bot, requiring TELEGRAM_TOKEN.")
 
 	// Database flags.
-	postgres_host = srvCmd.PersistentFlags().StringP("postgres_host", "", "localhost", "Postgres database hostname.")
-	postgres_port = srvCmd.PersistentFlags().Uint16P("postgres_port", "", 5432, "Postgres database port.")
-	postgres_database = srvCmd.PersistentFlags().StringP("postgres_database", "", "4bit", "Postgres database to use.")
-	postgres_username = srvCmd.PersistentFlags().StringP("postgres_username", "", "admin", "Postgres username.")
-	postgres_password = srvCmd.PersistentFlags().StringP("postgres_password", "", "example", "Postgres password.")
+	server_storage = newStorageFlags(srvCmd)
+
+	// Retention flags.
+	retention_interval = srvCmd.PersistentFlags().DurationP("retention_interval", "", time.Hour, "Interval between telemetry retention runs, 0 disables retention.")
+	for _, sensor := range retention.SensorNames() {
+		policy := retention.DefaultPolicies[sensor]
+		if retention.Downsamples(sensor) {
+			retention_raw_days[sensor] = srvCmd.PersistentFlags().UintP(
+				fmt.Sprintf("retention_%s_raw_days", sensor),
+				"",
+				policy.RawDays,
+				fmt.Sprintf("Days to keep raw %s states before rolling them into hourly aggregates, 0 keeps them raw.", sensor),
+			)
+		}
+		retention_max_days[sensor] = srvCmd.PersistentFlags().UintP(
+			fmt.Sprintf("retention_%s_max_days", sensor),
+			"",
+			policy.MaxDays,
+			fmt.Sprintf("Days to keep %s telemetry before removing it, 0 keeps it forever.", sensor),
+		)
+	}
 
 	return srvCmd
 }
@@ -0,0 +1,40 @@
+package database
+
+import "4bit.api/v0/server/route/node/interfaces"
+
+// User-defined threshold rule evaluated against incoming node readings,
+// ie. "LoadVoltage < 4.8 for 5 minutes".
+type AlertRule struct {
+	BaseEntry
+	Name string
+
+	// Reading the rule applies to. A zero NodeId applies to all nodes.
+	NodeId uint64
+	Type   interfaces.StateType
+	Field  string
+
+	// Condition for which the rule fires.
+	Operator  string
+	Threshold float64
+	For       uint64 // Seconds the condition must hold before firing.
+
+	// Margin past the threshold a reading must recover by to resolve the alert.
+	Hysteresis float64
+
+	// Notification route to deliver alerts to. When empty, alerts are delivered
+	// to the telegram chat, falling back to the alert chat if zero.
+	Route  string
+	ChatId int64
+}
+
+// Transition of an alert rule for a given node.
+type AlertEvent struct {
+	BaseEntry
+	NodeId uint64
+	State  string
+	Value  float64
+
+	// Relationship.
+	RuleId uint64
+	Rule   *AlertRule `pg:"rel:has-one"`
+}
@@ -1,20 +1,15 @@
 package database
 
-import (
-	"fmt"
-	"time"
-
-	"github.com/go-pg/pg/v10"
-	"github.com/go-pg/pg/v10/orm"
-)
+import "time"
 
 type CameraEntry struct {
 	Id         uint64
 	Name       string
 	CreatedAt  time.Time
 	ModifiedAt time.Time
-	IP         string
+	IP         string // Address or hostname of the camera's host.
 	Port       uint16
+	Path       string // Streaming path on the host, allowing multiple streams per host.
 
 	// Adjustment Relationship
 	AdjustmentId uint64
@@ -30,22 +25,40 @@ type CameraAdjsustment struct {
 	CropFrameX      uint64
 	CropFrameY      uint64
 	Rotate          float64
+
+	// Frame Overlay
+	OverlayName      bool
+	OverlayTimestamp bool
+	OverlayText      string
+	OverlayCorner    string
+
+	// Regions blacked out of every frame.
+	PrivacyMasks []CameraPrivacyMask
 }
 
-func CreateCameraSchema(db *pg.DB) error {
-	models := []interface{}{
-		(*CameraEntry)(nil),
-		(*CameraAdjsustment)(nil),
-	}
-
-	// Attempt to create the table schemas
-	for _, model := range models {
-		if err := db.Model(model).CreateTable(&orm.CreateTableOptions{
-			IfNotExists: true,
-		}); err != nil {
-			return fmt.Errorf("failed to create tables: %v", err)
-		}
-	}
-
-	return nil
+// Polygon blacked out of a camera's frames.
+type CameraPrivacyMask struct {
+	Points []CameraMaskPoint
+}
+
+// Vertex of a privacy mask, relative to the frame's size ranging from 0 to 1,
+// keeping masks independent of the camera's resolution.
+type CameraMaskPoint struct {
+	X float64
+	Y float64
+}
+
+// Named set of cameras, ie. "outside" or "garage", with tags further
+// categorizing the set, ie. "perimeter".
+type CameraGroup struct {
+	BaseEntry
+	Name string   `pg:",unique"`
+	Tags []string `pg:",array"`
+}
+
+// Assignment of a camera to a group.
+type CameraGroupMember struct {
+	BaseEntry
+	GroupId  uint64 `pg:",unique:member"`
+	CameraId uint64 `pg:",unique:member"`
 }
@@ -12,25 +12,28 @@ var (
 	DbInstance *pg.DB
 )
 
-// Creates a new connection with a given postgres sql server, setting up the schemas for the db.
-func NewConnection(options *pg.Options) (*pg.DB, error) {
+// Connects to a given postgres sql server, leaving its schemas as is.
+func Connect(options *pg.Options) *pg.DB {
 	// Return already established connection if present.
 	if DbInstance != nil {
-		return DbInstance, nil
+		return DbInstance
 	}
 
 	DbInstance = pg.Connect(options)
+	return DbInstance
+}
 
-	// Create schemas.
-	log.Println("Attempting to create Node schema")
-	if err := CreateNodeSchema(DbInstance); err != nil {
-		return nil, fmt.Errorf("failed to create node schema: %v", err)
-	}
+// Creates a new connection with a given postgres sql server, migrating the schemas
+// of the db to the latest version.
+func NewConnection(options *pg.Options) (*pg.DB, error) {
+	db := Connect(options)
 
-	log.Println("Attempting to create Camera schema")
-	if err := CreateCameraSchema(DbInstance); err != nil {
-		return nil, fmt.Errorf("failed to create camera schema: %v", err)
+	log.Println("Attempting to apply pending migrations")
+	migrated, err := MigrateUp(db, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to migrate schemas: %v", err)
 	}
+	log.Printf("Applied %d migrations\n", len(migrated))
 
-	return DbInstance, nil
+	return db, nil
 }
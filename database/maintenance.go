@@ -0,0 +1,114 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+)
+
+// Rows of the camera tables left without the rows they relate to, ie. by
+// non-transactional adds & removes prior to the foreign keys.
+type CameraOrphans struct {
+	Adjustments  []uint64 // Adjustments no camera refers to.
+	Cameras      []uint64 // Cameras referring to a missing adjustment.
+	GroupMembers []uint64 // Group memberships of a missing camera or group.
+}
+
+// Count returns the total number of orphan rows.
+func (orphans *CameraOrphans) Count() int {
+	return len(orphans.Adjustments) + len(orphans.Cameras) + len(orphans.GroupMembers)
+}
+
+// FindCameraOrphans retrieves the IDs of orphan rows within the camera tables.
+func FindCameraOrphans(db pg.DBI) (*CameraOrphans, error) {
+	orphans := &CameraOrphans{
+		Adjustments:  []uint64{},
+		Cameras:      []uint64{},
+		GroupMembers: []uint64{},
+	}
+
+	if err := db.Model((*CameraAdjsustment)(nil)).
+		Column("camera_adjsustment.id").
+		Where("NOT EXISTS (SELECT 1 FROM camera_entries WHERE camera_entries.adjustment_id = camera_adjsustment.id)").
+		Order("camera_adjsustment.id ASC").
+		Select(&orphans.Adjustments); err != nil {
+		return nil, fmt.Errorf("failed to query orphan camera adjustments: %v", err)
+	}
+
+	if err := db.Model((*CameraEntry)(nil)).
+		Column("camera_entry.id").
+		Where("NOT EXISTS (SELECT 1 FROM camera_adjsustments WHERE camera_adjsustments.id = camera_entry.adjustment_id)").
+		Order("camera_entry.id ASC").
+		Select(&orphans.Cameras); err != nil {
+		return nil, fmt.Errorf("failed to query cameras with missing adjustments: %v", err)
+	}
+
+	if err := db.Model((*CameraGroupMember)(nil)).
+		Column("camera_group_member.id").
+		WhereGroup(func(q *pg.Query) (*pg.Query, error) {
+			q = q.WhereOr("NOT EXISTS (SELECT 1 FROM camera_entries WHERE camera_entries.id = camera_group_member.camera_id)").
+				WhereOr("NOT EXISTS (SELECT 1 FROM camera_groups WHERE camera_groups.id = camera_group_member.group_id)")
+			return q, nil
+		}).
+		Order("camera_group_member.id ASC").
+		Select(&orphans.GroupMembers); err != nil {
+		return nil, fmt.Errorf("failed to query orphan camera group members: %v", err)
+	}
+
+	return orphans, nil
+}
+
+// CleanCameraOrphans removes orphan adjustments & group memberships, and links
+// cameras missing their adjustment to a new default one, such that the camera
+// foreign keys can be added.
+// On success, returns the orphans which were cleaned.
+func CleanCameraOrphans(db *pg.DB) (*CameraOrphans, error) {
+	var orphans *CameraOrphans
+	if err := db.RunInTransaction(context.Background(), func(tx *pg.Tx) error {
+		var err error
+		if orphans, err = FindCameraOrphans(tx); err != nil {
+			return err
+		}
+
+		if len(orphans.Adjustments) > 0 {
+			if _, err := tx.Model((*CameraAdjsustment)(nil)).
+				WhereIn("id IN (?)", orphans.Adjustments).
+				Delete(); err != nil {
+				return fmt.Errorf("failed to remove orphan camera adjustments: %v", err)
+			}
+		}
+
+		if len(orphans.GroupMembers) > 0 {
+			if _, err := tx.Model((*CameraGroupMember)(nil)).
+				WhereIn("id IN (?)", orphans.GroupMembers).
+				Delete(); err != nil {
+				return fmt.Errorf("failed to remove orphan camera group members: %v", err)
+			}
+		}
+
+		// Keep the cameras, as removing them would lose their configuration.
+		for _, cameraId := range orphans.Cameras {
+			camAdjust := CameraAdjsustment{
+				BaseEntry: BaseEntry{
+					Timestamp: time.Now(),
+				},
+			}
+			if _, err := tx.Model(&camAdjust).Insert(); err != nil {
+				return fmt.Errorf("failed to add camera adjustment for camera entry[%d]: %v", cameraId, err)
+			}
+			if _, err := tx.Model((*CameraEntry)(nil)).
+				Set("adjustment_id = ?", camAdjust.Id).
+				Where("id = ?", cameraId).
+				Update(); err != nil {
+				return fmt.Errorf("failed to link camera adjustment to camera entry[%d]: %v", cameraId, err)
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return orphans, nil
+}
@@ -0,0 +1,184 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
+)
+
+// Versioned change to the database schema, reverted by its Down function.
+type Migration struct {
+	Version uint64
+	Name    string
+	Up      func(db pg.DBI) error
+	Down    func(db pg.DBI) error
+}
+
+// Migration applied to the database.
+type SchemaVersion struct {
+	tableName struct{} `pg:"schema_version"`
+
+	Version   uint64 `pg:",pk"`
+	Name      string
+	AppliedAt time.Time
+}
+
+// State of a migration, which is either known to the binary, applied to the
+// database, or both.
+type MigrationStatus struct {
+	Version   uint64
+	Name      string
+	AppliedAt *time.Time // Nil while pending.
+	Unknown   bool       // Applied to the database but unknown to the binary.
+}
+
+// createSchemaVersionTable creates the table tracking applied migrations.
+func createSchemaVersionTable(db pg.DBI) error {
+	if err := db.Model((*SchemaVersion)(nil)).CreateTable(&orm.CreateTableOptions{
+		IfNotExists: true,
+	}); err != nil {
+		return fmt.Errorf("failed to create schema version table: %v", err)
+	}
+	return nil
+}
+
+// getSchemaVersions retrieves the applied migrations, oldest first.
+func getSchemaVersions(db pg.DBI) ([]SchemaVersion, error) {
+	if err := createSchemaVersionTable(db); err != nil {
+		return nil, err
+	}
+
+	versions := []SchemaVersion{}
+	if err := db.Model(&versions).Order("version ASC").Select(); err != nil {
+		return nil, fmt.Errorf("failed to query schema versions: %v", err)
+	}
+	return versions, nil
+}
+
+// findMigration looks up a known migration by its version.
+func findMigration(version uint64) *Migration {
+	for i := range Migrations {
+		if Migrations[i].Version == version {
+			return &Migrations[i]
+		}
+	}
+	return nil
+}
+
+// GetMigrationStatus retrieves the state of every known & applied migration,
+// ordered by version.
+func GetMigrationStatus(db pg.DBI) ([]MigrationStatus, error) {
+	versions, err := getSchemaVersions(db)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := []MigrationStatus{}
+	for _, migration := range Migrations {
+		statuses = append(statuses, MigrationStatus{
+			Version: migration.Version,
+			Name:    migration.Name,
+		})
+	}
+	for i := range versions {
+		version := &versions[i]
+		found := false
+		for j := range statuses {
+			if statuses[j].Version == version.Version {
+				statuses[j].AppliedAt = &version.AppliedAt
+				found = true
+			}
+		}
+		if !found {
+			statuses = append(statuses, MigrationStatus{
+				Version:   version.Version,
+				Name:      version.Name,
+				AppliedAt: &version.AppliedAt,
+				Unknown:   true,
+			})
+		}
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+	return statuses, nil
+}
+
+// MigrateUp applies pending migrations in order, up to & including the target
+// version, where a zero target applies all of them. Each migration is applied
+// within its own transaction.
+// On success, returns the applied migrations.
+func MigrateUp(db *pg.DB, target uint64) ([]Migration, error) {
+	versions, err := getSchemaVersions(db)
+	if err != nil {
+		return nil, err
+	}
+	applied := map[uint64]bool{}
+	for _, version := range versions {
+		applied[version.Version] = true
+	}
+
+	migrated := []Migration{}
+	for _, migration := range Migrations {
+		if applied[migration.Version] {
+			continue
+		}
+		if target != 0 && migration.Version > target {
+			break
+		}
+
+		log.Printf("Applying migration %d '%s'\n", migration.Version, migration.Name)
+		if err := db.RunInTransaction(context.Background(), func(tx *pg.Tx) error {
+			if err := migration.Up(tx); err != nil {
+				return err
+			}
+			_, err := tx.Model(&SchemaVersion{
+				Version:   migration.Version,
+				Name:      migration.Name,
+				AppliedAt: time.Now().UTC(),
+			}).Insert()
+			return err
+		}); err != nil {
+			return migrated, fmt.Errorf("failed to apply migration %d '%s': %v", migration.Version, migration.Name, err)
+		}
+		migrated = append(migrated, migration)
+	}
+	return migrated, nil
+}
+
+// MigrateDown reverts the given number of most recently applied migrations,
+// newest first. Each migration is reverted within its own transaction.
+// On success, returns the reverted migrations.
+func MigrateDown(db *pg.DB, steps int) ([]Migration, error) {
+	versions, err := getSchemaVersions(db)
+	if err != nil {
+		return nil, err
+	}
+
+	migrated := []Migration{}
+	for i := len(versions) - 1; i >= 0 && len(migrated) < steps; i-- {
+		migration := findMigration(versions[i].Version)
+		if migration == nil {
+			return migrated, fmt.Errorf("cannot revert migration %d '%s' unknown to this binary", versions[i].Version, versions[i].Name)
+		}
+
+		log.Printf("Reverting migration %d '%s'\n", migration.Version, migration.Name)
+		if err := db.RunInTransaction(context.Background(), func(tx *pg.Tx) error {
+			if err := migration.Down(tx); err != nil {
+				return err
+			}
+			_, err := tx.Model(&versions[i]).WherePK().Delete()
+			return err
+		}); err != nil {
+			return migrated, fmt.Errorf("failed to revert migration %d '%s': %v", migration.Version, migration.Name, err)
+		}
+		migrated = append(migrated, *migration)
+	}
+	return migrated, nil
+}
@@ -0,0 +1,252 @@
+package database
+
+import (
+	"fmt"
+
+	"github.com/go-pg/pg/v10"
+)
+
+// execStatements returns a migration step running the statements in order.
+func execStatements(statements ...string) func(db pg.DBI) error {
+	return func(db pg.DBI) error {
+		for _, statement := range statements {
+			if _, err := db.Exec(statement); err != nil {
+				return fmt.Errorf("failed to execute '%s': %v", statement, err)
+			}
+		}
+		return nil
+	}
+}
+
+// Known migrations, ordered by version. New schema changes are appended as new
+// migrations of explicit statements, rather than editing applied ones or
+// deriving them from the models.
+var Migrations = []Migration{
+	{
+		// Creates the tables as they were prior to migrations, adopting the tables
+		// of existing databases as is.
+		Version: 1,
+		Name:    "create_baseline_tables",
+		Up: execStatements(
+			`CREATE TABLE IF NOT EXISTS "nodes" ("id" bigserial, "timestamp" timestamptz, "certificate_fingerprint" text, PRIMARY KEY ("id"))`,
+			`CREATE TABLE IF NOT EXISTS "node_power_states" ("id" bigserial, "timestamp" timestamptz, "current_ma" real, "load_voltage" real, "power_mw" real, "node_id" bigint, PRIMARY KEY ("id"))`,
+			`CREATE TABLE IF NOT EXISTS "node_barometer_states" ("id" bigserial, "timestamp" timestamptz, "pressure" real, "temperature" real, "altitude" real, "node_id" bigint, PRIMARY KEY ("id"))`,
+			`CREATE TABLE IF NOT EXISTS "camera_entries" ("id" bigserial, "name" text, "created_at" timestamptz, "modified_at" timestamptz, "ip" text, "port" integer, "adjustment_id" bigint, PRIMARY KEY ("id"))`,
+			`CREATE TABLE IF NOT EXISTS "camera_adjsustments" ("id" bigserial, "timestamp" timestamptz, "crop_frame_height" double precision, "crop_frame_width" double precision, "crop_framex" bigint, "crop_framey" bigint, "rotate" double precision, PRIMARY KEY ("id"))`,
+		),
+		Down: execStatements(
+			`DROP TABLE "camera_entries"`,
+			`DROP TABLE "camera_adjsustments"`,
+			`DROP TABLE "node_barometer_states"`,
+			`DROP TABLE "node_power_states"`,
+			`DROP TABLE "nodes"`,
+		),
+	},
+	{
+		Version: 2,
+		Name:    "create_node_fingerprints",
+		Up: execStatements(
+			`CREATE TABLE "node_fingerprints" ("id" bigserial, "timestamp" timestamptz, "fingerprint" text, "valid_from" timestamptz, "valid_until" timestamptz, "node_id" bigint, PRIMARY KEY ("id"), UNIQUE ("fingerprint"))`,
+		),
+		Down: execStatements(
+			`DROP TABLE "node_fingerprints"`,
+		),
+	},
+	{
+		Version: 3,
+		Name:    "create_node_heartbeats",
+		Up: execStatements(
+			`CREATE TABLE "node_heartbeats" ("id" bigserial, "timestamp" timestamptz, "uptime" bigint, "firmware_version" text, "ip" text, "free_memory" bigint, "interval" bigint, "node_id" bigint, PRIMARY KEY ("id"))`,
+		),
+		Down: execStatements(
+			`DROP TABLE "node_heartbeats"`,
+		),
+	},
+	{
+		Version: 4,
+		Name:    "create_alert_tables",
+		Up: execStatements(
+			`CREATE TABLE "alert_rules" ("id" bigserial, "timestamp" timestamptz, "name" text, "node_id" bigint, "type" smallint, "field" text, "operator" text, "threshold" double precision, "for" bigint, "hysteresis" double precision, "chat_id" bigint, PRIMARY KEY ("id"))`,
+			`CREATE TABLE "alert_events" ("id" bigserial, "timestamp" timestamptz, "node_id" bigint, "state" text, "value" double precision, "rule_id" bigint, PRIMARY KEY ("id"))`,
+		),
+		Down: execStatements(
+			`DROP TABLE "alert_events"`,
+			`DROP TABLE "alert_rules"`,
+		),
+	},
+	{
+		Version: 5,
+		Name:    "create_parking_tables",
+		Up: execStatements(
+			`CREATE TABLE "parking_garages" ("id" bigserial, "timestamp" timestamptz, "name" text, PRIMARY KEY ("id"), UNIQUE ("name"))`,
+			`CREATE TABLE "parking_floor_samples" ("id" bigserial, "timestamp" timestamptz, "floor" bigint, "altitude" real, "garage_id" bigint, "node_id" bigint, "barometer_state_id" bigint, PRIMARY KEY ("id"))`,
+			`CREATE TABLE "parking_floor_bands" ("id" bigserial, "timestamp" timestamptz, "floor" bigint, "altitude" real, "min_altitude" real, "max_altitude" real, "samples" bigint, "garage_id" bigint, "node_id" bigint, PRIMARY KEY ("id"))`,
+		),
+		Down: execStatements(
+			`DROP TABLE "parking_floor_bands"`,
+			`DROP TABLE "parking_floor_samples"`,
+			`DROP TABLE "parking_garages"`,
+		),
+	},
+	{
+		// Altitudes relative to a garage's reference barometer, compensating for
+		// pressure drift.
+		Version: 6,
+		Name:    "add_parking_relative_altitudes",
+		Up: execStatements(
+			`ALTER TABLE "parking_garages" ADD COLUMN "reference_node_id" bigint`,
+			`ALTER TABLE "parking_floor_samples" ADD COLUMN "relative_altitude" real`,
+			`ALTER TABLE "parking_floor_bands" ADD COLUMN "relative_altitude" real, ADD COLUMN "min_relative_altitude" real, ADD COLUMN "max_relative_altitude" real, ADD COLUMN "relative_samples" bigint`,
+		),
+		Down: execStatements(
+			`ALTER TABLE "parking_floor_bands" DROP COLUMN "relative_altitude", DROP COLUMN "min_relative_altitude", DROP COLUMN "max_relative_altitude", DROP COLUMN "relative_samples"`,
+			`ALTER TABLE "parking_floor_samples" DROP COLUMN "relative_altitude"`,
+			`ALTER TABLE "parking_garages" DROP COLUMN "reference_node_id"`,
+		),
+	},
+	{
+		Version: 7,
+		Name:    "create_parking_sessions",
+		Up: execStatements(
+			`CREATE TABLE "parking_sessions" ("id" bigserial, "timestamp" timestamptz, "started_at" timestamptz, "ended_at" timestamptz, "start_reason" text, "floor" bigint, "confidence" double precision, "altitude" real, "garage_id" bigint, "node_id" bigint, PRIMARY KEY ("id"))`,
+		),
+		Down: execStatements(
+			`DROP TABLE "parking_sessions"`,
+		),
+	},
+	{
+		Version: 8,
+		Name:    "create_telegram_accesses",
+		Up: execStatements(
+			`CREATE TABLE "telegram_accesses" ("id" bigserial, "timestamp" timestamptz, "kind" text, "subject_id" bigint, "role" text, "granted_by" bigint, PRIMARY KEY ("id"), UNIQUE ("kind", "subject_id"))`,
+		),
+		Down: execStatements(
+			`DROP TABLE "telegram_accesses"`,
+		),
+	},
+	{
+		Version: 9,
+		Name:    "create_notify_routes",
+		Up: execStatements(
+			`CREATE TABLE "notify_routes" ("id" bigserial, "timestamp" timestamptz, "name" text, "kind" text, "config" jsonb, PRIMARY KEY ("id"), UNIQUE ("name"))`,
+			`ALTER TABLE "alert_rules" ADD COLUMN "route" text`,
+		),
+		Down: execStatements(
+			`ALTER TABLE "alert_rules" DROP COLUMN "route"`,
+			`DROP TABLE "notify_routes"`,
+		),
+	},
+	{
+		Version: 10,
+		Name:    "create_schedules",
+		Up: execStatements(
+			`CREATE TABLE "schedules" ("id" bigserial, "timestamp" timestamptz, "cron" text, "report" text, "node_id" bigint, "route" text, "chat_id" bigint, "last_run_at" timestamptz, PRIMARY KEY ("id"))`,
+		),
+		Down: execStatements(
+			`DROP TABLE "schedules"`,
+		),
+	},
+	{
+		Version: 11,
+		Name:    "add_camera_overlays",
+		Up: execStatements(
+			`ALTER TABLE "camera_adjsustments" ADD COLUMN "overlay_name" boolean, ADD COLUMN "overlay_timestamp" boolean, ADD COLUMN "overlay_text" text, ADD COLUMN "overlay_corner" text`,
+		),
+		Down: execStatements(
+			`ALTER TABLE "camera_adjsustments" DROP COLUMN "overlay_name", DROP COLUMN "overlay_timestamp", DROP COLUMN "overlay_text", DROP COLUMN "overlay_corner"`,
+		),
+	},
+	{
+		Version: 12,
+		Name:    "add_camera_privacy_masks",
+		Up: execStatements(
+			`ALTER TABLE "camera_adjsustments" ADD COLUMN "privacy_masks" jsonb`,
+		),
+		Down: execStatements(
+			`ALTER TABLE "camera_adjsustments" DROP COLUMN "privacy_masks"`,
+		),
+	},
+	{
+		Version: 13,
+		Name:    "create_camera_groups",
+		Up: execStatements(
+			`CREATE TABLE "camera_groups" ("id" bigserial, "timestamp" timestamptz, "name" text, "tags" text[], PRIMARY KEY ("id"), UNIQUE ("name"))`,
+			`CREATE TABLE "camera_group_members" ("id" bigserial, "timestamp" timestamptz, "group_id" bigint, "camera_id" bigint, PRIMARY KEY ("id"), UNIQUE ("group_id", "camera_id"))`,
+		),
+		Down: execStatements(
+			`DROP TABLE "camera_group_members"`,
+			`DROP TABLE "camera_groups"`,
+		),
+	},
+	{
+		// Streaming path, allowing multiple streams per host. Existing cameras
+		// stream from the default path.
+		Version: 14,
+		Name:    "add_camera_stream_path",
+		Up: execStatements(
+			`ALTER TABLE "camera_entries" ADD COLUMN "path" text`,
+			`UPDATE "camera_entries" SET "path" = '/stream' WHERE "path" IS NULL`,
+		),
+		Down: execStatements(
+			`ALTER TABLE "camera_entries" DROP COLUMN "path"`,
+		),
+	},
+	{
+		// Hourly aggregates which raw node telemetry is rolled into.
+		Version: 15,
+		Name:    "create_telemetry_aggregates",
+		Up: execStatements(
+			`CREATE TABLE "node_power_aggregates" ("id" bigserial, "timestamp" timestamptz, "samples" bigint, "min_current_ma" real, "avg_current_ma" real, "max_current_ma" real, "min_load_voltage" real, "avg_load_voltage" real, "max_load_voltage" real, "min_power_mw" real, "avg_power_mw" real, "max_power_mw" real, "node_id" bigint, PRIMARY KEY ("id"))`,
+			`CREATE TABLE "node_barometer_aggregates" ("id" bigserial, "timestamp" timestamptz, "samples" bigint, "min_pressure" real, "avg_pressure" real, "max_pressure" real, "min_temperature" real, "avg_temperature" real, "max_temperature" real, "min_altitude" real, "avg_altitude" real, "max_altitude" real, "node_id" bigint, PRIMARY KEY ("id"))`,
+		),
+		Down: execStatements(
+			`DROP TABLE "node_barometer_aggregates"`,
+			`DROP TABLE "node_power_aggregates"`,
+		),
+	},
+	{
+		// Indexes of node history, queried per node by time, ie. the last
+		// heartbeat of each node.
+		Version: 16,
+		Name:    "index_node_history",
+		Up: execStatements(
+			`CREATE INDEX "node_heartbeats_node_id_timestamp" ON "node_heartbeats" ("node_id", "timestamp")`,
+			`CREATE INDEX "node_power_states_node_id_timestamp" ON "node_power_states" ("node_id", "timestamp")`,
+			`CREATE INDEX "node_barometer_states_node_id_timestamp" ON "node_barometer_states" ("node_id", "timestamp")`,
+		),
+		Down: execStatements(
+			`DROP INDEX "node_barometer_states_node_id_timestamp"`,
+			`DROP INDEX "node_power_states_node_id_timestamp"`,
+			`DROP INDEX "node_heartbeats_node_id_timestamp"`,
+		),
+	},
+	{
+		// Foreign keys between camera tables. Group memberships are removed along
+		// with their camera or group, while an adjustment can't be removed while a
+		// camera refers to it. A camera's adjustment is removed along with the
+		// camera within the same transaction, as the camera is the referencing side.
+		Version: 17,
+		Name:    "add_camera_foreign_keys",
+		Up: func(db pg.DBI) error {
+			// Orphan rows left behind prior to the foreign keys prevent adding them.
+			orphans, err := FindCameraOrphans(db)
+			if err != nil {
+				return err
+			}
+			if orphans.Count() > 0 {
+				return fmt.Errorf("found %d orphan camera rows, clean them with 'maintenance orphans --clean'", orphans.Count())
+			}
+
+			return execStatements(
+				`ALTER TABLE "camera_entries" ADD CONSTRAINT "camera_entries_adjustment_fkey" FOREIGN KEY ("adjustment_id") REFERENCES "camera_adjsustments" ("id") ON DELETE RESTRICT`,
+				`ALTER TABLE "camera_group_members" ADD CONSTRAINT "camera_group_members_camera_id_fkey" FOREIGN KEY ("camera_id") REFERENCES "camera_entries" ("id") ON DELETE CASCADE`,
+				`ALTER TABLE "camera_group_members" ADD CONSTRAINT "camera_group_members_group_id_fkey" FOREIGN KEY ("group_id") REFERENCES "camera_groups" ("id") ON DELETE CASCADE`,
+			)(db)
+		},
+		Down: execStatements(
+			`ALTER TABLE "camera_group_members" DROP CONSTRAINT "camera_group_members_group_id_fkey"`,
+			`ALTER TABLE "camera_group_members" DROP CONSTRAINT "camera_group_members_camera_id_fkey"`,
+			`ALTER TABLE "camera_entries" DROP CONSTRAINT "camera_entries_adjustment_fkey"`,
+		),
+	},
+}
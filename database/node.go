@@ -1,14 +1,219 @@
 package database
 
 import (
+	"context"
 	"fmt"
+	"log"
+	"time"
+
+	"github.com/go-pg/pg/v10"
 )
 
+// Models holding a node's history, keyed by their node_id column. These are
+// carried over whenever a node gets merged into another.
+var nodeHistoryModels = []interface{}{
+	(*NodeFingerprint)(nil),
+	(*NodePowerState)(nil),
+	(*NodeBarometerState)(nil),
+	(*NodePowerAggregate)(nil),
+	(*NodeBarometerAggregate)(nil),
+	(*NodeHeartbeat)(nil),
+	(*AlertRule)(nil),
+	(*AlertEvent)(nil),
+	(*ParkingFloorSample)(nil),
+	(*ParkingFloorBand)(nil),
+	(*ParkingSession)(nil),
+}
+
 // Query the database to get the node with the matching fingerprint.
+// The fingerprint is checked against the node's associated fingerprints and their
+// validity windows, falling back on the node's certificate fingerprint for nodes
+// which were created prior to fingerprint tracking.
 func GetNodeByFingerprint(fingerprint string) (*Node, error) {
+	now := time.Now().UTC()
+
+	nodeFingerprint := NodeFingerprint{}
+	err := DbInstance.Model(&nodeFingerprint).
+		Relation("Node").
+		Where("node_fingerprint.fingerprint = ?", fingerprint).
+		Select()
+	if err == nil {
+		if nodeFingerprint.ValidFrom.After(now) {
+			return nil, fmt.Errorf("fingerprint '%s' is not valid until %v", fingerprint, nodeFingerprint.ValidFrom)
+		}
+		if nodeFingerprint.ValidUntil != nil && !nodeFingerprint.ValidUntil.After(now) {
+			return nil, fmt.Errorf("fingerprint '%s' expired on %v", fingerprint, *nodeFingerprint.ValidUntil)
+		}
+
+		// Promote a successor fingerprint to the node's current fingerprint on first use.
+		node := nodeFingerprint.Node
+		if node.CertificateFingerprint != fingerprint {
+			log.Printf("Node[%d] rotated to fingerprint '%s'", node.Id, fingerprint)
+			node.CertificateFingerprint = fingerprint
+			if _, err := DbInstance.Model(node).Column("certificate_fingerprint").WherePK().Update(); err != nil {
+				log.Printf("Failed to update node[%d] current fingerprint: %v", node.Id, err)
+			}
+		}
+		return node, nil
+	} else if err != pg.ErrNoRows {
+		return nil, fmt.Errorf("failed to query fingerprint '%s': %v", fingerprint, err)
+	}
+
+	// Fallback on legacy nodes, registering their fingerprint.
 	node := Node{}
 	if err := DbInstance.Model(&node).Where("node.certificate_fingerprint = ?", fingerprint).Select(); err != nil {
 		return nil, fmt.Errorf("failed to find node with fingerprint '%s'", fingerprint)
 	}
+	if _, err := AddNodeFingerprint(DbInstance, node.Id, fingerprint, node.Timestamp, nil); err != nil {
+		log.Printf("Failed to register legacy fingerprint for node[%d]: %v", node.Id, err)
+	}
 	return &node, nil
 }
+
+// Query all fingerprints associated with a given node.
+func GetNodeFingerprints(nodeId uint64) ([]NodeFingerprint, error) {
+	fingerprints := []NodeFingerprint{}
+	if err := DbInstance.Model(&fingerprints).
+		Where("node_id = ?", nodeId).
+		Order("valid_from ASC").
+		Select(); err != nil {
+		return nil, fmt.Errorf("failed to query fingerprints for node %d: %v", nodeId, err)
+	}
+	return fingerprints, nil
+}
+
+// Associates a new fingerprint with a given node.
+func AddNodeFingerprint(db pg.DBI, nodeId uint64, fingerprint string, validFrom time.Time, validUntil *time.Time) (*NodeFingerprint, error) {
+	nodeFingerprint := NodeFingerprint{
+		Fingerprint: fingerprint,
+		ValidFrom:   validFrom,
+		ValidUntil:  validUntil,
+		NodeId:      nodeId,
+	}
+	nodeFingerprint.Timestamp = time.Now().UTC()
+
+	if _, err := db.Model(&nodeFingerprint).Insert(); err != nil {
+		return nil, fmt.Errorf("failed to add fingerprint '%s' to node %d: %v", fingerprint, nodeId, err)
+	}
+	return &nodeFingerprint, nil
+}
+
+// Registers a successor fingerprint for the given node, which becomes valid at
+// validFrom. The node's current fingerprint is retired at retireAt, if given.
+func RotateNodeFingerprint(node *Node, successor string, validFrom time.Time, retireAt *time.Time) (*NodeFingerprint, error) {
+	var nodeFingerprint *NodeFingerprint
+	err := DbInstance.RunInTransaction(context.Background(), func(tx *pg.Tx) error {
+		var err error
+		if nodeFingerprint, err = AddNodeFingerprint(tx, node.Id, successor, validFrom, nil); err != nil {
+			return err
+		}
+
+		if retireAt == nil {
+			return nil
+		}
+		if _, err := tx.Model((*NodeFingerprint)(nil)).
+			Set("valid_until = ?", *retireAt).
+			Where("node_id = ?", node.Id).
+			Where("fingerprint = ?", node.CertificateFingerprint).
+			Update(); err != nil {
+			return fmt.Errorf("failed to retire fingerprint '%s': %v", node.CertificateFingerprint, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return nodeFingerprint, nil
+}
+
+// Queries the node owning a fingerprint through the given connection or transaction.
+func getFingerprintOwner(db pg.DBI, fingerprint string) (*Node, error) {
+	owner := Node{}
+	err := db.Model(&owner).
+		Where("node.id IN (SELECT node_id FROM node_fingerprints WHERE fingerprint = ?)", fingerprint).
+		WhereOr("node.certificate_fingerprint = ?", fingerprint).
+		First()
+	if err == pg.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to query owner of fingerprint '%s': %v", fingerprint, err)
+	}
+	return &owner, nil
+}
+
+// Query the node owning a fingerprint, regardless of its validity window.
+// Returns nil when no node owns the fingerprint.
+func GetFingerprintOwner(fingerprint string) (*Node, error) {
+	return getFingerprintOwner(DbInstance, fingerprint)
+}
+
+// Links a fingerprint to an existing node. Should the fingerprint already belong
+// to a different node, that node's history is merged into the given node and the
+// orphaned node is removed.
+func RelinkNodeFingerprint(nodeId uint64, fingerprint string, validFrom time.Time, validUntil *time.Time) (*NodeFingerprint, error) {
+	var nodeFingerprint *NodeFingerprint
+	err := DbInstance.RunInTransaction(context.Background(), func(tx *pg.Tx) error {
+		node := Node{}
+		if err := tx.Model(&node).Where("node.id = ?", nodeId).Select(); err != nil {
+			return fmt.Errorf("failed to find node %d: %v", nodeId, err)
+		}
+
+		// Find the node currently owning the fingerprint, if any.
+		orphan, err := getFingerprintOwner(tx, fingerprint)
+		if err != nil {
+			return err
+		}
+
+		if orphan != nil && orphan.Id != node.Id {
+			log.Printf("Merging node[%d] into node[%d]", orphan.Id, node.Id)
+			for _, model := range nodeHistoryModels {
+				if _, err := tx.Model(model).
+					Set("node_id = ?", node.Id).
+					Where("node_id = ?", orphan.Id).
+					Update(); err != nil {
+					return fmt.Errorf("failed to merge node %d history: %v", orphan.Id, err)
+				}
+			}
+			if _, err := tx.Model(orphan).WherePK().Delete(); err != nil {
+				return fmt.Errorf("failed to remove orphaned node %d: %v", orphan.Id, err)
+			}
+		}
+
+		// Re-create the fingerprint's association with the given window.
+		if _, err := tx.Model((*NodeFingerprint)(nil)).Where("fingerprint = ?", fingerprint).Delete(); err != nil {
+			return fmt.Errorf("failed to clear fingerprint '%s': %v", fingerprint, err)
+		}
+		nodeFingerprint, err = AddNodeFingerprint(tx, node.Id, fingerprint, validFrom, validUntil)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return nodeFingerprint, nil
+}
+
+// Query all nodes.
+func GetNodes() ([]Node, error) {
+	nodes := []Node{}
+	if err := DbInstance.Model(&nodes).Order("id ASC").Select(); err != nil {
+		return nil, fmt.Errorf("failed to query nodes: %v", err)
+	}
+	return nodes, nil
+}
+
+// Query the last heartbeat of each node, keyed by node id.
+func GetLastHeartbeats() (map[uint64]NodeHeartbeat, error) {
+	heartbeats := []NodeHeartbeat{}
+	if err := DbInstance.Model(&heartbeats).
+		DistinctOn("node_id").
+		Order("node_id ASC", "timestamp DESC").
+		Select(); err != nil {
+		return nil, fmt.Errorf("failed to query last heartbeats: %v", err)
+	}
+
+	heartbeatMp := map[uint64]NodeHeartbeat{}
+	for _, heartbeat := range heartbeats {
+		heartbeatMp[heartbeat.NodeId] = heartbeat
+	}
+	return heartbeatMp, nil
+}
@@ -1,9 +1,9 @@
 package database
 
 import (
+	"time"
+
 	"4bit.api/v0/server/route/node/interfaces"
-	"github.com/go-pg/pg/v10"
-	"github.com/go-pg/pg/v10/orm"
 )
 
 type Node struct {
@@ -11,40 +11,45 @@ type Node struct {
 	CertificateFingerprint string
 }
 
-type NodePowerState struct {
+// Certificate fingerprint associated with a node. A node may own several
+// fingerprints throughout its lifetime, which allows for rotating certificates
+// without losing the node's history.
+type NodeFingerprint struct {
 	BaseEntry
-	interfaces.PowerState
+	Fingerprint string `pg:",unique"`
+
+	// Window for which the fingerprint is accepted. A nil ValidUntil never expires.
+	ValidFrom  time.Time
+	ValidUntil *time.Time
 
 	// Relationship.
 	NodeId uint64
 	Node   *Node `pg:"rel:has-one"`
 }
 
-type NodeBarometerState struct {
+type NodePowerState struct {
 	BaseEntry
-	interfaces.BarometerState
+	interfaces.PowerState
 
 	// Relationship.
 	NodeId uint64
 	Node   *Node `pg:"rel:has-one"`
 }
 
-func CreateNodeSchema(db *pg.DB) error {
-	models := []interface{}{
-		(*Node)(nil),
-		(*NodePowerState)(nil),
-		(*NodeBarometerState)(nil),
-	}
+type NodeHeartbeat struct {
+	BaseEntry
+	interfaces.Heartbeat
 
-	for _, model := range models {
-		err := db.Model(model).CreateTable(&orm.CreateTableOptions{
-			IfNotExists: true,
-		})
+	// Relationship.
+	NodeId uint64
+	Node   *Node `pg:"rel:has-one"`
+}
 
-		if err != nil {
-			return err
-		}
-	}
+type NodeBarometerState struct {
+	BaseEntry
+	interfaces.BarometerState
 
-	return nil
+	// Relationship.
+	NodeId uint64
+	Node   *Node `pg:"rel:has-one"`
 }
@@ -0,0 +1,12 @@
+package database
+
+// Named notification channel messages get routed to, ie. an email address or a
+// telegram chat.
+type NotifyRoute struct {
+	BaseEntry
+	Name string `pg:",unique"`
+	Kind string
+
+	// Kind-specific options, ie. "url" of a webhook or "chat_id" of a telegram chat.
+	Config map[string]string
+}
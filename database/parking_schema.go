@@ -0,0 +1,73 @@
+package database
+
+import "time"
+
+type ParkingGarage struct {
+	BaseEntry
+	Name string `pg:",unique"`
+
+	// Stationary barometer node used to compensate for pressure drift. Zero if none.
+	ReferenceNodeId uint64
+}
+
+// Barometer altitude recorded while a node was parked on a known floor.
+type ParkingFloorSample struct {
+	BaseEntry
+	Floor    int
+	Altitude float32
+
+	// Altitude relative to the garage's reference node. Nil if there was no
+	// reference reading.
+	RelativeAltitude *float32
+
+	// Relationships.
+	GarageId         uint64
+	Garage           *ParkingGarage `pg:"rel:has-one"`
+	NodeId           uint64
+	Node             *Node `pg:"rel:has-one"`
+	BarometerStateId uint64
+	BarometerState   *NodeBarometerState `pg:"rel:has-one"`
+}
+
+// Altitude band of a garage floor for a given node, calibrated from that
+// node's floor samples.
+type ParkingFloorBand struct {
+	BaseEntry
+	Floor       int
+	Altitude    float32 // Mean altitude of the samples.
+	MinAltitude float32
+	MaxAltitude float32
+	Samples     uint64
+
+	// Band relative to the garage's reference node, calibrated from the samples
+	// which had a reference reading.
+	RelativeAltitude    float32
+	MinRelativeAltitude float32
+	MaxRelativeAltitude float32
+	RelativeSamples     uint64
+
+	// Relationships.
+	GarageId uint64
+	Garage   *ParkingGarage `pg:"rel:has-one"`
+	NodeId   uint64
+	Node     *Node `pg:"rel:has-one"`
+}
+
+// Period during which a vehicle node stayed parked.
+type ParkingSession struct {
+	BaseEntry
+	StartedAt   time.Time
+	EndedAt     *time.Time // Nil while still parked.
+	StartReason string
+
+	// Inferred floor at the start of the session. Nil if unknown.
+	Floor      *int
+	Confidence float64
+	Altitude   float32
+
+	// Relationships.
+	GarageId uint64
+	Garage   *ParkingGarage `pg:"rel:has-one"`
+	NodeId   uint64
+	Node     *Node `pg:"rel:has-one"`
+}
@@ -0,0 +1,316 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
+)
+
+// Camera repository backed by postgres.
+type postgresCameraRepository struct {
+	db *pg.DB
+}
+
+// Node repository backed by postgres.
+type postgresNodeRepository struct {
+	db *pg.DB
+}
+
+// Node state repository backed by postgres.
+type postgresNodeStateRepository struct {
+	db *pg.DB
+}
+
+// NewPostgresStore constructs the storage backend over an established postgres
+// connection.
+func NewPostgresStore(db *pg.DB) *Store {
+	return &Store{
+		Backend:    POSTGRES_BACKEND,
+		Cameras:    &postgresCameraRepository{db: db},
+		Nodes:      &postgresNodeRepository{db: db},
+		NodeStates: &postgresNodeStateRepository{db: db},
+	}
+}
+
+func (repo *postgresCameraRepository) List(limit int) ([]CameraEntry, error) {
+	cameras := []CameraEntry{}
+	query := repo.db.Model(&cameras).Relation("Adjustment").Order("camera_entry.id ASC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	if err := query.Select(); err != nil {
+		return nil, fmt.Errorf("failed to query camera entries: %v", err)
+	}
+	return cameras, nil
+}
+
+func (repo *postgresCameraRepository) Get(id uint64) (*CameraEntry, error) {
+	camEntry := CameraEntry{}
+	if err := repo.db.Model(&camEntry).
+		Relation("Adjustment").
+		Where("camera_entry.id = ?", id).
+		Select(); err != nil {
+		if err == pg.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to query camera entry[%d]: %v", id, err)
+	}
+	return &camEntry, nil
+}
+
+func (repo *postgresCameraRepository) FindByIP(ip string) ([]CameraEntry, error) {
+	cameras := []CameraEntry{}
+	if err := repo.db.Model(&cameras).
+		Relation("Adjustment").
+		Where("camera_entry.ip = ?", ip).
+		Order("camera_entry.id ASC").
+		Select(); err != nil {
+		return nil, fmt.Errorf("failed to query camera entries with ip '%s': %v", ip, err)
+	}
+	return cameras, nil
+}
+
+func (repo *postgresCameraRepository) StreamExists(ip string, port uint16, path string, excludeId uint64) (bool, error) {
+	exists, err := repo.db.Model((*CameraEntry)(nil)).
+		Where("camera_entry.ip = ?", ip).
+		Where("camera_entry.port = ?", port).
+		Where("camera_entry.path = ?", path).
+		Where("camera_entry.id != ?", excludeId).
+		Exists()
+	if err != nil {
+		return false, fmt.Errorf("failed to query camera stream: %v", err)
+	}
+	return exists, nil
+}
+
+func (repo *postgresCameraRepository) Add(camEntry *CameraEntry) error {
+	return repo.db.RunInTransaction(context.Background(), func(tx *pg.Tx) error {
+		if _, err := tx.Model(camEntry.Adjustment).Insert(); err != nil {
+			return fmt.Errorf("failed to add camera adjustment: %v", err)
+		}
+
+		camEntry.AdjustmentId = camEntry.Adjustment.Id
+		if _, err := tx.Model(camEntry).Insert(); err != nil {
+			return fmt.Errorf("failed to add camera entry: %v", err)
+		}
+		return nil
+	})
+}
+
+func (repo *postgresCameraRepository) Update(camEntry *CameraEntry) error {
+	if _, err := repo.db.Model(camEntry).
+		Column("name", "ip", "port", "path", "modified_at").
+		WherePK().
+		Update(); err != nil {
+		return fmt.Errorf("failed to update camera entry[%d]: %v", camEntry.Id, err)
+	}
+	return nil
+}
+
+func (repo *postgresCameraRepository) UpdateAdjustment(adjustment *CameraAdjsustment) error {
+	if _, err := repo.db.Model(adjustment).WherePK().Update(); err != nil {
+		return fmt.Errorf("failed to update camera adjustment[%d]: %v", adjustment.Id, err)
+	}
+	return nil
+}
+
+func (repo *postgresCameraRepository) Remove(camEntry *CameraEntry) error {
+	return repo.db.RunInTransaction(context.Background(), func(tx *pg.Tx) error {
+		if _, err := tx.Model((*CameraGroupMember)(nil)).Where("camera_id = ?", camEntry.Id).Delete(); err != nil {
+			return fmt.Errorf("failed to remove camera group memberships: %v", err)
+		}
+		if _, err := tx.Model(camEntry).WherePK().Delete(); err != nil {
+			return fmt.Errorf("failed to remove camera entry: %v", err)
+		}
+		if _, err := tx.Model((*CameraAdjsustment)(nil)).Where("id = ?", camEntry.AdjustmentId).Delete(); err != nil {
+			return fmt.Errorf("failed to remove camera adjustment: %v", err)
+		}
+		return nil
+	})
+}
+
+func (repo *postgresCameraRepository) ListGroups() ([]CameraGroup, error) {
+	groups := []CameraGroup{}
+	if err := repo.db.Model(&groups).Order("name ASC").Select(); err != nil {
+		return nil, fmt.Errorf("failed to query camera groups: %v", err)
+	}
+	return groups, nil
+}
+
+func (repo *postgresCameraRepository) GetGroup(name string) (*CameraGroup, error) {
+	group := CameraGroup{}
+	if err := repo.db.Model(&group).Where("name = ?", name).First(); err != nil {
+		if err == pg.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to query camera group '%s': %v", name, err)
+	}
+	return &group, nil
+}
+
+func (repo *postgresCameraRepository) GetGroupCameras(group string, tag string) ([]CameraEntry, error) {
+	if group != "" {
+		if _, err := repo.GetGroup(group); err == ErrNotFound {
+			return nil, fmt.Errorf("camera group '%s' not found", group)
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	cameras := []CameraEntry{}
+	query := repo.db.Model(&cameras).
+		Relation("Adjustment").
+		Where("camera_entry.id IN (?)", repo.db.Model((*CameraGroupMember)(nil)).
+			Column("camera_group_member.camera_id").
+			Join("JOIN camera_groups AS camera_group ON camera_group.id = camera_group_member.group_id").
+			Apply(func(query *orm.Query) (*orm.Query, error) {
+				if group != "" {
+					query = query.Where("camera_group.name = ?", group)
+				}
+				if tag != "" {
+					query = query.Where("? = ANY(camera_group.tags)", tag)
+				}
+				return query, nil
+			})).
+		Order("camera_entry.id ASC")
+	if err := query.Select(); err != nil {
+		return nil, fmt.Errorf("failed to query cameras of group '%s' with tag '%s': %v", group, tag, err)
+	}
+	return cameras, nil
+}
+
+func (repo *postgresCameraRepository) SaveGroup(group *CameraGroup) error {
+	if _, err := repo.db.Model(group).
+		OnConflict("(name) DO UPDATE").
+		Set("tags = EXCLUDED.tags").
+		Returning("id, timestamp").
+		Insert(); err != nil {
+		return fmt.Errorf("failed to save camera group '%s': %v", group.Name, err)
+	}
+	return nil
+}
+
+func (repo *postgresCameraRepository) RemoveGroup(group *CameraGroup) error {
+	return repo.db.RunInTransaction(context.Background(), func(tx *pg.Tx) error {
+		if _, err := tx.Model((*CameraGroupMember)(nil)).Where("group_id = ?", group.Id).Delete(); err != nil {
+			return fmt.Errorf("failed to remove camera group members: %v", err)
+		}
+		if _, err := tx.Model(group).WherePK().Delete(); err != nil {
+			return fmt.Errorf("failed to remove camera group '%s': %v", group.Name, err)
+		}
+		return nil
+	})
+}
+
+func (repo *postgresCameraRepository) AssignGroup(groupId uint64, cameraIds []uint64) error {
+	if len(cameraIds) == 0 {
+		return nil
+	}
+
+	members := []CameraGroupMember{}
+	for _, cameraId := range cameraIds {
+		member := CameraGroupMember{
+			GroupId:  groupId,
+			CameraId: cameraId,
+		}
+		member.Timestamp = time.Now().UTC()
+		members = append(members, member)
+	}
+	if _, err := repo.db.Model(&members).OnConflict("(group_id, camera_id) DO NOTHING").Insert(); err != nil {
+		return fmt.Errorf("failed to assign cameras to group[%d]: %v", groupId, err)
+	}
+	return nil
+}
+
+func (repo *postgresCameraRepository) UnassignGroup(groupId uint64, cameraIds []uint64) (int, error) {
+	if len(cameraIds) == 0 {
+		return 0, nil
+	}
+
+	res, err := repo.db.Model((*CameraGroupMember)(nil)).
+		Where("group_id = ?", groupId).
+		WhereIn("camera_id IN (?)", cameraIds).
+		Delete()
+	if err != nil {
+		return 0, fmt.Errorf("failed to unassign cameras from group[%d]: %v", groupId, err)
+	}
+	return res.RowsAffected(), nil
+}
+
+func (repo *postgresNodeRepository) List() ([]Node, error) {
+	return GetNodes()
+}
+
+func (repo *postgresNodeRepository) GetByFingerprint(fingerprint string) (*Node, error) {
+	return GetNodeByFingerprint(fingerprint)
+}
+
+func (repo *postgresNodeRepository) FingerprintExists(fingerprint string) (bool, error) {
+	exists, err := repo.db.Model((*NodeFingerprint)(nil)).Where("fingerprint = ?", fingerprint).Exists()
+	if err != nil {
+		return false, fmt.Errorf("failed to query fingerprint '%s': %v", fingerprint, err)
+	}
+	return exists, nil
+}
+
+func (repo *postgresNodeRepository) Add(node *Node) error {
+	return repo.db.RunInTransaction(context.Background(), func(tx *pg.Tx) error {
+		if _, err := tx.Model(node).Insert(); err != nil {
+			return fmt.Errorf("failed to add node: %v", err)
+		}
+		_, err := AddNodeFingerprint(tx, node.Id, node.CertificateFingerprint, node.Timestamp, nil)
+		return err
+	})
+}
+
+func (repo *postgresNodeStateRepository) AddPowerState(state *NodePowerState) error {
+	if _, err := repo.db.Model(state).Insert(); err != nil {
+		return fmt.Errorf("failed to add power state: %v", err)
+	}
+	return nil
+}
+
+func (repo *postgresNodeStateRepository) AddBarometerState(state *NodeBarometerState) error {
+	if _, err := repo.db.Model(state).Insert(); err != nil {
+		return fmt.Errorf("failed to add barometer state: %v", err)
+	}
+	return nil
+}
+
+func (repo *postgresNodeStateRepository) AddHeartbeat(heartbeat *NodeHeartbeat) error {
+	if _, err := repo.db.Model(heartbeat).Insert(); err != nil {
+		return fmt.Errorf("failed to add heartbeat: %v", err)
+	}
+	return nil
+}
+
+func (repo *postgresNodeStateRepository) GetPowerStates(nodeId uint64, limit int) ([]NodePowerState, error) {
+	powerStates := []NodePowerState{}
+	if err := repo.db.Model(&powerStates).
+		Relation("Node").
+		Where("node_id = ?", nodeId).
+		Limit(limit).
+		Select(); err != nil {
+		return nil, fmt.Errorf("failed to query power states of node[%d]: %v", nodeId, err)
+	}
+	return powerStates, nil
+}
+
+func (repo *postgresNodeStateRepository) GetBarometerStates(nodeId uint64, limit int) ([]NodeBarometerState, error) {
+	barometerStates := []NodeBarometerState{}
+	if err := repo.db.Model(&barometerStates).
+		Relation("Node").
+		Where("node_id = ?", nodeId).
+		Limit(limit).
+		Select(); err != nil {
+		return nil, fmt.Errorf("failed to query barometer states of node[%d]: %v", nodeId, err)
+	}
+	return barometerStates, nil
+}
+
+func (repo *postgresNodeStateRepository) GetLastHeartbeats() (map[uint64]NodeHeartbeat, error) {
+	return GetLastHeartbeats()
+}
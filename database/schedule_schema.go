@@ -0,0 +1,20 @@
+package database
+
+import "time"
+
+// Report delivered periodically, ie. a morning snapshot of all cameras.
+type Schedule struct {
+	BaseEntry
+	Cron   string // Cron expression in the server's local time, ie. "0 8 * * *".
+	Report string
+
+	// Node the report is limited to. A zero NodeId reports on all nodes.
+	NodeId uint64
+
+	// Notification route to deliver the report to. When empty, the report is
+	// delivered to the telegram chat.
+	Route  string
+	ChatId int64
+
+	LastRunAt *time.Time
+}
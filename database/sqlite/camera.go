@@ -0,0 +1,386 @@
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"4bit.api/v0/database"
+)
+
+// Camera repository backed by sqlite.
+type cameraRepository struct {
+	db *sql.DB
+}
+
+// Columns of a camera entry joined with its adjustment.
+const cameraColumns = `
+	camera_entries.id, camera_entries.name, camera_entries.created_at,
+	camera_entries.modified_at, camera_entries.ip, camera_entries.port,
+	camera_entries.path, camera_entries.adjustment_id,
+	camera_adjsustments.id, camera_adjsustments.timestamp,
+	camera_adjsustments.crop_frame_height, camera_adjsustments.crop_frame_width,
+	camera_adjsustments.crop_framex, camera_adjsustments.crop_framey,
+	camera_adjsustments.rotate, camera_adjsustments.overlay_name,
+	camera_adjsustments.overlay_timestamp, camera_adjsustments.overlay_text,
+	camera_adjsustments.overlay_corner, camera_adjsustments.privacy_masks`
+
+// Selects camera entries along with their adjustments.
+const selectCameras = `SELECT ` + cameraColumns + `
+	FROM camera_entries
+	LEFT JOIN camera_adjsustments ON camera_adjsustments.id = camera_entries.adjustment_id`
+
+// queryCameras queries camera entries along with their adjustments.
+func queryCameras(db execer, query string, args ...interface{}) ([]database.CameraEntry, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cameras := []database.CameraEntry{}
+	for rows.Next() {
+		camEntry := database.CameraEntry{}
+		var (
+			adjustmentId     sql.NullInt64
+			adjustmentTime   sql.NullTime
+			cropHeight       sql.NullFloat64
+			cropWidth        sql.NullFloat64
+			cropX            sql.NullInt64
+			cropY            sql.NullInt64
+			rotate           sql.NullFloat64
+			overlayName      sql.NullBool
+			overlayTimestamp sql.NullBool
+			overlayText      sql.NullString
+			overlayCorner    sql.NullString
+			privacyMasks     sql.NullString
+		)
+		if err := rows.Scan(
+			&camEntry.Id, &camEntry.Name, &camEntry.CreatedAt,
+			&camEntry.ModifiedAt, &camEntry.IP, &camEntry.Port,
+			&camEntry.Path, &camEntry.AdjustmentId,
+			&adjustmentId, &adjustmentTime,
+			&cropHeight, &cropWidth,
+			&cropX, &cropY,
+			&rotate, &overlayName,
+			&overlayTimestamp, &overlayText,
+			&overlayCorner, &privacyMasks,
+		); err != nil {
+			return nil, err
+		}
+
+		// Cameras missing their adjustment are left without one.
+		if adjustmentId.Valid {
+			adjustment := &database.CameraAdjsustment{
+				CropFrameHeight:  cropHeight.Float64,
+				CropFrameWidth:   cropWidth.Float64,
+				CropFrameX:       uint64(cropX.Int64),
+				CropFrameY:       uint64(cropY.Int64),
+				Rotate:           rotate.Float64,
+				OverlayName:      overlayName.Bool,
+				OverlayTimestamp: overlayTimestamp.Bool,
+				OverlayText:      overlayText.String,
+				OverlayCorner:    overlayCorner.String,
+			}
+			adjustment.Id = uint64(adjustmentId.Int64)
+			adjustment.Timestamp = adjustmentTime.Time
+			if privacyMasks.String != "" {
+				if err := json.Unmarshal([]byte(privacyMasks.String), &adjustment.PrivacyMasks); err != nil {
+					return nil, fmt.Errorf("invalid privacy masks of camera adjustment[%d]: %v", adjustment.Id, err)
+				}
+			}
+			camEntry.Adjustment = adjustment
+		}
+		cameras = append(cameras, camEntry)
+	}
+	return cameras, rows.Err()
+}
+
+// idPlaceholders constructs the placeholders & arguments of an IN clause.
+func idPlaceholders(ids []uint64) (string, []interface{}) {
+	placeholders := []string{}
+	args := []interface{}{}
+	for _, id := range ids {
+		placeholders = append(placeholders, "?")
+		args = append(args, id)
+	}
+	return strings.Join(placeholders, ", "), args
+}
+
+func (repo *cameraRepository) List(limit int) ([]database.CameraEntry, error) {
+	query := selectCameras + ` ORDER BY camera_entries.id ASC`
+	args := []interface{}{}
+	if limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, limit)
+	}
+	cameras, err := queryCameras(repo.db, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query camera entries: %v", err)
+	}
+	return cameras, nil
+}
+
+func (repo *cameraRepository) Get(id uint64) (*database.CameraEntry, error) {
+	cameras, err := queryCameras(repo.db, selectCameras+` WHERE camera_entries.id = ?`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query camera entry[%d]: %v", id, err)
+	}
+	if len(cameras) == 0 {
+		return nil, database.ErrNotFound
+	}
+	return &cameras[0], nil
+}
+
+func (repo *cameraRepository) FindByIP(ip string) ([]database.CameraEntry, error) {
+	cameras, err := queryCameras(repo.db, selectCameras+` WHERE camera_entries.ip = ? ORDER BY camera_entries.id ASC`, ip)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query camera entries with ip '%s': %v", ip, err)
+	}
+	return cameras, nil
+}
+
+func (repo *cameraRepository) StreamExists(ip string, port uint16, path string, excludeId uint64) (bool, error) {
+	exists := false
+	if err := repo.db.QueryRow(
+		`SELECT EXISTS (SELECT 1 FROM camera_entries WHERE ip = ? AND port = ? AND path = ? AND id != ?)`,
+		ip, port, path, excludeId,
+	).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to query camera stream: %v", err)
+	}
+	return exists, nil
+}
+
+// marshalPrivacyMasks serializes the privacy masks of an adjustment.
+func marshalPrivacyMasks(adjustment *database.CameraAdjsustment) (string, error) {
+	if adjustment.PrivacyMasks == nil {
+		return "", nil
+	}
+	masks, err := json.Marshal(adjustment.PrivacyMasks)
+	if err != nil {
+		return "", fmt.Errorf("failed to serialize privacy masks: %v", err)
+	}
+	return string(masks), nil
+}
+
+func (repo *cameraRepository) Add(camEntry *database.CameraEntry) error {
+	adjustment := camEntry.Adjustment
+	masks, err := marshalPrivacyMasks(adjustment)
+	if err != nil {
+		return err
+	}
+
+	return runInTransaction(repo.db, func(tx *sql.Tx) error {
+		adjustmentId, err := insert(
+			tx,
+			`INSERT INTO camera_adjsustments (
+				timestamp, crop_frame_height, crop_frame_width, crop_framex, crop_framey,
+				rotate, overlay_name, overlay_timestamp, overlay_text, overlay_corner,
+				privacy_masks
+			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			adjustment.Timestamp, adjustment.CropFrameHeight, adjustment.CropFrameWidth,
+			adjustment.CropFrameX, adjustment.CropFrameY, adjustment.Rotate,
+			adjustment.OverlayName, adjustment.OverlayTimestamp, adjustment.OverlayText,
+			adjustment.OverlayCorner, masks,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to add camera adjustment: %v", err)
+		}
+		adjustment.Id = adjustmentId
+		camEntry.AdjustmentId = adjustmentId
+
+		camEntry.Id, err = insert(
+			tx,
+			`INSERT INTO camera_entries (
+				name, created_at, modified_at, ip, port, path, adjustment_id
+			) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			camEntry.Name, camEntry.CreatedAt, camEntry.ModifiedAt,
+			camEntry.IP, camEntry.Port, camEntry.Path, camEntry.AdjustmentId,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to add camera entry: %v", err)
+		}
+		return nil
+	})
+}
+
+func (repo *cameraRepository) Update(camEntry *database.CameraEntry) error {
+	if _, err := repo.db.Exec(
+		`UPDATE camera_entries SET name = ?, ip = ?, port = ?, path = ?, modified_at = ? WHERE id = ?`,
+		camEntry.Name, camEntry.IP, camEntry.Port, camEntry.Path, camEntry.ModifiedAt, camEntry.Id,
+	); err != nil {
+		return fmt.Errorf("failed to update camera entry[%d]: %v", camEntry.Id, err)
+	}
+	return nil
+}
+
+func (repo *cameraRepository) UpdateAdjustment(adjustment *database.CameraAdjsustment) error {
+	masks, err := marshalPrivacyMasks(adjustment)
+	if err != nil {
+		return err
+	}
+
+	if _, err := repo.db.Exec(
+		`UPDATE camera_adjsustments SET
+			timestamp = ?, crop_frame_height = ?, crop_frame_width = ?, crop_framex = ?,
+			crop_framey = ?, rotate = ?, overlay_name = ?, overlay_timestamp = ?,
+			overlay_text = ?, overlay_corner = ?, privacy_masks = ?
+		WHERE id = ?`,
+		adjustment.Timestamp, adjustment.CropFrameHeight, adjustment.CropFrameWidth,
+		adjustment.CropFrameX, adjustment.CropFrameY, adjustment.Rotate,
+		adjustment.OverlayName, adjustment.OverlayTimestamp, adjustment.OverlayText,
+		adjustment.OverlayCorner, masks, adjustment.Id,
+	); err != nil {
+		return fmt.Errorf("failed to update camera adjustment[%d]: %v", adjustment.Id, err)
+	}
+	return nil
+}
+
+func (repo *cameraRepository) Remove(camEntry *database.CameraEntry) error {
+	return runInTransaction(repo.db, func(tx *sql.Tx) error {
+		if _, err := tx.Exec(`DELETE FROM camera_group_members WHERE camera_id = ?`, camEntry.Id); err != nil {
+			return fmt.Errorf("failed to remove camera group memberships: %v", err)
+		}
+		if _, err := tx.Exec(`DELETE FROM camera_entries WHERE id = ?`, camEntry.Id); err != nil {
+			return fmt.Errorf("failed to remove camera entry: %v", err)
+		}
+		if _, err := tx.Exec(`DELETE FROM camera_adjsustments WHERE id = ?`, camEntry.AdjustmentId); err != nil {
+			return fmt.Errorf("failed to remove camera adjustment: %v", err)
+		}
+		return nil
+	})
+}
+
+// queryGroups queries camera groups, deserializing their tags.
+func queryGroups(db execer, query string, args ...interface{}) ([]database.CameraGroup, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	groups := []database.CameraGroup{}
+	for rows.Next() {
+		group := database.CameraGroup{}
+		tags := ""
+		if err := rows.Scan(&group.Id, &group.Timestamp, &group.Name, &tags); err != nil {
+			return nil, err
+		}
+		group.Tags = []string{}
+		if tags != "" {
+			if err := json.Unmarshal([]byte(tags), &group.Tags); err != nil {
+				return nil, fmt.Errorf("invalid tags of camera group '%s': %v", group.Name, err)
+			}
+		}
+		groups = append(groups, group)
+	}
+	return groups, rows.Err()
+}
+
+func (repo *cameraRepository) ListGroups() ([]database.CameraGroup, error) {
+	groups, err := queryGroups(repo.db, `SELECT id, timestamp, name, tags FROM camera_groups ORDER BY name ASC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query camera groups: %v", err)
+	}
+	return groups, nil
+}
+
+func (repo *cameraRepository) GetGroup(name string) (*database.CameraGroup, error) {
+	groups, err := queryGroups(repo.db, `SELECT id, timestamp, name, tags FROM camera_groups WHERE name = ?`, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query camera group '%s': %v", name, err)
+	}
+	if len(groups) == 0 {
+		return nil, database.ErrNotFound
+	}
+	return &groups[0], nil
+}
+
+func (repo *cameraRepository) GetGroupCameras(group string, tag string) ([]database.CameraEntry, error) {
+	if group != "" {
+		if _, err := repo.GetGroup(group); err == database.ErrNotFound {
+			return nil, fmt.Errorf("camera group '%s' not found", group)
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	// Tags are stored as a JSON array, matched through its elements.
+	query := selectCameras + ` WHERE camera_entries.id IN (
+		SELECT camera_group_members.camera_id FROM camera_group_members
+		JOIN camera_groups ON camera_groups.id = camera_group_members.group_id
+		WHERE (? = '' OR camera_groups.name = ?)
+		AND (? = '' OR EXISTS (SELECT 1 FROM json_each(camera_groups.tags) WHERE json_each.value = ?))
+	) ORDER BY camera_entries.id ASC`
+	cameras, err := queryCameras(repo.db, query, group, group, tag, tag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query cameras of group '%s' with tag '%s': %v", group, tag, err)
+	}
+	return cameras, nil
+}
+
+func (repo *cameraRepository) SaveGroup(group *database.CameraGroup) error {
+	tags, err := json.Marshal(group.Tags)
+	if err != nil {
+		return fmt.Errorf("failed to serialize tags of camera group '%s': %v", group.Name, err)
+	}
+
+	if err := repo.db.QueryRow(
+		`INSERT INTO camera_groups (timestamp, name, tags) VALUES (?, ?, ?)
+		ON CONFLICT (name) DO UPDATE SET tags = excluded.tags
+		RETURNING id, timestamp`,
+		group.Timestamp, group.Name, string(tags),
+	).Scan(&group.Id, &group.Timestamp); err != nil {
+		return fmt.Errorf("failed to save camera group '%s': %v", group.Name, err)
+	}
+	return nil
+}
+
+func (repo *cameraRepository) RemoveGroup(group *database.CameraGroup) error {
+	return runInTransaction(repo.db, func(tx *sql.Tx) error {
+		if _, err := tx.Exec(`DELETE FROM camera_group_members WHERE group_id = ?`, group.Id); err != nil {
+			return fmt.Errorf("failed to remove camera group members: %v", err)
+		}
+		if _, err := tx.Exec(`DELETE FROM camera_groups WHERE id = ?`, group.Id); err != nil {
+			return fmt.Errorf("failed to remove camera group '%s': %v", group.Name, err)
+		}
+		return nil
+	})
+}
+
+func (repo *cameraRepository) AssignGroup(groupId uint64, cameraIds []uint64) error {
+	return runInTransaction(repo.db, func(tx *sql.Tx) error {
+		for _, cameraId := range cameraIds {
+			if _, err := tx.Exec(
+				`INSERT INTO camera_group_members (timestamp, group_id, camera_id) VALUES (?, ?, ?)
+				ON CONFLICT (group_id, camera_id) DO NOTHING`,
+				time.Now().UTC(), groupId, cameraId,
+			); err != nil {
+				return fmt.Errorf("failed to assign camera[%d] to group[%d]: %v", cameraId, groupId, err)
+			}
+		}
+		return nil
+	})
+}
+
+func (repo *cameraRepository) UnassignGroup(groupId uint64, cameraIds []uint64) (int, error) {
+	if len(cameraIds) == 0 {
+		return 0, nil
+	}
+
+	placeholders, args := idPlaceholders(cameraIds)
+	res, err := repo.db.Exec(
+		`DELETE FROM camera_group_members WHERE group_id = ? AND camera_id IN (`+placeholders+`)`,
+		append([]interface{}{groupId}, args...)...,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to unassign cameras from group[%d]: %v", groupId, err)
+	}
+	removed, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(removed), nil
+}
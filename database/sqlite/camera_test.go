@@ -0,0 +1,294 @@
+package sqlite
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"4bit.api/v0/database"
+)
+
+// addTestCamera adds a camera streaming from the given endpoint.
+func addTestCamera(t *testing.T, store *database.Store, name string, ip string, path string) *database.CameraEntry {
+	t.Helper()
+	now := time.Now().UTC()
+	camEntry := &database.CameraEntry{
+		Name:       name,
+		CreatedAt:  now,
+		ModifiedAt: now,
+		IP:         ip,
+		Port:       8080,
+		Path:       path,
+		Adjustment: &database.CameraAdjsustment{
+			CropFrameHeight: 0.5,
+			CropFrameWidth:  0.5,
+			CropFrameX:      10,
+			CropFrameY:      20,
+			Rotate:          90,
+			OverlayName:     true,
+			OverlayCorner:   "top-left",
+			PrivacyMasks: []database.CameraPrivacyMask{
+				{Points: []database.CameraMaskPoint{{X: 0, Y: 0}, {X: 0.5, Y: 0}, {X: 0.5, Y: 0.5}}},
+			},
+		},
+	}
+	camEntry.Adjustment.Timestamp = now
+	if err := store.Cameras.Add(camEntry); err != nil {
+		t.Fatalf("failed to add camera '%s': %v", name, err)
+	}
+	return camEntry
+}
+
+// cameraIds returns the IDs of the cameras, in order.
+func cameraIds(cameras []database.CameraEntry) []uint64 {
+	ids := []uint64{}
+	for _, camEntry := range cameras {
+		ids = append(ids, camEntry.Id)
+	}
+	return ids
+}
+
+func TestCameraAddAndGet(t *testing.T) {
+	store := openTestStore(t)
+	added := addTestCamera(t, store, "porch", "10.0.0.1", "/stream")
+	if added.Id == 0 || added.AdjustmentId == 0 || added.Adjustment.Id != added.AdjustmentId {
+		t.Fatalf("expected IDs to be assigned, got camera[%d] adjustment[%d]", added.Id, added.AdjustmentId)
+	}
+
+	camEntry, err := store.Cameras.Get(added.Id)
+	if err != nil {
+		t.Fatalf("failed to get camera: %v", err)
+	}
+	if camEntry.Name != "porch" || camEntry.IP != "10.0.0.1" || camEntry.Port != 8080 || camEntry.Path != "/stream" {
+		t.Errorf("unexpected camera %+v", camEntry)
+	}
+	if camEntry.Adjustment == nil {
+		t.Fatal("expected the camera's adjustment")
+	}
+	adjustment := camEntry.Adjustment
+	if adjustment.CropFrameX != 10 || adjustment.CropFrameY != 20 || adjustment.Rotate != 90 ||
+		!adjustment.OverlayName || adjustment.OverlayCorner != "top-left" {
+		t.Errorf("unexpected adjustment %+v", adjustment)
+	}
+	if !reflect.DeepEqual(adjustment.PrivacyMasks, added.Adjustment.PrivacyMasks) {
+		t.Errorf("expected privacy masks %+v, got %+v", added.Adjustment.PrivacyMasks, adjustment.PrivacyMasks)
+	}
+
+	if _, err := store.Cameras.Get(added.Id + 1); err != database.ErrNotFound {
+		t.Errorf("expected ErrNotFound for a missing camera, got %v", err)
+	}
+}
+
+func TestCameraListAndFindByIP(t *testing.T) {
+	store := openTestStore(t)
+	first := addTestCamera(t, store, "first", "10.0.0.1", "/a")
+	second := addTestCamera(t, store, "second", "10.0.0.1", "/b")
+	third := addTestCamera(t, store, "third", "10.0.0.2", "/a")
+
+	cameras, err := store.Cameras.List(0)
+	if err != nil {
+		t.Fatalf("failed to list cameras: %v", err)
+	}
+	if ids := cameraIds(cameras); !reflect.DeepEqual(ids, []uint64{first.Id, second.Id, third.Id}) {
+		t.Errorf("expected all cameras ordered by ID, got %v", ids)
+	}
+
+	cameras, err = store.Cameras.List(2)
+	if err != nil {
+		t.Fatalf("failed to list cameras: %v", err)
+	}
+	if ids := cameraIds(cameras); !reflect.DeepEqual(ids, []uint64{first.Id, second.Id}) {
+		t.Errorf("expected the limit to apply, got %v", ids)
+	}
+
+	cameras, err = store.Cameras.FindByIP("10.0.0.1")
+	if err != nil {
+		t.Fatalf("failed to find cameras: %v", err)
+	}
+	if ids := cameraIds(cameras); !reflect.DeepEqual(ids, []uint64{first.Id, second.Id}) {
+		t.Errorf("expected the host's cameras, got %v", ids)
+	}
+}
+
+func TestCameraStreamExists(t *testing.T) {
+	store := openTestStore(t)
+	camEntry := addTestCamera(t, store, "porch", "10.0.0.1", "/stream")
+
+	for _, tc := range []struct {
+		path      string
+		excludeId uint64
+		exists    bool
+	}{
+		{"/stream", 0, true},
+		{"/stream", camEntry.Id, false},
+		{"/other", 0, false},
+	} {
+		exists, err := store.Cameras.StreamExists("10.0.0.1", 8080, tc.path, tc.excludeId)
+		if err != nil {
+			t.Fatalf("failed to query stream: %v", err)
+		}
+		if exists != tc.exists {
+			t.Errorf("expected stream '%s' excluding camera[%d] to exist: %v", tc.path, tc.excludeId, tc.exists)
+		}
+	}
+}
+
+func TestCameraUpdate(t *testing.T) {
+	store := openTestStore(t)
+	camEntry := addTestCamera(t, store, "porch", "10.0.0.1", "/stream")
+
+	camEntry.Name = "garden"
+	camEntry.IP = "cam.local"
+	camEntry.Port = 81
+	camEntry.Path = "/video"
+	if err := store.Cameras.Update(camEntry); err != nil {
+		t.Fatalf("failed to update camera: %v", err)
+	}
+
+	adjustment := camEntry.Adjustment
+	adjustment.Rotate = 180
+	adjustment.OverlayText = "garden"
+	adjustment.PrivacyMasks = nil
+	if err := store.Cameras.UpdateAdjustment(adjustment); err != nil {
+		t.Fatalf("failed to update adjustment: %v", err)
+	}
+
+	updated, err := store.Cameras.Get(camEntry.Id)
+	if err != nil {
+		t.Fatalf("failed to get camera: %v", err)
+	}
+	if updated.Name != "garden" || updated.IP != "cam.local" || updated.Port != 81 || updated.Path != "/video" {
+		t.Errorf("unexpected camera %+v", updated)
+	}
+	if updated.Adjustment.Rotate != 180 || updated.Adjustment.OverlayText != "garden" || len(updated.Adjustment.PrivacyMasks) != 0 {
+		t.Errorf("unexpected adjustment %+v", updated.Adjustment)
+	}
+}
+
+func TestCameraGroups(t *testing.T) {
+	store := openTestStore(t)
+	porch := addTestCamera(t, store, "porch", "10.0.0.1", "/a")
+	garage := addTestCamera(t, store, "garage", "10.0.0.2", "/a")
+
+	outside := &database.CameraGroup{Name: "outside", Tags: []string{"perimeter"}}
+	inside := &database.CameraGroup{Name: "inside", Tags: []string{}}
+	for _, group := range []*database.CameraGroup{outside, inside} {
+		if err := store.Cameras.SaveGroup(group); err != nil {
+			t.Fatalf("failed to save group '%s': %v", group.Name, err)
+		}
+	}
+
+	// Saving an existing group replaces its tags, keeping its ID.
+	outsideId := outside.Id
+	outside.Tags = []string{"perimeter", "night"}
+	if err := store.Cameras.SaveGroup(outside); err != nil {
+		t.Fatalf("failed to save group: %v", err)
+	}
+	if outside.Id != outsideId {
+		t.Errorf("expected group[%d] to be updated, got group[%d]", outsideId, outside.Id)
+	}
+	group, err := store.Cameras.GetGroup("outside")
+	if err != nil {
+		t.Fatalf("failed to get group: %v", err)
+	}
+	if !reflect.DeepEqual(group.Tags, []string{"perimeter", "night"}) {
+		t.Errorf("expected tags to be replaced, got %v", group.Tags)
+	}
+
+	groups, err := store.Cameras.ListGroups()
+	if err != nil {
+		t.Fatalf("failed to list groups: %v", err)
+	}
+	if len(groups) != 2 || groups[0].Name != "inside" || groups[1].Name != "outside" {
+		t.Errorf("expected groups ordered by name, got %+v", groups)
+	}
+
+	// Assigning twice skips existing members.
+	for i := 0; i < 2; i++ {
+		if err := store.Cameras.AssignGroup(outside.Id, []uint64{porch.Id, garage.Id}); err != nil {
+			t.Fatalf("failed to assign group: %v", err)
+		}
+	}
+	if err := store.Cameras.AssignGroup(inside.Id, []uint64{garage.Id}); err != nil {
+		t.Fatalf("failed to assign group: %v", err)
+	}
+
+	for _, tc := range []struct {
+		group string
+		tag   string
+		ids   []uint64
+	}{
+		{"outside", "", []uint64{porch.Id, garage.Id}},
+		{"inside", "", []uint64{garage.Id}},
+		{"", "night", []uint64{porch.Id, garage.Id}},
+		{"inside", "night", []uint64{}},
+		{"", "", []uint64{porch.Id, garage.Id}},
+	} {
+		cameras, err := store.Cameras.GetGroupCameras(tc.group, tc.tag)
+		if err != nil {
+			t.Fatalf("failed to get cameras of group '%s' with tag '%s': %v", tc.group, tc.tag, err)
+		}
+		if ids := cameraIds(cameras); !reflect.DeepEqual(ids, tc.ids) {
+			t.Errorf("expected cameras %v of group '%s' with tag '%s', got %v", tc.ids, tc.group, tc.tag, ids)
+		}
+	}
+	if _, err := store.Cameras.GetGroupCameras("missing", ""); err == nil {
+		t.Error("expected an error for a missing group")
+	}
+
+	removed, err := store.Cameras.UnassignGroup(outside.Id, []uint64{porch.Id, porch.Id + 100})
+	if err != nil {
+		t.Fatalf("failed to unassign group: %v", err)
+	}
+	if removed != 1 {
+		t.Errorf("expected 1 removed member, got %d", removed)
+	}
+	cameras, err := store.Cameras.GetGroupCameras("outside", "")
+	if err != nil {
+		t.Fatalf("failed to get group cameras: %v", err)
+	}
+	if ids := cameraIds(cameras); !reflect.DeepEqual(ids, []uint64{garage.Id}) {
+		t.Errorf("expected the remaining member, got %v", ids)
+	}
+
+	if err := store.Cameras.RemoveGroup(inside); err != nil {
+		t.Fatalf("failed to remove group: %v", err)
+	}
+	if _, err := store.Cameras.GetGroup("inside"); err != database.ErrNotFound {
+		t.Errorf("expected ErrNotFound for a removed group, got %v", err)
+	}
+}
+
+func TestCameraRemove(t *testing.T) {
+	store := openTestStore(t)
+	porch := addTestCamera(t, store, "porch", "10.0.0.1", "/a")
+	garage := addTestCamera(t, store, "garage", "10.0.0.2", "/a")
+
+	group := &database.CameraGroup{Name: "outside", Tags: []string{}}
+	if err := store.Cameras.SaveGroup(group); err != nil {
+		t.Fatalf("failed to save group: %v", err)
+	}
+	if err := store.Cameras.AssignGroup(group.Id, []uint64{porch.Id, garage.Id}); err != nil {
+		t.Fatalf("failed to assign group: %v", err)
+	}
+
+	if err := store.Cameras.Remove(porch); err != nil {
+		t.Fatalf("failed to remove camera: %v", err)
+	}
+	if _, err := store.Cameras.Get(porch.Id); err != database.ErrNotFound {
+		t.Errorf("expected ErrNotFound for a removed camera, got %v", err)
+	}
+
+	// The camera's memberships are removed along with it, leaving others intact.
+	cameras, err := store.Cameras.GetGroupCameras("outside", "")
+	if err != nil {
+		t.Fatalf("failed to get group cameras: %v", err)
+	}
+	if ids := cameraIds(cameras); !reflect.DeepEqual(ids, []uint64{garage.Id}) {
+		t.Errorf("expected only the remaining camera in the group, got %v", ids)
+	}
+	camEntry, err := store.Cameras.Get(garage.Id)
+	if err != nil || camEntry.Adjustment == nil {
+		t.Errorf("expected the remaining camera along with its adjustment, got %+v (%v)", camEntry, err)
+	}
+}
@@ -0,0 +1,254 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"4bit.api/v0/database"
+)
+
+// Node repository backed by sqlite.
+type nodeRepository struct {
+	db *sql.DB
+}
+
+// Node state repository backed by sqlite.
+type nodeStateRepository struct {
+	db *sql.DB
+}
+
+func (repo *nodeRepository) List() ([]database.Node, error) {
+	rows, err := repo.db.Query(`SELECT id, timestamp, certificate_fingerprint FROM nodes ORDER BY id ASC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query nodes: %v", err)
+	}
+	defer rows.Close()
+
+	nodes := []database.Node{}
+	for rows.Next() {
+		node := database.Node{}
+		if err := rows.Scan(&node.Id, &node.Timestamp, &node.CertificateFingerprint); err != nil {
+			return nil, fmt.Errorf("failed to query nodes: %v", err)
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, rows.Err()
+}
+
+// getNode queries a node by the given column.
+func (repo *nodeRepository) getNode(column string, value interface{}) (*database.Node, error) {
+	node := database.Node{}
+	if err := repo.db.QueryRow(
+		`SELECT id, timestamp, certificate_fingerprint FROM nodes WHERE `+column+` = ?`,
+		value,
+	).Scan(&node.Id, &node.Timestamp, &node.CertificateFingerprint); err != nil {
+		return nil, err
+	}
+	return &node, nil
+}
+
+// The fingerprint is checked against the node's associated fingerprints and their
+// validity windows, as with the postgres backend.
+func (repo *nodeRepository) GetByFingerprint(fingerprint string) (*database.Node, error) {
+	now := time.Now().UTC()
+
+	var (
+		nodeId     uint64
+		validFrom  time.Time
+		validUntil sql.NullTime
+	)
+	err := repo.db.QueryRow(
+		`SELECT node_id, valid_from, valid_until FROM node_fingerprints WHERE fingerprint = ?`,
+		fingerprint,
+	).Scan(&nodeId, &validFrom, &validUntil)
+	if err == nil {
+		if validFrom.After(now) {
+			return nil, fmt.Errorf("fingerprint '%s' is not valid until %v", fingerprint, validFrom)
+		}
+		if validUntil.Valid && !validUntil.Time.After(now) {
+			return nil, fmt.Errorf("fingerprint '%s' expired on %v", fingerprint, validUntil.Time)
+		}
+
+		node, err := repo.getNode("id", nodeId)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find node with fingerprint '%s'", fingerprint)
+		}
+
+		// Promote a successor fingerprint to the node's current fingerprint on first use.
+		if node.CertificateFingerprint != fingerprint {
+			log.Printf("Node[%d] rotated to fingerprint '%s'", node.Id, fingerprint)
+			node.CertificateFingerprint = fingerprint
+			if _, err := repo.db.Exec(`UPDATE nodes SET certificate_fingerprint = ? WHERE id = ?`, fingerprint, node.Id); err != nil {
+				log.Printf("Failed to update node[%d] current fingerprint: %v", node.Id, err)
+			}
+		}
+		return node, nil
+	} else if err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to query fingerprint '%s': %v", fingerprint, err)
+	}
+
+	// Fallback on nodes without a registered fingerprint.
+	node, err := repo.getNode("certificate_fingerprint", fingerprint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find node with fingerprint '%s'", fingerprint)
+	}
+	return node, nil
+}
+
+func (repo *nodeRepository) FingerprintExists(fingerprint string) (bool, error) {
+	exists := false
+	if err := repo.db.QueryRow(
+		`SELECT EXISTS (SELECT 1 FROM node_fingerprints WHERE fingerprint = ?)`,
+		fingerprint,
+	).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to query fingerprint '%s': %v", fingerprint, err)
+	}
+	return exists, nil
+}
+
+func (repo *nodeRepository) Add(node *database.Node) error {
+	return runInTransaction(repo.db, func(tx *sql.Tx) error {
+		var err error
+		node.Id, err = insert(
+			tx,
+			`INSERT INTO nodes (timestamp, certificate_fingerprint) VALUES (?, ?)`,
+			node.Timestamp, node.CertificateFingerprint,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to add node: %v", err)
+		}
+
+		if _, err := tx.Exec(
+			`INSERT INTO node_fingerprints (timestamp, fingerprint, valid_from, node_id) VALUES (?, ?, ?, ?)`,
+			time.Now().UTC(), node.CertificateFingerprint, node.Timestamp, node.Id,
+		); err != nil {
+			return fmt.Errorf("failed to add fingerprint '%s' to node %d: %v", node.CertificateFingerprint, node.Id, err)
+		}
+		return nil
+	})
+}
+
+func (repo *nodeStateRepository) AddPowerState(state *database.NodePowerState) error {
+	id, err := insert(
+		repo.db,
+		`INSERT INTO node_power_states (timestamp, current_ma, load_voltage, power_mw, node_id) VALUES (?, ?, ?, ?, ?)`,
+		state.Timestamp, state.Current_mA, state.LoadVoltage, state.Power_mW, state.NodeId,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to add power state: %v", err)
+	}
+	state.Id = id
+	return nil
+}
+
+func (repo *nodeStateRepository) AddBarometerState(state *database.NodeBarometerState) error {
+	id, err := insert(
+		repo.db,
+		`INSERT INTO node_barometer_states (timestamp, pressure, temperature, altitude, node_id) VALUES (?, ?, ?, ?, ?)`,
+		state.Timestamp, state.Pressure, state.Temperature, state.Altitude, state.NodeId,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to add barometer state: %v", err)
+	}
+	state.Id = id
+	return nil
+}
+
+func (repo *nodeStateRepository) AddHeartbeat(heartbeat *database.NodeHeartbeat) error {
+	id, err := insert(
+		repo.db,
+		`INSERT INTO node_heartbeats (timestamp, uptime, firmware_version, ip, free_memory, interval, node_id) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		heartbeat.Timestamp, heartbeat.Uptime, heartbeat.FirmwareVersion, heartbeat.IP,
+		heartbeat.FreeMemory, heartbeat.Interval, heartbeat.NodeId,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to add heartbeat: %v", err)
+	}
+	heartbeat.Id = id
+	return nil
+}
+
+// getStateNode queries the node states belong to, which are returned along
+// with it as with the postgres backend.
+func (repo *nodeStateRepository) getStateNode(nodeId uint64) (*database.Node, error) {
+	return (&nodeRepository{db: repo.db}).getNode("id", nodeId)
+}
+
+func (repo *nodeStateRepository) GetPowerStates(nodeId uint64, limit int) ([]database.NodePowerState, error) {
+	node, err := repo.getStateNode(nodeId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query node[%d]: %v", nodeId, err)
+	}
+
+	rows, err := repo.db.Query(
+		`SELECT id, timestamp, current_ma, load_voltage, power_mw FROM node_power_states WHERE node_id = ? LIMIT ?`,
+		nodeId, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query power states of node[%d]: %v", nodeId, err)
+	}
+	defer rows.Close()
+
+	powerStates := []database.NodePowerState{}
+	for rows.Next() {
+		state := database.NodePowerState{NodeId: nodeId, Node: node}
+		if err := rows.Scan(&state.Id, &state.Timestamp, &state.Current_mA, &state.LoadVoltage, &state.Power_mW); err != nil {
+			return nil, fmt.Errorf("failed to query power states of node[%d]: %v", nodeId, err)
+		}
+		powerStates = append(powerStates, state)
+	}
+	return powerStates, rows.Err()
+}
+
+func (repo *nodeStateRepository) GetBarometerStates(nodeId uint64, limit int) ([]database.NodeBarometerState, error) {
+	node, err := repo.getStateNode(nodeId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query node[%d]: %v", nodeId, err)
+	}
+
+	rows, err := repo.db.Query(
+		`SELECT id, timestamp, pressure, temperature, altitude FROM node_barometer_states WHERE node_id = ? LIMIT ?`,
+		nodeId, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query barometer states of node[%d]: %v", nodeId, err)
+	}
+	defer rows.Close()
+
+	barometerStates := []database.NodeBarometerState{}
+	for rows.Next() {
+		state := database.NodeBarometerState{NodeId: nodeId, Node: node}
+		if err := rows.Scan(&state.Id, &state.Timestamp, &state.Pressure, &state.Temperature, &state.Altitude); err != nil {
+			return nil, fmt.Errorf("failed to query barometer states of node[%d]: %v", nodeId, err)
+		}
+		barometerStates = append(barometerStates, state)
+	}
+	return barometerStates, rows.Err()
+}
+
+func (repo *nodeStateRepository) GetLastHeartbeats() (map[uint64]database.NodeHeartbeat, error) {
+	// Heartbeats are inserted as they're received, the latest having the largest ID.
+	rows, err := repo.db.Query(
+		`SELECT id, timestamp, uptime, firmware_version, ip, free_memory, interval, node_id
+		FROM node_heartbeats WHERE id IN (SELECT max(id) FROM node_heartbeats GROUP BY node_id)`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query last heartbeats: %v", err)
+	}
+	defer rows.Close()
+
+	heartbeatMp := map[uint64]database.NodeHeartbeat{}
+	for rows.Next() {
+		heartbeat := database.NodeHeartbeat{}
+		if err := rows.Scan(
+			&heartbeat.Id, &heartbeat.Timestamp, &heartbeat.Uptime, &heartbeat.FirmwareVersion,
+			&heartbeat.IP, &heartbeat.FreeMemory, &heartbeat.Interval, &heartbeat.NodeId,
+		); err != nil {
+			return nil, fmt.Errorf("failed to query last heartbeats: %v", err)
+		}
+		heartbeatMp[heartbeat.NodeId] = heartbeat
+	}
+	return heartbeatMp, rows.Err()
+}
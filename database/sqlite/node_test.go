@@ -0,0 +1,139 @@
+package sqlite
+
+import (
+	"testing"
+	"time"
+
+	"4bit.api/v0/database"
+	"4bit.api/v0/server/route/node/interfaces"
+)
+
+// addTestNode adds a node with the given certificate fingerprint.
+func addTestNode(t *testing.T, store *database.Store, fingerprint string) *database.Node {
+	t.Helper()
+	node := &database.Node{CertificateFingerprint: fingerprint}
+	node.Timestamp = time.Now().UTC().Add(-time.Minute)
+	if err := store.Nodes.Add(node); err != nil {
+		t.Fatalf("failed to add node: %v", err)
+	}
+	return node
+}
+
+func TestNodeAddAndGetByFingerprint(t *testing.T) {
+	store := openTestStore(t)
+	added := addTestNode(t, store, "AA:BB")
+	if added.Id == 0 {
+		t.Fatal("expected the node's ID to be assigned")
+	}
+
+	node, err := store.Nodes.GetByFingerprint("AA:BB")
+	if err != nil {
+		t.Fatalf("failed to get node: %v", err)
+	}
+	if node.Id != added.Id || node.CertificateFingerprint != "AA:BB" {
+		t.Errorf("expected node[%d], got %+v", added.Id, node)
+	}
+
+	if _, err := store.Nodes.GetByFingerprint("CC:DD"); err == nil {
+		t.Error("expected an error for an unknown fingerprint")
+	}
+
+	for fingerprint, exists := range map[string]bool{"AA:BB": true, "CC:DD": false} {
+		found, err := store.Nodes.FingerprintExists(fingerprint)
+		if err != nil {
+			t.Fatalf("failed to query fingerprint: %v", err)
+		}
+		if found != exists {
+			t.Errorf("expected fingerprint '%s' to exist: %v", fingerprint, exists)
+		}
+	}
+
+	// Registering a node under an existing fingerprint is rejected.
+	if err := store.Nodes.Add(&database.Node{CertificateFingerprint: "AA:BB"}); err == nil {
+		t.Error("expected an error for a duplicate fingerprint")
+	}
+	nodes, err := store.Nodes.List()
+	if err != nil {
+		t.Fatalf("failed to list nodes: %v", err)
+	}
+	if len(nodes) != 1 {
+		t.Errorf("expected the rejected node to be rolled back, got %+v", nodes)
+	}
+}
+
+func TestNodeStates(t *testing.T) {
+	store := openTestStore(t)
+	node := addTestNode(t, store, "AA:BB")
+
+	powerState := &database.NodePowerState{
+		PowerState: interfaces.PowerState{Current_mA: 120, LoadVoltage: 5, Power_mW: 600},
+		NodeId:     node.Id,
+	}
+	if err := store.NodeStates.AddPowerState(powerState); err != nil {
+		t.Fatalf("failed to add power state: %v", err)
+	}
+	barometerState := &database.NodeBarometerState{
+		BarometerState: interfaces.BarometerState{Pressure: 1013, Temperature: 21, Altitude: 120},
+		NodeId:         node.Id,
+	}
+	if err := store.NodeStates.AddBarometerState(barometerState); err != nil {
+		t.Fatalf("failed to add barometer state: %v", err)
+	}
+
+	powerStates, err := store.NodeStates.GetPowerStates(node.Id, 10)
+	if err != nil {
+		t.Fatalf("failed to get power states: %v", err)
+	}
+	if len(powerStates) != 1 || powerStates[0].PowerState != powerState.PowerState || powerStates[0].Node == nil {
+		t.Errorf("expected the power state along with its node, got %+v", powerStates)
+	}
+
+	barometerStates, err := store.NodeStates.GetBarometerStates(node.Id, 10)
+	if err != nil {
+		t.Fatalf("failed to get barometer states: %v", err)
+	}
+	if len(barometerStates) != 1 || barometerStates[0].BarometerState != barometerState.BarometerState || barometerStates[0].Node == nil {
+		t.Errorf("expected the barometer state along with its node, got %+v", barometerStates)
+	}
+
+	if _, err := store.NodeStates.GetPowerStates(node.Id+1, 10); err == nil {
+		t.Error("expected an error for the states of a missing node")
+	}
+
+	// States of missing nodes are rejected by their foreign key.
+	if err := store.NodeStates.AddPowerState(&database.NodePowerState{NodeId: node.Id + 1}); err == nil {
+		t.Error("expected an error for a state of a missing node")
+	}
+}
+
+func TestNodeLastHeartbeats(t *testing.T) {
+	store := openTestStore(t)
+	first := addTestNode(t, store, "AA:BB")
+	second := addTestNode(t, store, "CC:DD")
+
+	now := time.Now().UTC()
+	for _, heartbeat := range []*database.NodeHeartbeat{
+		{Heartbeat: interfaces.Heartbeat{Uptime: 10, FirmwareVersion: "1.0"}, NodeId: first.Id},
+		{Heartbeat: interfaces.Heartbeat{Uptime: 20, FirmwareVersion: "1.1"}, NodeId: first.Id},
+		{Heartbeat: interfaces.Heartbeat{Uptime: 5, IP: "10.0.0.2", Interval: 60}, NodeId: second.Id},
+	} {
+		heartbeat.Timestamp = now
+		if err := store.NodeStates.AddHeartbeat(heartbeat); err != nil {
+			t.Fatalf("failed to add heartbeat: %v", err)
+		}
+	}
+
+	heartbeats, err := store.NodeStates.GetLastHeartbeats()
+	if err != nil {
+		t.Fatalf("failed to get last heartbeats: %v", err)
+	}
+	if len(heartbeats) != 2 {
+		t.Fatalf("expected a heartbeat per node, got %+v", heartbeats)
+	}
+	if heartbeat := heartbeats[first.Id]; heartbeat.Uptime != 20 || heartbeat.FirmwareVersion != "1.1" {
+		t.Errorf("expected the latest heartbeat of node[%d], got %+v", first.Id, heartbeat)
+	}
+	if heartbeat := heartbeats[second.Id]; heartbeat.IP != "10.0.0.2" || heartbeat.Interval != 60 {
+		t.Errorf("unexpected heartbeat of node[%d]: %+v", second.Id, heartbeat)
+	}
+}
@@ -0,0 +1,163 @@
+// sqlite package provides an embedded storage backend, allowing the server to
+// run without a postgres server, ie. on a single Raspberry Pi.
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+
+	"4bit.api/v0/database"
+	_ "modernc.org/sqlite"
+)
+
+// Tables of the embedded backend, mirroring the postgres tables of cameras &
+// nodes.
+var schema = []string{
+	`CREATE TABLE IF NOT EXISTS camera_adjsustments (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		timestamp DATETIME,
+		crop_frame_height REAL,
+		crop_frame_width REAL,
+		crop_framex INTEGER,
+		crop_framey INTEGER,
+		rotate REAL,
+		overlay_name BOOLEAN,
+		overlay_timestamp BOOLEAN,
+		overlay_text TEXT,
+		overlay_corner TEXT,
+		privacy_masks TEXT
+	)`,
+	`CREATE TABLE IF NOT EXISTS camera_entries (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT,
+		created_at DATETIME,
+		modified_at DATETIME,
+		ip TEXT,
+		port INTEGER,
+		path TEXT,
+		adjustment_id INTEGER REFERENCES camera_adjsustments (id) ON DELETE RESTRICT
+	)`,
+	`CREATE TABLE IF NOT EXISTS camera_groups (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		timestamp DATETIME,
+		name TEXT UNIQUE,
+		tags TEXT
+	)`,
+	`CREATE TABLE IF NOT EXISTS camera_group_members (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		timestamp DATETIME,
+		group_id INTEGER REFERENCES camera_groups (id) ON DELETE CASCADE,
+		camera_id INTEGER REFERENCES camera_entries (id) ON DELETE CASCADE,
+		UNIQUE (group_id, camera_id)
+	)`,
+	`CREATE TABLE IF NOT EXISTS nodes (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		timestamp DATETIME,
+		certificate_fingerprint TEXT
+	)`,
+	`CREATE TABLE IF NOT EXISTS node_fingerprints (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		timestamp DATETIME,
+		fingerprint TEXT UNIQUE,
+		valid_from DATETIME,
+		valid_until DATETIME,
+		node_id INTEGER REFERENCES nodes (id) ON DELETE CASCADE
+	)`,
+	`CREATE TABLE IF NOT EXISTS node_power_states (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		timestamp DATETIME,
+		current_ma REAL,
+		load_voltage REAL,
+		power_mw REAL,
+		node_id INTEGER REFERENCES nodes (id) ON DELETE CASCADE
+	)`,
+	`CREATE INDEX IF NOT EXISTS node_power_states_node_id_timestamp ON node_power_states (node_id, timestamp)`,
+	`CREATE TABLE IF NOT EXISTS node_barometer_states (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		timestamp DATETIME,
+		pressure REAL,
+		temperature REAL,
+		altitude REAL,
+		node_id INTEGER REFERENCES nodes (id) ON DELETE CASCADE
+	)`,
+	`CREATE INDEX IF NOT EXISTS node_barometer_states_node_id_timestamp ON node_barometer_states (node_id, timestamp)`,
+	`CREATE TABLE IF NOT EXISTS node_heartbeats (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		timestamp DATETIME,
+		uptime INTEGER,
+		firmware_version TEXT,
+		ip TEXT,
+		free_memory INTEGER,
+		interval INTEGER,
+		node_id INTEGER REFERENCES nodes (id) ON DELETE CASCADE
+	)`,
+	`CREATE INDEX IF NOT EXISTS node_heartbeats_node_id_timestamp ON node_heartbeats (node_id, timestamp)`,
+}
+
+// Open opens the database file at the given path, creating it along with its
+// tables if missing.
+// On success, returns the storage backend over the database.
+func Open(path string) (*database.Store, error) {
+	// Enforce foreign keys & wait on locks held by other connections.
+	dsn := fmt.Sprintf(
+		"file:%s?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)",
+		url.PathEscape(path),
+	)
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite database '%s': %v", path, err)
+	}
+
+	// Serialize writes, which sqlite doesn't allow concurrently.
+	db.SetMaxOpenConns(1)
+
+	for _, stmt := range schema {
+		if _, err := db.Exec(stmt); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to create sqlite schema: %v", err)
+		}
+	}
+
+	return &database.Store{
+		Backend:    database.SQLITE_BACKEND,
+		Cameras:    &cameraRepository{db: db},
+		Nodes:      &nodeRepository{db: db},
+		NodeStates: &nodeStateRepository{db: db},
+	}, nil
+}
+
+// Query executor shared by connections & transactions.
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// runInTransaction runs the function within a transaction, committing it on
+// success & rolling it back otherwise.
+func runInTransaction(db *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// insert executes the insert statement.
+// On success, returns the ID of the inserted row.
+func insert(db execer, query string, args ...interface{}) (uint64, error) {
+	res, err := db.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return uint64(id), nil
+}
@@ -0,0 +1,45 @@
+package sqlite
+
+import (
+	"path/filepath"
+	"testing"
+
+	"4bit.api/v0/database"
+)
+
+// openTestStore opens a store over a fresh database file, removed along with
+// the test's temporary directory.
+func openTestStore(t *testing.T) *database.Store {
+	t.Helper()
+	store, err := Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	return store
+}
+
+func TestOpenReopensExistingDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	if store.Backend != database.SQLITE_BACKEND {
+		t.Errorf("expected backend '%s', got '%s'", database.SQLITE_BACKEND, store.Backend)
+	}
+	if err := store.Nodes.Add(&database.Node{CertificateFingerprint: "AA"}); err != nil {
+		t.Fatalf("failed to add node: %v", err)
+	}
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("failed to reopen store: %v", err)
+	}
+	nodes, err := reopened.Nodes.List()
+	if err != nil {
+		t.Fatalf("failed to list nodes: %v", err)
+	}
+	if len(nodes) != 1 || nodes[0].CertificateFingerprint != "AA" {
+		t.Errorf("expected the node to persist, got %+v", nodes)
+	}
+}
@@ -0,0 +1,123 @@
+package database
+
+import (
+	"fmt"
+)
+
+// Returned by repositories when the requested entry doesn't exist.
+var ErrNotFound = fmt.Errorf("not found")
+
+// Storage of cameras along with their adjustments & groups.
+type CameraRepository interface {
+	// List returns the cameras along with their adjustments ordered by ID, up to
+	// the limit when non-zero.
+	List(limit int) ([]CameraEntry, error)
+
+	// Get returns the camera along with its adjustment, or ErrNotFound.
+	Get(id uint64) (*CameraEntry, error)
+
+	// FindByIP returns the cameras of a host along with their adjustments.
+	FindByIP(ip string) ([]CameraEntry, error)
+
+	// StreamExists checks whether a camera other than the excluded one streams
+	// from the given endpoint.
+	StreamExists(ip string, port uint16, path string, excludeId uint64) (bool, error)
+
+	// Add inserts the camera along with its adjustment, as a whole.
+	Add(camEntry *CameraEntry) error
+
+	// Update replaces the camera's name, stream endpoint & modification time.
+	Update(camEntry *CameraEntry) error
+
+	// UpdateAdjustment replaces the camera adjustment's fields.
+	UpdateAdjustment(adjustment *CameraAdjsustment) error
+
+	// Remove deletes the camera along with its adjustment & group memberships,
+	// as a whole.
+	Remove(camEntry *CameraEntry) error
+
+	// ListGroups returns the camera groups ordered by name.
+	ListGroups() ([]CameraGroup, error)
+
+	// GetGroup returns the camera group with the given name, or ErrNotFound.
+	GetGroup(name string) (*CameraGroup, error)
+
+	// GetGroupCameras returns the cameras within a group and/or within groups
+	// having a tag, ignoring empty filters.
+	GetGroupCameras(group string, tag string) ([]CameraEntry, error)
+
+	// SaveGroup inserts the camera group, replacing the tags of an existing
+	// group with the same name.
+	SaveGroup(group *CameraGroup) error
+
+	// RemoveGroup deletes the camera group along with its memberships.
+	RemoveGroup(group *CameraGroup) error
+
+	// AssignGroup adds the cameras to the group, skipping existing members.
+	AssignGroup(groupId uint64, cameraIds []uint64) error
+
+	// UnassignGroup removes the cameras from the group.
+	// On success, returns the number of removed members.
+	UnassignGroup(groupId uint64, cameraIds []uint64) (int, error)
+}
+
+// Storage of nodes.
+type NodeRepository interface {
+	// List returns the nodes ordered by ID.
+	List() ([]Node, error)
+
+	// GetByFingerprint returns the node which currently accepts the
+	// certificate fingerprint.
+	GetByFingerprint(fingerprint string) (*Node, error)
+
+	// FingerprintExists checks whether the fingerprint was registered to a node,
+	// regardless of its validity window.
+	FingerprintExists(fingerprint string) (bool, error)
+
+	// Add inserts the node, registering its certificate fingerprint.
+	Add(node *Node) error
+}
+
+// Storage of the states & heartbeats reported by nodes.
+type NodeStateRepository interface {
+	AddPowerState(state *NodePowerState) error
+	AddBarometerState(state *NodeBarometerState) error
+	AddHeartbeat(heartbeat *NodeHeartbeat) error
+
+	// GetPowerStates returns up to limit power states of a node.
+	GetPowerStates(nodeId uint64, limit int) ([]NodePowerState, error)
+
+	// GetBarometerStates returns up to limit barometer states of a node.
+	GetBarometerStates(nodeId uint64, limit int) ([]NodeBarometerState, error)
+
+	// GetLastHeartbeats returns the last heartbeat of each node, keyed by node ID.
+	GetLastHeartbeats() (map[uint64]NodeHeartbeat, error)
+}
+
+// Storage backend of the server.
+type Store struct {
+	Backend    string // Name of the backend, ie. "postgres".
+	Cameras    CameraRepository
+	Nodes      NodeRepository
+	NodeStates NodeStateRepository
+}
+
+// Singleton storage backend
+var (
+	StoreInstance *Store
+)
+
+// Storage backends the server can run with.
+const (
+	POSTGRES_BACKEND = "postgres"
+	SQLITE_BACKEND   = "sqlite"
+)
+
+// RequirePostgres verifies the server runs with the postgres backend, which
+// subsystems beyond cameras & nodes are only stored in.
+func RequirePostgres() error {
+	if DbInstance == nil {
+		return fmt.Errorf("requires the %s storage backend", POSTGRES_BACKEND)
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package database
+
+// Kinds of subjects which can be granted access to the telegram bot.
+const (
+	TELEGRAM_SUBJECT_USER = "user"
+	TELEGRAM_SUBJECT_CHAT = "chat"
+)
+
+// Roles granted to telegram bot subjects.
+const (
+	TELEGRAM_ROLE_MEMBER = "member"
+	TELEGRAM_ROLE_ADMIN  = "admin"
+)
+
+// Telegram user or chat allowed to interact with the bot. Admin users may
+// additionally manage the allowlist.
+type TelegramAccess struct {
+	BaseEntry
+	Kind      string `pg:"unique:subject"`
+	SubjectId int64  `pg:"unique:subject"`
+	Role      string
+
+	// Telegram user which granted the access.
+	GrantedBy int64
+}
@@ -0,0 +1,77 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+)
+
+// DownsampleTelemetry rolls the raw states recorded before the given hour into
+// hourly aggregates per node, removing the aggregated states as a whole.
+// On success, returns the number of aggregated states & of added aggregates.
+func DownsampleTelemetry(db *pg.DB, telemetry TelemetryTable, before time.Time) (int, int, error) {
+	if telemetry.AggregateTable == "" {
+		return 0, 0, fmt.Errorf("'%s' has no aggregate table", telemetry.Table)
+	}
+
+	// Only aggregate whole hours, so that an hour is never split across runs.
+	before = before.UTC().Truncate(time.Hour)
+
+	columns := []string{`"timestamp"`, "node_id", "samples"}
+	aggregates := []string{`date_trunc('hour', "timestamp" AT TIME ZONE 'UTC') AT TIME ZONE 'UTC'`, "node_id", "count(*)"}
+	for _, column := range telemetry.Columns {
+		// Zero readings are stored as NULL, which would be skipped otherwise.
+		value := fmt.Sprintf("coalesce(%s, 0)", column)
+		columns = append(columns, "min_"+column, "avg_"+column, "max_"+column)
+		aggregates = append(aggregates, "min("+value+")", "avg("+value+")", "max("+value+")")
+	}
+
+	states, added := 0, 0
+	err := db.RunInTransaction(context.Background(), func(tx *pg.Tx) error {
+		res, err := tx.Exec(
+			fmt.Sprintf(
+				"INSERT INTO ? (%s) SELECT %s FROM ? WHERE \"timestamp\" < ? GROUP BY 1, node_id",
+				strings.Join(columns, ", "),
+				strings.Join(aggregates, ", "),
+			),
+			pg.Ident(telemetry.AggregateTable),
+			pg.Ident(telemetry.Table),
+			before,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to aggregate '%s': %v", telemetry.Table, err)
+		}
+		added = res.RowsAffected()
+
+		if res, err = tx.Exec("DELETE FROM ? WHERE \"timestamp\" < ?", pg.Ident(telemetry.Table), before); err != nil {
+			return fmt.Errorf("failed to remove aggregated '%s': %v", telemetry.Table, err)
+		}
+		states = res.RowsAffected()
+		return nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	return states, added, nil
+}
+
+// PruneTelemetry removes the raw states & aggregates recorded before the given
+// time.
+// On success, returns the number of removed rows.
+func PruneTelemetry(db *pg.DB, telemetry TelemetryTable, before time.Time) (int, error) {
+	pruned := 0
+	for _, table := range []string{telemetry.Table, telemetry.AggregateTable} {
+		if table == "" {
+			continue
+		}
+		res, err := db.Exec("DELETE FROM ? WHERE \"timestamp\" < ?", pg.Ident(table), before)
+		if err != nil {
+			return pruned, fmt.Errorf("failed to prune '%s': %v", table, err)
+		}
+		pruned += res.RowsAffected()
+	}
+	return pruned, nil
+}
@@ -0,0 +1,69 @@
+package database
+
+// Hourly aggregate of a node's power states, rolled up from the raw states once
+// they age past their retention. The timestamp marks the start of the hour.
+type NodePowerAggregate struct {
+	BaseEntry
+	Samples uint64
+
+	MinCurrent_mA  float32 `pg:"min_current_ma"`
+	AvgCurrent_mA  float32 `pg:"avg_current_ma"`
+	MaxCurrent_mA  float32 `pg:"max_current_ma"`
+	MinLoadVoltage float32
+	AvgLoadVoltage float32
+	MaxLoadVoltage float32
+	MinPower_mW    float32 `pg:"min_power_mw"`
+	AvgPower_mW    float32 `pg:"avg_power_mw"`
+	MaxPower_mW    float32 `pg:"max_power_mw"`
+
+	// Relationship.
+	NodeId uint64
+	Node   *Node `pg:"rel:has-one"`
+}
+
+// Hourly aggregate of a node's barometer states, rolled up from the raw states
+// once they age past their retention. The timestamp marks the start of the hour.
+type NodeBarometerAggregate struct {
+	BaseEntry
+	Samples uint64
+
+	MinPressure    float32
+	AvgPressure    float32
+	MaxPressure    float32
+	MinTemperature float32
+	AvgTemperature float32
+	MaxTemperature float32
+	MinAltitude    float32
+	AvgAltitude    float32
+	MaxAltitude    float32
+
+	// Relationship.
+	NodeId uint64
+	Node   *Node `pg:"rel:has-one"`
+}
+
+// Raw telemetry table along with the table its states are aggregated into.
+type TelemetryTable struct {
+	Table          string
+	AggregateTable string // Empty for telemetry which is only pruned.
+
+	// Columns of the raw states, aggregated into the "min_", "avg_" & "max_"
+	// prefixed columns of the aggregate table.
+	Columns []string
+}
+
+var (
+	PowerTelemetry = TelemetryTable{
+		Table:          "node_power_states",
+		AggregateTable: "node_power_aggregates",
+		Columns:        []string{"current_ma", "load_voltage", "power_mw"},
+	}
+	BarometerTelemetry = TelemetryTable{
+		Table:          "node_barometer_states",
+		AggregateTable: "node_barometer_aggregates",
+		Columns:        []string{"pressure", "temperature", "altitude"},
+	}
+	HeartbeatTelemetry = TelemetryTable{
+		Table: "node_heartbeats",
+	}
+)
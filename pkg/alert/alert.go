@@ -0,0 +1,335 @@
+// The alert package evaluates user-defined threshold rules against incoming
+// node readings, tracking each rule's state per node and dispatching transitions
+// to registered handlers.
+package alert
+
+import (
+	"fmt"
+	"log"
+	"reflect"
+	"sync"
+	"time"
+
+	"4bit.api/v0/database"
+	"4bit.api/v0/pkg/notify"
+	nodeInterfaces "4bit.api/v0/server/route/node/interfaces"
+	"github.com/go-pg/pg/v10/orm"
+)
+
+type AlertState string
+
+const (
+	ALERT_OK       AlertState = "ok"
+	ALERT_PENDING  AlertState = "pending"  // Condition met, waiting on the rule's duration.
+	ALERT_FIRING   AlertState = "firing"   // Condition held for the rule's duration.
+	ALERT_RESOLVED AlertState = "resolved" // Reading recovered past the rule's hysteresis.
+)
+
+// Supported rule operators.
+var Operators = []string{"<", "<=", ">", ">="}
+
+// Current state of a rule for a given node.
+type ActiveAlert struct {
+	Rule   database.AlertRule
+	NodeId uint64
+	State  AlertState
+	Since  time.Time
+	Value  float64
+}
+
+// Handler invoked on a rule transitioning between firing and resolved.
+type Handler func(rule database.AlertRule, event database.AlertEvent)
+
+// Identifies the state of a rule for a given node.
+type stateKey struct {
+	RuleId uint64
+	NodeId uint64
+}
+
+var (
+	// Reading types rules can be evaluated against.
+	SensorTypes = map[nodeInterfaces.StateType]reflect.Type{
+		nodeInterfaces.BAROMETER: reflect.TypeOf(nodeInterfaces.BarometerState{}),
+		nodeInterfaces.POWER:     reflect.TypeOf(nodeInterfaces.PowerState{}),
+	}
+
+	handlers   = []Handler{}
+	states     = map[stateKey]*ActiveAlert{}
+	stateMutex = &sync.Mutex{}
+)
+
+// RegisterHandler adds a handler for which alert transitions get dispatched to.
+func RegisterHandler(handler Handler) {
+	stateMutex.Lock()
+	defer stateMutex.Unlock()
+	handlers = append(handlers, handler)
+}
+
+// Init restores the state of firing alerts from their last recorded events.
+// It returns an error reflecting the failure state.
+func Init() error {
+	// Alert events are only stored in postgres.
+	db := database.DbInstance
+	if db == nil {
+		return nil
+	}
+
+	events := []database.AlertEvent{}
+	if err := db.Model(&events).
+		Relation("Rule").
+		DistinctOn("alert_event.rule_id, alert_event.node_id").
+		Order("alert_event.rule_id ASC", "alert_event.node_id ASC", "alert_event.timestamp DESC").
+		Select(); err != nil {
+		return fmt.Errorf("failed to query last alert events: %v", err)
+	}
+
+	stateMutex.Lock()
+	defer stateMutex.Unlock()
+	for _, event := range events {
+		if event.Rule == nil || AlertState(event.State) != ALERT_FIRING {
+			continue
+		}
+		states[stateKey{event.RuleId, event.NodeId}] = &ActiveAlert{
+			Rule:   *event.Rule,
+			NodeId: event.NodeId,
+			State:  ALERT_FIRING,
+			Since:  event.Timestamp,
+			Value:  event.Value,
+		}
+	}
+	log.Printf("Restored %d firing alerts\n", len(states))
+
+	return nil
+}
+
+// ValidateRule verifies that a rule can be evaluated.
+// It returns an error reflecting the invalid state.
+func ValidateRule(rule *database.AlertRule) error {
+	sensorType, ok := SensorTypes[rule.Type]
+	if !ok {
+		return fmt.Errorf("unknown reading type %d", rule.Type)
+	}
+
+	field, ok := sensorType.FieldByName(rule.Field)
+	if !ok {
+		return fmt.Errorf("unknown field '%s' for reading type %d", rule.Field, rule.Type)
+	}
+	if _, err := toFloat(reflect.Zero(field.Type)); err != nil {
+		return fmt.Errorf("field '%s' is not numeric", rule.Field)
+	}
+
+	if rule.Route != "" {
+		if _, err := notify.GetRoute(rule.Route); err != nil {
+			return err
+		}
+	}
+
+	for _, op := range Operators {
+		if op == rule.Operator {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown operator '%s'", rule.Operator)
+}
+
+// FormatRule constructs a human readable description of an alert rule's condition.
+func FormatRule(rule database.AlertRule) string {
+	condition := fmt.Sprintf("%s %s %.2f", rule.Field, rule.Operator, rule.Threshold)
+	if rule.For > 0 {
+		condition += fmt.Sprintf(" for %ds", rule.For)
+	}
+	return fmt.Sprintf("Rule[%d] '%s': %s", rule.Id, rule.Name, condition)
+}
+
+// FormatEvent constructs a human readable notification of an alert transition.
+func FormatEvent(rule database.AlertRule, event database.AlertEvent) notify.Message {
+	title := fmt.Sprintf("🚨 FIRING on Node[%d]", event.NodeId)
+	if AlertState(event.State) == ALERT_RESOLVED {
+		title = fmt.Sprintf("✅ RESOLVED on Node[%d]", event.NodeId)
+	}
+
+	return notify.Message{
+		Title: title,
+		Body:  FormatRule(rule) + "\n" + fmt.Sprintf("Value: %.2f at %v", event.Value, event.Timestamp.Local()),
+	}
+}
+
+// GetActiveAlerts returns a copy of all pending & firing alerts.
+func GetActiveAlerts() []ActiveAlert {
+	stateMutex.Lock()
+	defer stateMutex.Unlock()
+
+	activeAlerts := []ActiveAlert{}
+	for _, state := range states {
+		if state.State == ALERT_OK {
+			continue
+		}
+		activeAlerts = append(activeAlerts, *state)
+	}
+	return activeAlerts
+}
+
+// ForgetRule drops the tracked state of a given rule.
+func ForgetRule(ruleId uint64) {
+	stateMutex.Lock()
+	defer stateMutex.Unlock()
+
+	for key := range states {
+		if key.RuleId == ruleId {
+			delete(states, key)
+		}
+	}
+}
+
+// toFloat converts a numeric reflected value into a float.
+func toFloat(value reflect.Value) (float64, error) {
+	switch value.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return value.Float(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(value.Uint()), nil
+	}
+	return 0, fmt.Errorf("unsupported kind %v", value.Kind())
+}
+
+// isBreached checks whether the value meets the rule's condition.
+func isBreached(rule *database.AlertRule, value float64) bool {
+	switch rule.Operator {
+	case "<":
+		return value < rule.Threshold
+	case "<=":
+		return value <= rule.Threshold
+	case ">":
+		return value > rule.Threshold
+	case ">=":
+		return value >= rule.Threshold
+	}
+	return false
+}
+
+// isRecovered checks whether the value moved past the threshold by the rule's
+// hysteresis margin.
+func isRecovered(rule *database.AlertRule, value float64) bool {
+	switch rule.Operator {
+	case "<", "<=":
+		return value >= rule.Threshold+rule.Hysteresis
+	case ">", ">=":
+		return value <= rule.Threshold-rule.Hysteresis
+	}
+	return true
+}
+
+// Evaluate runs the rules matching the reading's node and type, recording and
+// dispatching any firing or resolved transitions.
+func Evaluate(nodeId uint64, stateType nodeInterfaces.StateType, reading interface{}, timestamp time.Time) {
+	// Alert rules are only stored in postgres.
+	db := database.DbInstance
+	if db == nil {
+		return
+	}
+
+	rules := []database.AlertRule{}
+	if err := db.Model(&rules).
+		Where("type = ?", stateType).
+		WhereGroup(func(q *orm.Query) (*orm.Query, error) {
+			return q.Where("node_id = ?", nodeId).WhereOr("node_id = 0"), nil
+		}).
+		Select(); err != nil {
+		log.Printf("failed to query alert rules for node %d: %v\n", nodeId, err)
+		return
+	}
+
+	readingValue := reflect.ValueOf(reading)
+	for _, rule := range rules {
+		field := readingValue.FieldByName(rule.Field)
+		if !field.IsValid() {
+			continue
+		}
+		value, err := toFloat(field)
+		if err != nil {
+			continue
+		}
+
+		if event := transition(rule, nodeId, value, timestamp); event != nil {
+			dispatch(rule, event)
+		}
+	}
+}
+
+// transition steps the rule's state for a node given the new value.
+// It returns an event if the rule started firing or got resolved.
+func transition(rule database.AlertRule, nodeId uint64, value float64, timestamp time.Time) *database.AlertEvent {
+	stateMutex.Lock()
+	defer stateMutex.Unlock()
+
+	key := stateKey{rule.Id, nodeId}
+	state, ok := states[key]
+	if !ok {
+		state = &ActiveAlert{NodeId: nodeId, State: ALERT_OK}
+		states[key] = state
+	}
+	state.Rule = rule
+	state.Value = value
+
+	switch state.State {
+	case ALERT_OK:
+		if !isBreached(&rule, value) {
+			return nil
+		}
+		state.State = ALERT_PENDING
+		state.Since = timestamp
+		fallthrough
+
+	case ALERT_PENDING:
+		if !isBreached(&rule, value) {
+			state.State = ALERT_OK
+			return nil
+		}
+		if timestamp.Sub(state.Since) < time.Duration(rule.For)*time.Second {
+			return nil
+		}
+		state.State = ALERT_FIRING
+		state.Since = timestamp
+
+	case ALERT_FIRING:
+		if !isRecovered(&rule, value) {
+			return nil
+		}
+		delete(states, key)
+		return newEvent(rule, nodeId, ALERT_RESOLVED, value, timestamp)
+	}
+
+	return newEvent(rule, nodeId, ALERT_FIRING, value, timestamp)
+}
+
+// newEvent constructs an alert event.
+func newEvent(rule database.AlertRule, nodeId uint64, state AlertState, value float64, timestamp time.Time) *database.AlertEvent {
+	event := &database.AlertEvent{
+		NodeId: nodeId,
+		State:  string(state),
+		Value:  value,
+		RuleId: rule.Id,
+	}
+	event.Timestamp = timestamp
+	return event
+}
+
+// dispatch records the event and hands it off to the registered handlers.
+func dispatch(rule database.AlertRule, event *database.AlertEvent) {
+	log.Printf("Alert rule[%d] '%s' %s for node %d: %.2f\n", rule.Id, rule.Name, event.State, event.NodeId, event.Value)
+
+	db := database.DbInstance
+	if _, err := db.Model(event).Insert(); err != nil {
+		log.Printf("failed to record alert event for rule %d: %v\n", rule.Id, err)
+	}
+
+	stateMutex.Lock()
+	eventHandlers := append([]Handler{}, handlers...)
+	stateMutex.Unlock()
+
+	for _, handler := range eventHandlers {
+		go handler(rule, *event)
+	}
+}
@@ -0,0 +1,125 @@
+package camera
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"math"
+	"time"
+
+	"golang.org/x/image/draw"
+)
+
+const (
+	// Default size of each camera's tile within a collage.
+	DEFAULT_COLLAGE_CELL_WIDTH  = 640
+	DEFAULT_COLLAGE_CELL_HEIGHT = 360
+
+	// Largest size of each camera's tile, along with the most tiles per row,
+	// bounding the memory a collage takes.
+	MAX_COLLAGE_CELL_WIDTH  = 1920
+	MAX_COLLAGE_CELL_HEIGHT = 1080
+	MAX_COLLAGE_COLUMNS     = 16
+
+	// Quality of the encoded collage.
+	COLLAGE_JPEG_QUALITY = 85
+)
+
+var (
+	// Background of tiles, visible around letterboxed and missing snapshots.
+	collageBackground = image.NewUniform(color.RGBA{32, 32, 32, 255})
+)
+
+type CollageOptions struct {
+	// Size of each camera's tile. Defaults to DEFAULT_COLLAGE_CELL_WIDTH by
+	// DEFAULT_COLLAGE_CELL_HEIGHT.
+	CellWidth  int
+	CellHeight int
+
+	// Number of tiles per row. Defaults to a square-ish grid.
+	Columns int
+}
+
+// ValidateCollageOptions verifies that the collage options are within bounds.
+// It returns an error reflecting the invalid option.
+func ValidateCollageOptions(opts CollageOptions) error {
+	if opts.CellWidth < 0 || opts.CellWidth > MAX_COLLAGE_CELL_WIDTH {
+		return fmt.Errorf("cell width must be within 0-%d", MAX_COLLAGE_CELL_WIDTH)
+	}
+	if opts.CellHeight < 0 || opts.CellHeight > MAX_COLLAGE_CELL_HEIGHT {
+		return fmt.Errorf("cell height must be within 0-%d", MAX_COLLAGE_CELL_HEIGHT)
+	}
+	if opts.Columns < 0 || opts.Columns > MAX_COLLAGE_COLUMNS {
+		return fmt.Errorf("columns must be within 0-%d", MAX_COLLAGE_COLUMNS)
+	}
+	return nil
+}
+
+// NewCollage tiles the current snapshots of the given cameras into a single grid
+// image, labeling each tile with its camera's name and when it was last updated.
+// It returns the JPEG-encoded collage along with an error reflecting the failure state.
+func NewCollage(workers []*CameraPollWorker, opts CollageOptions) ([]byte, error) {
+	if len(workers) == 0 {
+		return nil, fmt.Errorf("no cameras to create a collage of")
+	}
+	if err := ValidateCollageOptions(opts); err != nil {
+		return nil, err
+	}
+	if opts.CellWidth <= 0 || opts.CellHeight <= 0 {
+		opts.CellWidth, opts.CellHeight = DEFAULT_COLLAGE_CELL_WIDTH, DEFAULT_COLLAGE_CELL_HEIGHT
+	}
+	if opts.Columns <= 0 {
+		opts.Columns = int(math.Ceil(math.Sqrt(float64(len(workers)))))
+		if opts.Columns > MAX_COLLAGE_COLUMNS {
+			opts.Columns = MAX_COLLAGE_COLUMNS
+		}
+	}
+	if opts.Columns > len(workers) {
+		opts.Columns = len(workers)
+	}
+	rows := (len(workers) + opts.Columns - 1) / opts.Columns
+
+	collage := image.NewRGBA(image.Rect(0, 0, opts.Columns*opts.CellWidth, rows*opts.CellHeight))
+	draw.Draw(collage, collage.Bounds(), collageBackground, image.Point{}, draw.Src)
+
+	for idx, worker := range workers {
+		cell := image.Rect(0, 0, opts.CellWidth, opts.CellHeight).Add(image.Point{
+			X: (idx % opts.Columns) * opts.CellWidth,
+			Y: (idx / opts.Columns) * opts.CellHeight,
+		})
+
+		snapshot := worker.GetSnapshot()
+		label := fmt.Sprintf("%s - %s", worker.Name, snapshot.LastUpdated.Format(time.Stamp))
+		if img, _, err := image.Decode(bytes.NewReader(snapshot.ImageData)); err != nil {
+			label = fmt.Sprintf("%s - no image", worker.Name)
+		} else {
+			draw.ApproxBiLinear.Scale(collage, fitRect(img.Bounds(), cell), img, img.Bounds(), draw.Src, nil)
+		}
+
+		// Label the tile's bottom-left corner.
+		drawLabel(collage, image.Point{X: cell.Min.X, Y: cell.Max.Y - labelSize(label).Y}, label)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := jpeg.Encode(buf, collage, &jpeg.Options{Quality: COLLAGE_JPEG_QUALITY}); err != nil {
+		return nil, fmt.Errorf("failed to encode collage: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// fitRect returns the largest rectangle centered within the bounds which keeps
+// the source's aspect ratio.
+func fitRect(src image.Rectangle, bounds image.Rectangle) image.Rectangle {
+	scale := math.Min(
+		float64(bounds.Dx())/float64(src.Dx()),
+		float64(bounds.Dy())/float64(src.Dy()),
+	)
+	size := image.Point{
+		X: int(float64(src.Dx()) * scale),
+		Y: int(float64(src.Dy()) * scale),
+	}
+	offset := bounds.Size().Sub(size).Div(2)
+	return image.Rectangle{Min: bounds.Min.Add(offset), Max: bounds.Min.Add(offset).Add(size)}
+}
@@ -0,0 +1,58 @@
+package camera
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"4bit.api/v0/database"
+)
+
+const (
+	// DNS-SD service type browsed for cameras.
+	DISCOVER_SERVICE_TYPE = "_http._tcp"
+)
+
+// isMjpegService checks whether an HTTP service advertises an MJPEG stream, through
+// its "path" TXT record along with an optional "type" or "format" of MJPEG.
+func isMjpegService(service Service) bool {
+	if _, ok := service.Txt["path"]; !ok {
+		return false
+	}
+	for _, key := range []string{"type", "format"} {
+		if value, ok := service.Txt[key]; ok && !strings.Contains(strings.ToLower(value), "mjpeg") {
+			return false
+		}
+	}
+	return true
+}
+
+// DiscoverCameras browses the LAN for HTTP services advertising an MJPEG stream,
+// returning the camera entries which would poll them. Cameras are addressed by
+// hostname, surviving changes of their addresses.
+func DiscoverCameras(ctx context.Context, resolver Resolver) ([]database.CameraEntry, error) {
+	services, err := resolver.Browse(ctx, DISCOVER_SERVICE_TYPE)
+	if err != nil {
+		return nil, err
+	}
+
+	cameras := []database.CameraEntry{}
+	for _, service := range services {
+		if !isMjpegService(service) {
+			continue
+		}
+
+		path := service.Txt["path"]
+		if !strings.HasPrefix(path, "/") {
+			path = "/" + path
+		}
+		cameras = append(cameras, database.CameraEntry{
+			Name: service.Name(),
+			IP:   strings.TrimSuffix(service.Host, "."),
+			Port: service.Port,
+			Path: path,
+		})
+	}
+	sort.Slice(cameras, func(i, j int) bool { return cameras[i].Name < cameras[j].Name })
+	return cameras, nil
+}
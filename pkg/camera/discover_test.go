@@ -0,0 +1,87 @@
+package camera
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"4bit.api/v0/database"
+)
+
+func TestIsMjpegService(t *testing.T) {
+	for _, tc := range []struct {
+		txt   map[string]string
+		mjpeg bool
+	}{
+		{map[string]string{"path": "/stream"}, true},
+		{map[string]string{"path": "/stream", "type": "MJPEG"}, true},
+		{map[string]string{"path": "/stream", "format": "video/x-mjpeg"}, true},
+		{map[string]string{"path": "/stream", "type": "h264"}, false},
+		{map[string]string{"path": "/stream", "type": "mjpeg", "format": "h264"}, false},
+		{map[string]string{"type": "mjpeg"}, false},
+		{nil, false},
+	} {
+		if mjpeg := isMjpegService(Service{Txt: tc.txt}); mjpeg != tc.mjpeg {
+			t.Errorf("expected service with TXT %v to be MJPEG: %v", tc.txt, tc.mjpeg)
+		}
+	}
+}
+
+func TestDiscoverCameras(t *testing.T) {
+	resolver := &StubResolver{
+		Services: map[string][]Service{
+			DISCOVER_SERVICE_TYPE: {
+				{
+					Instance: "Porch._http._tcp.local.",
+					Host:     "porch-cam.local.",
+					Port:     8080,
+					Txt:      map[string]string{"path": "stream", "type": "mjpeg"},
+				},
+				{
+					Instance: "Printer._http._tcp.local.",
+					Host:     "printer.local.",
+					Port:     80,
+					Txt:      map[string]string{},
+				},
+				{
+					Instance: "Garage._http._tcp.local.",
+					Host:     "garage-cam.local.",
+					Port:     81,
+					Txt:      map[string]string{"path": "/video"},
+				},
+			},
+			"_rtsp._tcp": {
+				{
+					Instance: "Other._rtsp._tcp.local.",
+					Host:     "other.local.",
+					Port:     554,
+					Txt:      map[string]string{"path": "/live"},
+				},
+			},
+		},
+	}
+
+	cameras, err := DiscoverCameras(context.Background(), resolver)
+	if err != nil {
+		t.Fatalf("failed to discover cameras: %v", err)
+	}
+
+	// Sorted by name, addressed by hostname with absolute paths.
+	expected := []database.CameraEntry{
+		{Name: "Garage", IP: "garage-cam.local", Port: 81, Path: "/video"},
+		{Name: "Porch", IP: "porch-cam.local", Port: 8080, Path: "/stream"},
+	}
+	if !reflect.DeepEqual(cameras, expected) {
+		t.Errorf("expected cameras %+v, got %+v", expected, cameras)
+	}
+}
+
+func TestDiscoverCamerasWithoutServices(t *testing.T) {
+	cameras, err := DiscoverCameras(context.Background(), &StubResolver{})
+	if err != nil {
+		t.Fatalf("failed to discover cameras: %v", err)
+	}
+	if len(cameras) != 0 {
+		t.Errorf("expected no cameras, got %+v", cameras)
+	}
+}
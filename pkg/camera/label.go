@@ -0,0 +1,49 @@
+package camera
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	// Padding surrounding label text, in pixels.
+	LABEL_PADDING = 4
+)
+
+var (
+	// Embedded bitmap font labels are rendered with.
+	labelFace = basicfont.Face7x13
+
+	// Translucent background labels are rendered on, keeping them legible.
+	labelBackground = image.NewUniform(color.RGBA{0, 0, 0, 160})
+)
+
+// labelSize returns the size of a rendered label's background.
+func labelSize(text string) image.Point {
+	drawer := font.Drawer{Face: labelFace}
+	return image.Point{
+		X: drawer.MeasureString(text).Ceil() + 2*LABEL_PADDING,
+		Y: labelFace.Metrics().Height.Ceil() + 2*LABEL_PADDING,
+	}
+}
+
+// drawLabel renders text on a translucent background with its top-left corner at
+// the given point, clipped to the destination's bounds.
+func drawLabel(dst draw.Image, at image.Point, text string) {
+	size := labelSize(text)
+	background := image.Rectangle{Min: at, Max: at.Add(size)}.Intersect(dst.Bounds())
+	draw.Draw(dst, background, labelBackground, image.Point{}, draw.Over)
+
+	drawer := font.Drawer{
+		Dst:  dst,
+		Src:  image.White,
+		Face: labelFace,
+		Dot:  fixed.P(at.X+LABEL_PADDING, at.Y+size.Y-LABEL_PADDING-labelFace.Metrics().Descent.Ceil()),
+	}
+	drawer.DrawString(text)
+}
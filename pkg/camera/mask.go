@@ -0,0 +1,85 @@
+package camera
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"sort"
+
+	"4bit.api/v0/database"
+)
+
+const (
+	// Fewest points of a privacy mask polygon.
+	MIN_MASK_POINTS = 3
+)
+
+var (
+	// Color masked regions are filled with.
+	maskFill = image.NewUniform(color.Black)
+)
+
+// ValidatePrivacyMasks verifies each mask is a polygon within the frame.
+// It returns an error reflecting the invalid mask.
+func ValidatePrivacyMasks(masks []database.CameraPrivacyMask) error {
+	for idx, mask := range masks {
+		if len(mask.Points) < MIN_MASK_POINTS {
+			return fmt.Errorf("mask %d has %d points, expected at least %d", idx, len(mask.Points), MIN_MASK_POINTS)
+		}
+		for _, point := range mask.Points {
+			if point.X < 0 || point.X > 1 || point.Y < 0 || point.Y > 1 {
+				return fmt.Errorf("mask %d point (%v, %v) outside of the frame, expected coordinates from 0 to 1", idx, point.X, point.Y)
+			}
+		}
+	}
+	return nil
+}
+
+// fillPrivacyMask blacks out the pixels whose centers lie within the mask's
+// polygon, following the even-odd rule.
+func fillPrivacyMask(dst draw.Image, mask database.CameraPrivacyMask) {
+	if len(mask.Points) < MIN_MASK_POINTS {
+		return
+	}
+
+	// Scale the points to the frame's pixels.
+	bounds := dst.Bounds()
+	xs := make([]float64, len(mask.Points))
+	ys := make([]float64, len(mask.Points))
+	minY, maxY := math.Inf(1), math.Inf(-1)
+	for idx, point := range mask.Points {
+		xs[idx] = point.X * float64(bounds.Dx())
+		ys[idx] = point.Y * float64(bounds.Dy())
+		minY = math.Min(minY, ys[idx])
+		maxY = math.Max(maxY, ys[idx])
+	}
+
+	for row := int(math.Floor(minY)); row < int(math.Ceil(maxY)) && row < bounds.Dy(); row++ {
+		if row < 0 {
+			continue
+		}
+
+		// Find where the row's center crosses the polygon's edges.
+		center := float64(row) + 0.5
+		crossings := []float64{}
+		for idx := range xs {
+			next := (idx + 1) % len(xs)
+			if (ys[idx] <= center) == (ys[next] <= center) {
+				continue
+			}
+			ratio := (center - ys[idx]) / (ys[next] - ys[idx])
+			crossings = append(crossings, xs[idx]+ratio*(xs[next]-xs[idx]))
+		}
+		sort.Float64s(crossings)
+
+		// Fill the spans between pairs of crossings.
+		for idx := 0; idx+1 < len(crossings); idx += 2 {
+			start := int(math.Ceil(crossings[idx] - 0.5))
+			end := int(math.Ceil(crossings[idx+1] - 0.5))
+			span := image.Rect(start, row, end, row+1).Add(bounds.Min).Intersect(bounds)
+			draw.Draw(dst, span, maskFill, image.Point{}, draw.Src)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package camera
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const (
+	// Multicast group & port mDNS responders listen on.
+	MDNS_ADDRESS = "224.0.0.251:5353"
+
+	// Duration to wait on responses when the context has no deadline.
+	MDNS_TIMEOUT = 2 * time.Second
+
+	// Largest mDNS message received.
+	MAX_MDNS_MESSAGE_SIZE = 9000
+)
+
+// mdnsQuery multicasts a question from an ephemeral port, so that responders
+// answer it directly, passing each received resource record to the handler
+// until it returns true or the context is done.
+func mdnsQuery(ctx context.Context, name string, qtype dnsmessage.Type, handle func(dnsmessage.Resource) bool) error {
+	if !strings.HasSuffix(name, ".") {
+		name += "."
+	}
+	qname, err := dnsmessage.NewName(name)
+	if err != nil {
+		return fmt.Errorf("invalid mdns name '%s': %v", name, err)
+	}
+
+	query := dnsmessage.Message{
+		Questions: []dnsmessage.Question{{
+			Name:  qname,
+			Type:  qtype,
+			Class: dnsmessage.ClassINET,
+		}},
+	}
+	packed, err := query.Pack()
+	if err != nil {
+		return fmt.Errorf("failed to pack mdns query: %v", err)
+	}
+
+	mdnsAddr, err := net.ResolveUDPAddr("udp4", MDNS_ADDRESS)
+	if err != nil {
+		return err
+	}
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4zero})
+	if err != nil {
+		return fmt.Errorf("failed to listen for mdns responses: %v", err)
+	}
+	defer conn.Close()
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, MDNS_TIMEOUT)
+		defer cancel()
+	}
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+
+	if _, err := conn.WriteToUDP(packed, mdnsAddr); err != nil {
+		return fmt.Errorf("failed to send mdns query: %v", err)
+	}
+
+	// Unblock reads once the context is cancelled.
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.SetDeadline(time.Now())
+		case <-done:
+		}
+	}()
+
+	buf := make([]byte, MAX_MDNS_MESSAGE_SIZE)
+	for {
+		n, _, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			// Deadline reached, done collecting responses.
+			if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+				return nil
+			}
+			return fmt.Errorf("failed to read mdns response: %v", err)
+		}
+
+		msg := dnsmessage.Message{}
+		if err := msg.Unpack(buf[:n]); err != nil || !msg.Header.Response {
+			continue
+		}
+		for _, res := range append(msg.Answers, msg.Additionals...) {
+			if handle(res) {
+				return nil
+			}
+		}
+	}
+}
+
+// mdnsLookupHost resolves the IPv4 addresses of a ".local" host, returning on
+// the first answer.
+func mdnsLookupHost(ctx context.Context, host string) ([]string, error) {
+	if !strings.HasSuffix(host, ".") {
+		host += "."
+	}
+
+	addrs := []string{}
+	err := mdnsQuery(ctx, host, dnsmessage.TypeA, func(res dnsmessage.Resource) bool {
+		a, ok := res.Body.(*dnsmessage.AResource)
+		if !ok || !strings.EqualFold(res.Header.Name.String(), host) {
+			return false
+		}
+		addrs = append(addrs, net.IP(a.A[:]).String())
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("no mdns response for host '%s'", host)
+	}
+	return addrs, nil
+}
+
+// mdnsBrowse collects the instances of a service type advertised within the
+// ".local" domain, along with their hosts, ports, addresses & TXT records.
+func mdnsBrowse(ctx context.Context, serviceType string) ([]Service, error) {
+	name := strings.TrimSuffix(serviceType, ".") + ".local."
+
+	instances := []string{}
+	services := map[string]*Service{}
+	hostAddrs := map[string][]string{}
+	err := mdnsQuery(ctx, name, dnsmessage.TypePTR, func(res dnsmessage.Resource) bool {
+		resName := strings.ToLower(res.Header.Name.String())
+		switch body := res.Body.(type) {
+		case *dnsmessage.PTRResource:
+			if resName != strings.ToLower(name) {
+				break
+			}
+			instance := body.PTR.String()
+			if _, ok := services[strings.ToLower(instance)]; !ok {
+				instances = append(instances, instance)
+				services[strings.ToLower(instance)] = &Service{Instance: instance, Txt: map[string]string{}}
+			}
+
+		case *dnsmessage.SRVResource:
+			service, ok := services[resName]
+			if !ok {
+				service = &Service{Instance: res.Header.Name.String(), Txt: map[string]string{}}
+				services[resName] = service
+			}
+			service.Host = body.Target.String()
+			service.Port = body.Port
+
+		case *dnsmessage.TXTResource:
+			service, ok := services[resName]
+			if !ok {
+				service = &Service{Instance: res.Header.Name.String(), Txt: map[string]string{}}
+				services[resName] = service
+			}
+			for _, txt := range body.TXT {
+				if idx := strings.Index(txt, "="); idx != -1 {
+					service.Txt[strings.ToLower(txt[:idx])] = txt[idx+1:]
+				} else if txt != "" {
+					service.Txt[strings.ToLower(txt)] = ""
+				}
+			}
+
+		case *dnsmessage.AResource:
+			hostAddrs[resName] = append(hostAddrs[resName], net.IP(body.A[:]).String())
+		}
+		return false
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Only keep instances which were browsed, resolving their hosts' addresses.
+	result := []Service{}
+	for _, instance := range instances {
+		service := services[strings.ToLower(instance)]
+		if service.Host == "" {
+			continue
+		}
+		service.Addrs = hostAddrs[strings.ToLower(service.Host)]
+		result = append(result, *service)
+	}
+	return result, nil
+}
@@ -0,0 +1,114 @@
+package camera
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"time"
+
+	"4bit.api/v0/database"
+)
+
+// Corners overlays can be rendered at.
+const (
+	CORNER_TOP_LEFT     = "top-left"
+	CORNER_TOP_RIGHT    = "top-right"
+	CORNER_BOTTOM_LEFT  = "bottom-left"
+	CORNER_BOTTOM_RIGHT = "bottom-right"
+)
+
+const (
+	// Format of the capture timestamp overlay.
+	OVERLAY_TIME_FORMAT = "2006-01-02 15:04:05"
+
+	// Corner overlays are rendered at when none is configured.
+	DEFAULT_OVERLAY_CORNER = CORNER_TOP_LEFT
+)
+
+// Overlay burned into a camera's frames.
+type Overlay struct {
+	Name      bool   // Whether to render the camera's name.
+	Timestamp bool   // Whether to render the frame's capture time.
+	Text      string // Optional custom text.
+	Corner    string
+}
+
+// NewOverlay constructs the overlay configured in a camera's adjustment.
+func NewOverlay(adjustment *database.CameraAdjsustment) Overlay {
+	if adjustment == nil {
+		return Overlay{}
+	}
+	return Overlay{
+		Name:      adjustment.OverlayName,
+		Timestamp: adjustment.OverlayTimestamp,
+		Text:      adjustment.OverlayText,
+		Corner:    adjustment.OverlayCorner,
+	}
+}
+
+// ValidateOverlayCorner verifies the corner is supported, where empty refers to
+// the default corner.
+func ValidateOverlayCorner(corner string) error {
+	switch corner {
+	case "", CORNER_TOP_LEFT, CORNER_TOP_RIGHT, CORNER_BOTTOM_LEFT, CORNER_BOTTOM_RIGHT:
+		return nil
+	}
+	return fmt.Errorf(
+		"unknown overlay corner '%s', expected one of %v",
+		corner,
+		[]string{CORNER_TOP_LEFT, CORNER_TOP_RIGHT, CORNER_BOTTOM_LEFT, CORNER_BOTTOM_RIGHT},
+	)
+}
+
+// IsEmpty checks whether the overlay renders nothing.
+func (overlay Overlay) IsEmpty() bool {
+	return !overlay.Name && !overlay.Timestamp && overlay.Text == ""
+}
+
+// lines returns the overlay's text lines, top to bottom.
+func (overlay Overlay) lines(name string, capturedAt time.Time) []string {
+	lines := []string{}
+	if overlay.Name && name != "" {
+		lines = append(lines, name)
+	}
+	if overlay.Timestamp {
+		lines = append(lines, capturedAt.Format(OVERLAY_TIME_FORMAT))
+	}
+	if overlay.Text != "" {
+		lines = append(lines, overlay.Text)
+	}
+	return lines
+}
+
+// Draw renders the overlay onto the frame, stacking its lines at the configured
+// corner.
+func (overlay Overlay) Draw(dst draw.Image, name string, capturedAt time.Time) {
+	lines := overlay.lines(name, capturedAt)
+	if len(lines) == 0 {
+		return
+	}
+
+	corner := overlay.Corner
+	if corner == "" {
+		corner = DEFAULT_OVERLAY_CORNER
+	}
+	isRight := corner == CORNER_TOP_RIGHT || corner == CORNER_BOTTOM_RIGHT
+	isBottom := corner == CORNER_BOTTOM_LEFT || corner == CORNER_BOTTOM_RIGHT
+
+	// Bottom corners stack upwards, keeping the last line nearest to the edge.
+	bounds := dst.Bounds()
+	lineHeight := labelSize("").Y
+	y := bounds.Min.Y
+	if isBottom {
+		y = bounds.Max.Y - len(lines)*lineHeight
+	}
+
+	for _, line := range lines {
+		x := bounds.Min.X
+		if isRight {
+			x = bounds.Max.X - labelSize(line).X
+		}
+		drawLabel(dst, image.Point{X: x, Y: y}, line)
+		y += lineHeight
+	}
+}
@@ -4,6 +4,9 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net"
+	"strconv"
+	"sync"
 	"time"
 
 	"4bit.api/v0/database"
@@ -11,8 +14,21 @@ import (
 )
 
 const (
-	// HTTP/1 Streaming endpoint format string.
-	STREAM_ENDPOINT_FMT = "http://%s:%d/stream"
+	// HTTP/1 Streaming endpoint format string, given the host & path.
+	STREAM_ENDPOINT_FMT = "http://%s%s"
+
+	// Streaming path of cameras which don't specify one.
+	DEFAULT_STREAM_PATH = "/stream"
+
+	// Interval camera hostnames are re-resolved at, following DHCP lease changes.
+	DEFAULT_RESOLVE_INTERVAL = 1 * time.Minute
+
+	// Duration to wait on resolving a single hostname.
+	RESOLVE_TIMEOUT = 2 * time.Second
+
+	// Delay before retrying a hostname which failed to resolve, doubling on each
+	// consecutive failure up to the resolve interval.
+	RESOLVE_RETRY_DELAY = 5 * time.Second
 )
 
 var (
@@ -25,10 +41,17 @@ type CameraPoller struct {
 
 	// List of all camera entries to poll.
 	cameras         []database.CameraEntry
-	PollWorkers     map[string]*CameraPollWorker
+	pollWorkers     map[uint64]*CameraPollWorker // Keyed by camera ID, guarded by the mutex.
+	workersMutex    sync.RWMutex
 	PollingInterval time.Duration
 	BufferSizeBytes uint64
 
+	// Hostname resolution of cameras, keyed by hostname, guarded by the mutex.
+	Resolver        Resolver
+	ResolveInterval time.Duration
+	resolvedHosts   map[string]*hostResolution
+	resolveMutex    sync.Mutex
+
 	// Routine status.
 	IsRunning           bool
 	ShouldUpdateEntries bool
@@ -51,7 +74,10 @@ func NewCameraPoller(ctx *context.Context) (*CameraPoller, error) {
 		BufferSizeBytes:     5 * (1024 * 1024), // 5MB
 		IsRunning:           false,
 		ShouldUpdateEntries: false,
-		PollWorkers:         map[string]*CameraPollWorker{},
+		pollWorkers:         map[uint64]*CameraPollWorker{},
+		Resolver:            &MdnsResolver{},
+		ResolveInterval:     DEFAULT_RESOLVE_INTERVAL,
+		resolvedHosts:       map[string]*hostResolution{},
 	}
 
 	if err := cameraPoller.UpdateStatus(); err != nil {
@@ -67,23 +93,230 @@ func (camPoller *CameraPoller) UpdateStatus() error {
 	log.Println("Updating CamerPoller status")
 
 	// Grab the current state of all cameras.
-	db := database.DbInstance
-	cameras := []database.CameraEntry{}
-	if err := db.Model(&cameras).Select(); err != nil {
+	cameras, err := database.StoreInstance.Cameras.List(0)
+	if err != nil {
 		return fmt.Errorf("failed to query all camera entries from database: %v", err)
 	}
 	camPoller.cameras = cameras
+	camPoller.trackHosts(cameras)
 
 	return nil
 }
 
+// StreamEndpoint constructs the streaming endpoint of a camera.
+func StreamEndpoint(cameraEntry database.CameraEntry) string {
+	return streamEndpoint(cameraEntry, cameraEntry.IP)
+}
+
+// streamEndpoint constructs the streaming endpoint of a camera given the address
+// its host resolved to.
+func streamEndpoint(cameraEntry database.CameraEntry, addr string) string {
+	path := cameraEntry.Path
+	if path == "" {
+		path = DEFAULT_STREAM_PATH
+	}
+	host := net.JoinHostPort(addr, strconv.Itoa(int(cameraEntry.Port)))
+	return fmt.Sprintf(STREAM_ENDPOINT_FMT, host, path)
+}
+
+// Resolution state of a camera hostname.
+type hostResolution struct {
+	addr       string    // Last resolved address, empty until first resolved.
+	failures   uint      // Consecutive failed lookups.
+	nextLookup time.Time // Time the hostname is due to be resolved at.
+}
+
+// workerEndpoint constructs the streaming endpoint polled by a camera's worker,
+// using the resolved address of its hostname when known.
+func (camPoller *CameraPoller) workerEndpoint(cameraEntry database.CameraEntry) string {
+	camPoller.resolveMutex.Lock()
+	defer camPoller.resolveMutex.Unlock()
+	if resolution, ok := camPoller.resolvedHosts[cameraEntry.IP]; ok && resolution.addr != "" {
+		return streamEndpoint(cameraEntry, resolution.addr)
+	}
+	return StreamEndpoint(cameraEntry)
+}
+
+// trackHosts registers the hostnames of cameras to be resolved right away,
+// forgetting hostnames no longer used by any camera.
+func (camPoller *CameraPoller) trackHosts(cameras []database.CameraEntry) {
+	camPoller.resolveMutex.Lock()
+	defer camPoller.resolveMutex.Unlock()
+
+	hosts := map[string]bool{}
+	for _, cameraEntry := range cameras {
+		host := cameraEntry.IP
+		if net.ParseIP(host) != nil {
+			continue
+		}
+		hosts[host] = true
+		if _, ok := camPoller.resolvedHosts[host]; !ok {
+			camPoller.resolvedHosts[host] = &hostResolution{}
+		}
+	}
+
+	for host := range camPoller.resolvedHosts {
+		if !hosts[host] {
+			delete(camPoller.resolvedHosts, host)
+		}
+	}
+}
+
+// retryDelay returns the delay before resolving a hostname again after the
+// given number of consecutive failures.
+func (camPoller *CameraPoller) retryDelay(failures uint) time.Duration {
+	delay := RESOLVE_RETRY_DELAY
+	for i := uint(1); i < failures && delay < camPoller.ResolveInterval; i++ {
+		delay *= 2
+	}
+	if delay > camPoller.ResolveInterval {
+		delay = camPoller.ResolveInterval
+	}
+	return delay
+}
+
+// resolveHosts resolves the hostnames of cameras which are due, keeping the last
+// known address of hostnames which fail to resolve. Resolved hostnames are due
+// again after the resolve interval, while failing ones back off.
+func (camPoller *CameraPoller) resolveHosts(now time.Time) {
+	camPoller.resolveMutex.Lock()
+	dueHosts := []string{}
+	for host, resolution := range camPoller.resolvedHosts {
+		if !now.Before(resolution.nextLookup) {
+			dueHosts = append(dueHosts, host)
+		}
+	}
+	camPoller.resolveMutex.Unlock()
+
+	for _, host := range dueHosts {
+		ctx, cancel := context.WithTimeout(context.Background(), RESOLVE_TIMEOUT)
+		addrs, err := camPoller.Resolver.LookupHost(ctx, host)
+		cancel()
+		if err == nil && len(addrs) == 0 {
+			err = fmt.Errorf("no addresses found")
+		}
+
+		camPoller.resolveMutex.Lock()
+		resolution, ok := camPoller.resolvedHosts[host]
+		if !ok {
+			// Camera removed while resolving.
+			camPoller.resolveMutex.Unlock()
+			continue
+		}
+		if err != nil {
+			resolution.failures++
+			resolution.nextLookup = now.Add(camPoller.retryDelay(resolution.failures))
+			log.Printf("failed to resolve camera host '%s', retrying in %v: %v\n", host, resolution.nextLookup.Sub(now), err)
+		} else {
+			if addrs[0] != resolution.addr {
+				log.Printf("camera host '%s' resolved to %s\n", host, addrs[0])
+				resolution.addr = addrs[0]
+			}
+			resolution.failures = 0
+			resolution.nextLookup = now.Add(camPoller.ResolveInterval)
+		}
+		camPoller.resolveMutex.Unlock()
+	}
+}
+
+// resolveHostsRoutine is intended to run in a goroutine which resolves the
+// hostnames of cameras as they're due, apart from the workers' updates such
+// that hostnames which fail to resolve don't hold them up.
+func (camPoller *CameraPoller) resolveHostsRoutine() {
+	tick := time.NewTicker(1 * time.Second)
+	defer tick.Stop()
+	ctx := *camPoller.ctx
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-tick.C:
+			camPoller.resolveHosts(now)
+		}
+	}
+}
+
+// GetWorker returns the poll worker of the camera with the given ID.
+func (camPoller *CameraPoller) GetWorker(id uint64) (*CameraPollWorker, bool) {
+	camPoller.workersMutex.RLock()
+	defer camPoller.workersMutex.RUnlock()
+	worker, ok := camPoller.pollWorkers[id]
+	return worker, ok
+}
+
+// GetAllWorkers returns a copy of the poll workers keyed by camera ID.
+func (camPoller *CameraPoller) GetAllWorkers() map[uint64]*CameraPollWorker {
+	camPoller.workersMutex.RLock()
+	defer camPoller.workersMutex.RUnlock()
+
+	workers := map[uint64]*CameraPollWorker{}
+	for id, worker := range camPoller.pollWorkers {
+		workers[id] = worker
+	}
+	return workers
+}
+
+// GetWorkers returns the poll workers keyed by camera ID, limited to the cameras
+// within a group and/or within groups having a tag when given.
+// It returns an error reflecting the failure state.
+func (camPoller *CameraPoller) GetWorkers(group string, tag string) (map[uint64]*CameraPollWorker, error) {
+	if group == "" && tag == "" {
+		return camPoller.GetAllWorkers(), nil
+	}
+
+	cameras, err := database.StoreInstance.Cameras.GetGroupCameras(group, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	camPoller.workersMutex.RLock()
+	defer camPoller.workersMutex.RUnlock()
+	workers := map[uint64]*CameraPollWorker{}
+	for _, cameraEntry := range cameras {
+		if worker, ok := camPoller.pollWorkers[cameraEntry.Id]; ok {
+			workers[cameraEntry.Id] = worker
+		}
+	}
+	return workers, nil
+}
+
+// GetWorkersByIP returns the poll workers of the cameras with the given IP,
+// keyed by camera ID, as a single host may serve multiple streams.
+func (camPoller *CameraPoller) GetWorkersByIP(ip string) map[uint64]*CameraPollWorker {
+	camPoller.workersMutex.RLock()
+	defer camPoller.workersMutex.RUnlock()
+
+	workers := map[uint64]*CameraPollWorker{}
+	for id, worker := range camPoller.pollWorkers {
+		if worker.IP == ip {
+			workers[id] = worker
+		}
+	}
+	return workers
+}
+
+// setWorker adds or replaces the poll worker of a camera.
+func (camPoller *CameraPoller) setWorker(id uint64, worker *CameraPollWorker) {
+	camPoller.workersMutex.Lock()
+	defer camPoller.workersMutex.Unlock()
+	camPoller.pollWorkers[id] = worker
+}
+
+// removeWorker removes the poll worker of a camera.
+func (camPoller *CameraPoller) removeWorker(id uint64) {
+	camPoller.workersMutex.Lock()
+	defer camPoller.workersMutex.Unlock()
+	delete(camPoller.pollWorkers, id)
+}
+
 // updateWorkerStatus is intended to run in a goroutine which constantly
 // polls and updates the workers to reflect the current active state.
 func (camPoller *CameraPoller) updateWorkerStatus() {
 	// Construct a poll rate.
 	tick := time.NewTicker(1 * time.Second)
 	ctx := *camPoller.ctx
-	workerCtxCancelMp := map[string]context.CancelFunc{}
+	workerCtxCancelMp := map[uint64]context.CancelFunc{}
 
 	for {
 		select {
@@ -104,26 +337,46 @@ func (camPoller *CameraPoller) updateWorkerStatus() {
 			// Check the current state of workers, creating/terminating as needed
 			// to reflect the current state.
 			for _, cameraEntry := range camPoller.cameras {
-				worker, ok := camPoller.PollWorkers[cameraEntry.IP]
+				worker, ok := camPoller.GetWorker(cameraEntry.Id)
+
+				// Restart workers of cameras whose endpoint or name changed, ie. after
+				// being updated or their hostname resolving to a new address.
+				if ok && (worker.endpoint != camPoller.workerEndpoint(cameraEntry) || worker.Name != cameraEntry.Name) {
+					log.Printf("camera[id=%d] changed, restarting worker[%s]\n", cameraEntry.Id, worker.endpoint)
+					workerCtxCancelMp[cameraEntry.Id]()
+					camPoller.removeWorker(cameraEntry.Id)
+					delete(workerCtxCancelMp, cameraEntry.Id)
+					ok = false
+				}
+
 				if !ok {
 					log.Printf(
-						"creating new worker to handle camera[ip=%s|name=%s]\n",
+						"creating new worker to handle camera[id=%d|ip=%s|name=%s]\n",
+						cameraEntry.Id,
 						cameraEntry.IP,
 						cameraEntry.Name,
 					)
 
-					httpStreamEndpoint := fmt.Sprintf(STREAM_ENDPOINT_FMT, cameraEntry.IP, cameraEntry.Port)
 					workerCtx, workerCancel := context.WithCancel(context.TODO())
 					newWorker := NewCameraPollWorker(&workerCtx, CameraPollWorkerOptions{
-						Endpoint: httpStreamEndpoint,
-						Name:     cameraEntry.Name,
-						RootCtx:  camPoller.ctx,
+						Endpoint:   camPoller.workerEndpoint(cameraEntry),
+						Id:         cameraEntry.Id,
+						IP:         cameraEntry.IP,
+						Name:       cameraEntry.Name,
+						RootCtx:    camPoller.ctx,
+						Adjustment: cameraEntry.Adjustment,
 					})
 
 					// Store the worker's context cancel func, used for tearing down workers.
-					camPoller.PollWorkers[cameraEntry.IP] = newWorker
-					workerCtxCancelMp[cameraEntry.IP] = workerCancel
-				} else if !worker.IsRunning {
+					camPoller.setWorker(cameraEntry.Id, newWorker)
+					workerCtxCancelMp[cameraEntry.Id] = workerCancel
+					continue
+				}
+
+				// Reflect adjustment changes on running workers.
+				worker.SetAdjustment(cameraEntry.Adjustment)
+
+				if !worker.IsRunning {
 					log.Printf(
 						"restarting worker[%s] for camera[ip=%s|name=%s]\n",
 						worker.endpoint,
@@ -142,11 +395,11 @@ func (camPoller *CameraPoller) updateWorkerStatus() {
 				}
 			}
 
-			for mpKey := range camPoller.PollWorkers {
+			for mpKey := range camPoller.GetAllWorkers() {
 				// Verify worker is not stale.
 				found := false
 				for _, camera := range camPoller.cameras {
-					if mpKey == camera.IP {
+					if mpKey == camera.Id {
 						found = true
 						break
 					}
@@ -154,9 +407,9 @@ func (camPoller *CameraPoller) updateWorkerStatus() {
 
 				if !found {
 					// Terminate worker.
-					log.Printf("Stale worker[%s], terminating...\n", mpKey)
+					log.Printf("Stale worker[%d], terminating...\n", mpKey)
 					workerCtxCancelMp[mpKey]()
-					delete(camPoller.PollWorkers, mpKey)
+					camPoller.removeWorker(mpKey)
 					delete(workerCtxCancelMp, mpKey)
 				}
 			}
@@ -174,6 +427,7 @@ func (camPoller *CameraPoller) StartPolling() error {
 	log.Println("Starting camera poller")
 	camPoller.IsRunning = true
 	go camPoller.updateWorkerStatus()
+	go camPoller.resolveHostsRoutine()
 
 	return nil
 }
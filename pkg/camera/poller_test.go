@@ -0,0 +1,140 @@
+package camera
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"4bit.api/v0/database"
+)
+
+// Resolver which finds hosts without any addresses.
+type emptyResolver struct {
+	StubResolver
+}
+
+func (resolver *emptyResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	return []string{}, nil
+}
+
+// newTestPoller constructs a poller resolving hostnames through the resolver,
+// without polling any camera.
+func newTestPoller(resolver Resolver) *CameraPoller {
+	return &CameraPoller{
+		Resolver:        resolver,
+		ResolveInterval: DEFAULT_RESOLVE_INTERVAL,
+		resolvedHosts:   map[string]*hostResolution{},
+	}
+}
+
+func TestResolveHostsFollowsAddressChanges(t *testing.T) {
+	resolver := &StubResolver{Hosts: map[string][]string{"cam.local": {"10.0.0.1"}}}
+	camPoller := newTestPoller(resolver)
+	camEntry := database.CameraEntry{IP: "cam.local", Port: 8080, Path: "/stream"}
+
+	// Hostnames are polled as is until first resolved.
+	camPoller.trackHosts([]database.CameraEntry{camEntry})
+	if endpoint := camPoller.workerEndpoint(camEntry); endpoint != "http://cam.local:8080/stream" {
+		t.Errorf("expected the unresolved endpoint, got '%s'", endpoint)
+	}
+
+	now := time.Now()
+	camPoller.resolveHosts(now)
+	if endpoint := camPoller.workerEndpoint(camEntry); endpoint != "http://10.0.0.1:8080/stream" {
+		t.Errorf("expected the resolved endpoint, got '%s'", endpoint)
+	}
+
+	// The new address is only picked up once the hostname is due again.
+	resolver.Hosts["cam.local"] = []string{"10.0.0.2"}
+	camPoller.resolveHosts(now.Add(camPoller.ResolveInterval / 2))
+	if endpoint := camPoller.workerEndpoint(camEntry); endpoint != "http://10.0.0.1:8080/stream" {
+		t.Errorf("expected the endpoint to be kept until due, got '%s'", endpoint)
+	}
+
+	camPoller.resolveHosts(now.Add(camPoller.ResolveInterval))
+	if endpoint := camPoller.workerEndpoint(camEntry); endpoint != "http://10.0.0.2:8080/stream" {
+		t.Errorf("expected the re-resolved endpoint, got '%s'", endpoint)
+	}
+}
+
+func TestResolveHostsBacksOffFailures(t *testing.T) {
+	resolver := &StubResolver{Hosts: map[string][]string{"cam.local": {"10.0.0.1"}}}
+	camPoller := newTestPoller(resolver)
+	camEntry := database.CameraEntry{IP: "cam.local", Port: 8080}
+	camPoller.trackHosts([]database.CameraEntry{camEntry})
+
+	now := time.Now()
+	camPoller.resolveHosts(now)
+
+	// Failing lookups keep the last known address.
+	delete(resolver.Hosts, "cam.local")
+	now = now.Add(camPoller.ResolveInterval)
+	camPoller.resolveHosts(now)
+	if endpoint := camPoller.workerEndpoint(camEntry); endpoint != "http://10.0.0.1:8080/stream" {
+		t.Errorf("expected the last known endpoint, got '%s'", endpoint)
+	}
+
+	// Retries double from the retry delay, up to the resolve interval.
+	for _, delay := range []time.Duration{
+		RESOLVE_RETRY_DELAY,
+		2 * RESOLVE_RETRY_DELAY,
+		4 * RESOLVE_RETRY_DELAY,
+		8 * RESOLVE_RETRY_DELAY,
+		camPoller.ResolveInterval,
+		camPoller.ResolveInterval,
+	} {
+		resolution := camPoller.resolvedHosts["cam.local"]
+		if next := resolution.nextLookup.Sub(now); next != delay {
+			t.Errorf("expected a retry in %v after %d failures, got %v", delay, resolution.failures, next)
+		}
+		now = resolution.nextLookup
+		camPoller.resolveHosts(now)
+	}
+
+	// Resolving again resets the backoff.
+	resolver.Hosts["cam.local"] = []string{"10.0.0.3"}
+	now = camPoller.resolvedHosts["cam.local"].nextLookup
+	camPoller.resolveHosts(now)
+	resolution := camPoller.resolvedHosts["cam.local"]
+	if resolution.failures != 0 || resolution.nextLookup.Sub(now) != camPoller.ResolveInterval {
+		t.Errorf("expected the backoff to reset, got %d failures with the next lookup in %v", resolution.failures, resolution.nextLookup.Sub(now))
+	}
+	if endpoint := camPoller.workerEndpoint(camEntry); endpoint != "http://10.0.0.3:8080/stream" {
+		t.Errorf("expected the re-resolved endpoint, got '%s'", endpoint)
+	}
+}
+
+func TestResolveHostsRejectsEmptyAddresses(t *testing.T) {
+	camPoller := newTestPoller(&emptyResolver{})
+	camEntry := database.CameraEntry{IP: "cam.local", Port: 8080}
+	camPoller.trackHosts([]database.CameraEntry{camEntry})
+
+	camPoller.resolveHosts(time.Now())
+	resolution := camPoller.resolvedHosts["cam.local"]
+	if resolution.failures != 1 || resolution.addr != "" {
+		t.Errorf("expected an empty lookup to fail, got %+v", resolution)
+	}
+	if endpoint := camPoller.workerEndpoint(camEntry); endpoint != "http://cam.local:8080/stream" {
+		t.Errorf("expected the unresolved endpoint, got '%s'", endpoint)
+	}
+}
+
+func TestTrackHosts(t *testing.T) {
+	camPoller := newTestPoller(&StubResolver{})
+	camPoller.trackHosts([]database.CameraEntry{
+		{IP: "cam.local"},
+		{IP: "other.local"},
+		{IP: "10.0.0.1"},
+		{IP: "::1"},
+	})
+	if len(camPoller.resolvedHosts) != 2 {
+		t.Errorf("expected only hostnames to be tracked, got %v", camPoller.resolvedHosts)
+	}
+
+	// Hostnames no longer used are forgotten, while tracked ones are kept.
+	resolution := camPoller.resolvedHosts["cam.local"]
+	camPoller.trackHosts([]database.CameraEntry{{IP: "cam.local"}})
+	if len(camPoller.resolvedHosts) != 1 || camPoller.resolvedHosts["cam.local"] != resolution {
+		t.Errorf("expected only 'cam.local' to remain tracked, got %v", camPoller.resolvedHosts)
+	}
+}
@@ -0,0 +1,74 @@
+package camera
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// Service advertised on the LAN through DNS-SD.
+type Service struct {
+	Instance string            // Fully qualified instance name, ie. "Garage._http._tcp.local.".
+	Host     string            // Hostname of the service, ie. "garage-cam.local.".
+	Addrs    []string          // Addresses of the host, when advertised.
+	Port     uint16            // Port the service listens on.
+	Txt      map[string]string // Key-value pairs of the service's TXT records.
+}
+
+// Name returns the human readable name of the service's instance.
+func (service Service) Name() string {
+	name := service.Instance
+	if idx := strings.Index(name, "._"); idx != -1 {
+		name = name[:idx]
+	}
+	return name
+}
+
+// Resolver resolves camera hostnames into addresses, and browses for services
+// advertised on the LAN.
+type Resolver interface {
+	// LookupHost returns the addresses of the given host.
+	LookupHost(ctx context.Context, host string) ([]string, error)
+
+	// Browse returns the instances of the given service type, ie. "_http._tcp".
+	Browse(ctx context.Context, serviceType string) ([]Service, error)
+}
+
+// Resolver which looks up ".local" hostnames & browses services through
+// multicast DNS, falling back on the system's resolver for other hostnames.
+type MdnsResolver struct{}
+
+// isLocalHost checks whether the host is within the mDNS ".local" domain.
+func isLocalHost(host string) bool {
+	return strings.HasSuffix(strings.ToLower(strings.TrimSuffix(host, ".")), ".local")
+}
+
+func (resolver *MdnsResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	if !isLocalHost(host) {
+		return net.DefaultResolver.LookupHost(ctx, host)
+	}
+	return mdnsLookupHost(ctx, host)
+}
+
+func (resolver *MdnsResolver) Browse(ctx context.Context, serviceType string) ([]Service, error) {
+	return mdnsBrowse(ctx, serviceType)
+}
+
+// Resolver answering from static hosts & services, ie. for local testing.
+type StubResolver struct {
+	Hosts    map[string][]string  // Addresses keyed by hostname.
+	Services map[string][]Service // Instances keyed by service type.
+}
+
+func (resolver *StubResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	addrs, ok := resolver.Hosts[strings.TrimSuffix(host, ".")]
+	if !ok || len(addrs) == 0 {
+		return nil, fmt.Errorf("no such host '%s'", host)
+	}
+	return addrs, nil
+}
+
+func (resolver *StubResolver) Browse(ctx context.Context, serviceType string) ([]Service, error) {
+	return resolver.Services[serviceType], nil
+}
@@ -7,11 +7,14 @@ import (
 	"context"
 	"fmt"
 	"image"
+	"image/draw"
 	"image/jpeg"
 	"log"
 	"net/http"
 	"sync"
 	"time"
+
+	"4bit.api/v0/database"
 )
 
 type CameraPollSnapshot struct {
@@ -25,31 +28,44 @@ type CameraPollWorker struct {
 	endpoint     string
 	lastReadData []byte
 	lastUpdated  time.Time
+	overlay      Overlay
+	masks        []database.CameraPrivacyMask
 	mutex        *sync.Mutex
 
 	IsRunning bool
+	Id        uint64
+	IP        string
 	Name      string
 }
 
 type CameraPollWorkerOptions struct {
 	Endpoint string
+	Id       uint64
+	IP       string
 	Name     string
 	RootCtx  *context.Context
+
+	// Optional overlay & privacy masks applied to each frame.
+	Adjustment *database.CameraAdjsustment
 }
 
 // NewCameraPollWorker creates a new CameraPollWorker instance given the options
 // and context.
 func NewCameraPollWorker(ctx *context.Context, opts CameraPollWorkerOptions) *CameraPollWorker {
-	return &CameraPollWorker{
+	worker := &CameraPollWorker{
 		ctx:          ctx,
 		rootCtx:      opts.RootCtx,
 		endpoint:     opts.Endpoint,
 		lastReadData: []byte{},
 		lastUpdated:  time.Now(),
 		IsRunning:    false,
+		Id:           opts.Id,
+		IP:           opts.IP,
 		Name:         opts.Name,
 		mutex:        &sync.Mutex{},
 	}
+	worker.SetAdjustment(opts.Adjustment)
+	return worker
 }
 
 // GetSnapshot returns a copy of the last image taken.
@@ -68,6 +84,38 @@ func (worker *CameraPollWorker) GetSnapshot() *CameraPollSnapshot {
 	}
 }
 
+// SetAdjustment replaces the overlay & privacy masks applied to subsequent frames.
+func (worker *CameraPollWorker) SetAdjustment(adjustment *database.CameraAdjsustment) {
+	masks := []database.CameraPrivacyMask{}
+	if adjustment != nil {
+		masks = adjustment.PrivacyMasks
+	}
+
+	worker.mutex.Lock()
+	defer worker.mutex.Unlock()
+	worker.overlay = NewOverlay(adjustment)
+	worker.masks = masks
+}
+
+// adjustFrame blacks out the privacy masks of a frame, then burns in its overlay.
+func (worker *CameraPollWorker) adjustFrame(img image.Image, capturedAt time.Time) image.Image {
+	worker.mutex.Lock()
+	overlay, masks := worker.overlay, worker.masks
+	worker.mutex.Unlock()
+	if len(masks) == 0 && overlay.IsEmpty() {
+		return img
+	}
+
+	bounds := img.Bounds()
+	frame := image.NewRGBA(bounds)
+	draw.Draw(frame, bounds, img, bounds.Min, draw.Src)
+	for _, mask := range masks {
+		fillPrivacyMask(frame, mask)
+	}
+	overlay.Draw(frame, worker.Name, capturedAt)
+	return frame
+}
+
 // cleanup unregisters the worker.
 func (worker *CameraPollWorker) cleanup() {
 	worker.IsRunning = false
@@ -121,10 +169,15 @@ pollLoop:
 			if err != nil {
 				continue
 			}
+			capturedAt := time.Now()
 			if Verbose {
 				log.Printf("worker[%s] decoded image format: %s\n", worker.endpoint, imgFmt)
 			}
 
+			// Adjust the frame before it's stored, so that masked regions never
+			// leave the server.
+			img = worker.adjustFrame(img, capturedAt)
+
 			// Encode image into jpeg
 			buf := new(bytes.Buffer)
 			if err := jpeg.Encode(buf, img, nil); err != nil {
@@ -138,7 +191,7 @@ pollLoop:
 			// Store the decoded image.
 			worker.mutex.Lock()
 			worker.lastReadData = buf.Bytes()
-			worker.lastUpdated = time.Now()
+			worker.lastUpdated = capturedAt
 			worker.mutex.Unlock()
 
 			// Deadline met, reset.
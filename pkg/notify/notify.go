@@ -0,0 +1,196 @@
+// The notify package delivers messages through pluggable notification channels,
+// such as webhooks, email and push services, configured as named routes.
+package notify
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"4bit.api/v0/database"
+	"github.com/go-pg/pg/v10"
+)
+
+// Supported notifier kinds.
+const (
+	KIND_TELEGRAM = "telegram"
+	KIND_WEBHOOK  = "webhook"
+	KIND_SMTP     = "smtp"
+	KIND_NTFY     = "ntfy"
+	KIND_GOTIFY   = "gotify"
+)
+
+const (
+	// Timeout of requests made by HTTP-based notifiers.
+	HTTP_TIMEOUT = 10 * time.Second
+
+	// Value shown in place of a route's secret options.
+	REDACTED = "<redacted>"
+)
+
+// Message delivered through a notifier.
+type Message struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+	Image []byte `json:"image"` // Optional image, base64-encoded in JSON.
+}
+
+// Text returns the message's title and body as a single text.
+func (msg Message) Text() string {
+	if msg.Title == "" {
+		return msg.Body
+	}
+	return msg.Title + "\n" + msg.Body
+}
+
+// Notifier delivers messages through a notification channel.
+type Notifier interface {
+	Notify(msg Message) error
+}
+
+// Factory constructs a notifier from a route's kind-specific options.
+type Factory func(config map[string]string) (Notifier, error)
+
+var (
+	factories = map[string]Factory{
+		KIND_WEBHOOK: NewWebhookNotifier,
+		KIND_SMTP:    NewSmtpNotifier,
+		KIND_NTFY:    NewNtfyNotifier,
+		KIND_GOTIFY:  NewGotifyNotifier,
+	}
+	factoryMutex = &sync.Mutex{}
+
+	httpClient = &http.Client{Timeout: HTTP_TIMEOUT}
+
+	// Config keys whose values are shown when listing routes, hiding the values
+	// of any other key.
+	publicKeys = map[string]bool{
+		"url":      true,
+		"host":     true,
+		"port":     true,
+		"from":     true,
+		"to":       true,
+		"username": true,
+		"priority": true,
+		"chat_id":  true,
+	}
+)
+
+// RegisterKind adds a kind of notifier routes can be configured with. Used by
+// notifiers living outside of this package, such as telegram's.
+func RegisterKind(kind string, factory Factory) {
+	factoryMutex.Lock()
+	defer factoryMutex.Unlock()
+	factories[kind] = factory
+}
+
+// Kinds returns the supported notifier kinds.
+func Kinds() []string {
+	factoryMutex.Lock()
+	defer factoryMutex.Unlock()
+
+	kinds := []string{}
+	for kind := range factories {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// New constructs a notifier of the given kind.
+// It returns the notifier along with an error reflecting the failure state.
+func New(kind string, config map[string]string) (Notifier, error) {
+	factoryMutex.Lock()
+	factory, ok := factories[kind]
+	factoryMutex.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown notifier kind '%s', expected one of %v", kind, Kinds())
+	}
+	return factory(config)
+}
+
+// GetRoute retrieves a notification route by name.
+func GetRoute(name string) (*database.NotifyRoute, error) {
+	db := database.DbInstance
+	route := database.NotifyRoute{}
+	if err := db.Model(&route).Where("name = ?", name).First(); err != nil {
+		if err == pg.ErrNoRows {
+			return nil, fmt.Errorf("notification route '%s' not found", name)
+		}
+		return nil, fmt.Errorf("failed to query notification route '%s': %v", name, err)
+	}
+	return &route, nil
+}
+
+// Send delivers a message through the given notification route.
+// It returns an error reflecting the failure state.
+func Send(routeName string, msg Message) error {
+	route, err := GetRoute(routeName)
+	if err != nil {
+		return err
+	}
+
+	notifier, err := New(route.Kind, route.Config)
+	if err != nil {
+		return fmt.Errorf("invalid notification route '%s': %v", route.Name, err)
+	}
+	if err := notifier.Notify(msg); err != nil {
+		return fmt.Errorf("failed to notify route '%s': %v", route.Name, err)
+	}
+	return nil
+}
+
+// Redact returns a copy of a route with its secret options hidden, only showing
+// options known not to be secrets. Credentials embedded within URLs are hidden
+// as well.
+func Redact(route database.NotifyRoute) database.NotifyRoute {
+	config := map[string]string{}
+	for key, value := range route.Config {
+		if !publicKeys[key] {
+			value = REDACTED
+		} else if key == "url" {
+			value = redactUrl(value)
+		}
+		config[key] = value
+	}
+	route.Config = config
+	return route
+}
+
+// redactUrl hides the password & query of a URL, which may hold credentials.
+func redactUrl(value string) string {
+	parsedUrl, err := url.Parse(value)
+	if err != nil {
+		return REDACTED
+	}
+	if parsedUrl.RawQuery != "" {
+		parsedUrl.RawQuery = REDACTED
+	}
+	return parsedUrl.Redacted()
+}
+
+// requireConfig extracts the required options from a route's config.
+// It returns the options in order along with an error reflecting the missing option.
+func requireConfig(config map[string]string, keys ...string) ([]string, error) {
+	values := []string{}
+	for _, key := range keys {
+		value := strings.TrimSpace(config[key])
+		if value == "" {
+			return nil, fmt.Errorf("missing '%s' option", key)
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// checkResponse verifies that an HTTP-based notifier's request succeeded.
+func checkResponse(resp *http.Response) error {
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected response status '%s'", resp.Status)
+	}
+	return nil
+}
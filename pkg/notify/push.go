@@ -0,0 +1,119 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Notifier which publishes messages to an ntfy topic.
+type NtfyNotifier struct {
+	TopicUrl string // ie. "https://ntfy.sh/my-topic".
+	Token    string // Optional access token.
+}
+
+// NewNtfyNotifier constructs an ntfy notifier given its "url" topic and optional
+// "token" options.
+func NewNtfyNotifier(config map[string]string) (Notifier, error) {
+	values, err := requireConfig(config, "url")
+	if err != nil {
+		return nil, err
+	}
+	return &NtfyNotifier{
+		TopicUrl: values[0],
+		Token:    config["token"],
+	}, nil
+}
+
+func (notifier *NtfyNotifier) Notify(msg Message) error {
+	// Images are uploaded as attachments, moving the body into a header.
+	method := "POST"
+	var body io.Reader = strings.NewReader(msg.Body)
+	headers := map[string]string{}
+	if len(msg.Image) > 0 {
+		method = "PUT"
+		body = bytes.NewReader(msg.Image)
+		headers["Filename"] = "image.jpg"
+		headers["Message"] = strings.ReplaceAll(msg.Body, "\n", "\\n")
+	}
+	if msg.Title != "" {
+		headers["Title"] = msg.Title
+	}
+	if notifier.Token != "" {
+		headers["Authorization"] = "Bearer " + notifier.Token
+	}
+
+	req, err := http.NewRequest(method, notifier.TopicUrl, body)
+	if err != nil {
+		return fmt.Errorf("failed to create ntfy request: %v", err)
+	}
+	for key, value := range headers {
+		req.Header.Add(key, value)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to publish to ntfy: %v", err)
+	}
+	defer resp.Body.Close()
+	return checkResponse(resp)
+}
+
+// Notifier which pushes messages to a Gotify server. Gotify doesn't support
+// attachments, so images are dropped.
+type GotifyNotifier struct {
+	ServerUrl string // ie. "https://gotify.example.com".
+	Token     string // Application token.
+	Priority  int
+}
+
+// NewGotifyNotifier constructs a gotify notifier given its "url", "token" and
+// optional "priority" options.
+func NewGotifyNotifier(config map[string]string) (Notifier, error) {
+	values, err := requireConfig(config, "url", "token")
+	if err != nil {
+		return nil, err
+	}
+
+	priority := 5
+	if value := config["priority"]; value != "" {
+		if priority, err = strconv.Atoi(value); err != nil {
+			return nil, fmt.Errorf("invalid priority '%s'", value)
+		}
+	}
+
+	return &GotifyNotifier{
+		ServerUrl: strings.TrimSuffix(values[0], "/"),
+		Token:     values[1],
+		Priority:  priority,
+	}, nil
+}
+
+func (notifier *GotifyNotifier) Notify(msg Message) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"title":    msg.Title,
+		"message":  msg.Body,
+		"priority": notifier.Priority,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to serialize message: %v", err)
+	}
+
+	req, err := http.NewRequest("POST", notifier.ServerUrl+"/message", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create gotify request: %v", err)
+	}
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("X-Gotify-Key", notifier.Token)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to push to gotify: %v", err)
+	}
+	defer resp.Body.Close()
+	return checkResponse(resp)
+}
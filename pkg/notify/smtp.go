@@ -0,0 +1,127 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+const (
+	// Length base64-encoded attachments are wrapped at.
+	MAX_BASE64_LINE_LENGTH = 76
+)
+
+// Notifier which emails messages through an SMTP server, upgrading the
+// connection with STARTTLS when supported.
+type SmtpNotifier struct {
+	Host     string
+	Port     string
+	Username string // Optional, along with the password.
+	Password string
+	From     string
+	To       []string
+}
+
+// NewSmtpNotifier constructs an email notifier given its "host", "from" and
+// comma-separated "to" options, along with the optional "port", "username" and
+// "password" options.
+func NewSmtpNotifier(config map[string]string) (Notifier, error) {
+	values, err := requireConfig(config, "host", "from", "to")
+	if err != nil {
+		return nil, err
+	}
+
+	recipients := []string{}
+	for _, recipient := range strings.Split(values[2], ",") {
+		if recipient = strings.TrimSpace(recipient); recipient != "" {
+			recipients = append(recipients, recipient)
+		}
+	}
+
+	port := config["port"]
+	if port == "" {
+		port = "587"
+	}
+
+	return &SmtpNotifier{
+		Host:     values[0],
+		Port:     port,
+		Username: config["username"],
+		Password: config["password"],
+		From:     values[1],
+		To:       recipients,
+	}, nil
+}
+
+// buildMail constructs the MIME email of a message, attaching its image if any.
+func (notifier *SmtpNotifier) buildMail(msg Message) ([]byte, error) {
+	subject := msg.Title
+	if subject == "" {
+		subject = "4bit notification"
+	}
+
+	mail := &bytes.Buffer{}
+	writer := multipart.NewWriter(mail)
+	fmt.Fprintf(mail, "From: %s\r\n", notifier.From)
+	fmt.Fprintf(mail, "To: %s\r\n", strings.Join(notifier.To, ", "))
+	fmt.Fprintf(mail, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(mail, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(mail, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(mail, "Content-Type: multipart/mixed; boundary=%s\r\n\r\n", writer.Boundary())
+
+	textPart, err := writer.CreatePart(textproto.MIMEHeader{
+		"Content-Type": {"text/plain; charset=utf-8"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	textPart.Write([]byte(msg.Body))
+
+	if len(msg.Image) > 0 {
+		imagePart, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {"image/jpeg"},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {`attachment; filename="image.jpg"`},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		// Wrap the encoded image, as SMTP limits the length of lines.
+		encoded := base64.StdEncoding.EncodeToString(msg.Image)
+		for len(encoded) > MAX_BASE64_LINE_LENGTH {
+			imagePart.Write([]byte(encoded[:MAX_BASE64_LINE_LENGTH] + "\r\n"))
+			encoded = encoded[MAX_BASE64_LINE_LENGTH:]
+		}
+		imagePart.Write([]byte(encoded))
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return mail.Bytes(), nil
+}
+
+func (notifier *SmtpNotifier) Notify(msg Message) error {
+	mail, err := notifier.buildMail(msg)
+	if err != nil {
+		return fmt.Errorf("failed to construct email: %v", err)
+	}
+
+	var auth smtp.Auth
+	if notifier.Username != "" {
+		auth = smtp.PlainAuth("", notifier.Username, notifier.Password, notifier.Host)
+	}
+
+	addr := notifier.Host + ":" + notifier.Port
+	if err := smtp.SendMail(addr, auth, notifier.From, notifier.To, mail); err != nil {
+		return fmt.Errorf("failed to send email through %s: %v", addr, err)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// Notifier which POSTs messages as JSON to a URL.
+type WebhookNotifier struct {
+	Url string
+
+	// Optional value of the Authorization header.
+	Authorization string
+}
+
+// NewWebhookNotifier constructs a webhook notifier given its "url" and optional
+// "authorization" header options.
+func NewWebhookNotifier(config map[string]string) (Notifier, error) {
+	values, err := requireConfig(config, "url")
+	if err != nil {
+		return nil, err
+	}
+	return &WebhookNotifier{
+		Url:           values[0],
+		Authorization: config["authorization"],
+	}, nil
+}
+
+func (notifier *WebhookNotifier) Notify(msg Message) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to serialize message: %v", err)
+	}
+
+	req, err := http.NewRequest("POST", notifier.Url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %v", err)
+	}
+	req.Header.Add("Content-Type", "application/json")
+	if notifier.Authorization != "" {
+		req.Header.Add("Authorization", notifier.Authorization)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to invoke webhook: %v", err)
+	}
+	defer resp.Body.Close()
+	return checkResponse(resp)
+}
@@ -0,0 +1,215 @@
+// The retention package bounds the growth of node telemetry, rolling raw states
+// into hourly aggregates once they age past their policy, then removing states
+// & aggregates past the policy's max age.
+package retention
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"4bit.api/v0/database"
+)
+
+// Retention policy of a sensor type's telemetry.
+type RetentionPolicy struct {
+	RawDays uint // Days raw states are kept before rolling them into hourly aggregates, 0 keeps them raw.
+	MaxDays uint // Days states & aggregates are kept before removing them, 0 keeps them forever.
+}
+
+// Retention state of a sensor type's telemetry.
+type RetentionStatus struct {
+	Sensor    string
+	Policy    RetentionPolicy
+	LastRunAt *time.Time
+	LastError string
+
+	// Rows handled by the last run.
+	Downsampled uint64 // Raw states rolled into aggregates.
+	Aggregated  uint64 // Aggregates added.
+	Pruned      uint64 // States & aggregates removed.
+
+	// Rows pruned since the server started.
+	TotalPruned uint64
+}
+
+// Sensor types of the node telemetry.
+const (
+	SENSOR_POWER     = "power"
+	SENSOR_BAROMETER = "barometer"
+	SENSOR_HEARTBEAT = "heartbeat"
+)
+
+var (
+	// Telemetry tables of each sensor type.
+	sensorTables = map[string]database.TelemetryTable{
+		SENSOR_POWER:     database.PowerTelemetry,
+		SENSOR_BAROMETER: database.BarometerTelemetry,
+		SENSOR_HEARTBEAT: database.HeartbeatTelemetry,
+	}
+
+	// Policies applied when not configured otherwise.
+	DefaultPolicies = map[string]RetentionPolicy{
+		SENSOR_POWER:     {RawDays: 7, MaxDays: 365},
+		SENSOR_BAROMETER: {RawDays: 7, MaxDays: 365},
+		SENSOR_HEARTBEAT: {MaxDays: 30},
+	}
+
+	// Retention state keyed by sensor type, guarded by the mutex.
+	statuses = map[string]*RetentionStatus{}
+	interval time.Duration
+	mutex    sync.Mutex
+
+	isRunning = false
+)
+
+func init() {
+	for sensor, policy := range DefaultPolicies {
+		statuses[sensor] = &RetentionStatus{
+			Sensor: sensor,
+			Policy: policy,
+		}
+	}
+}
+
+// SensorNames returns the sensor types retention applies to.
+func SensorNames() []string {
+	names := []string{}
+	for name := range sensorTables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Downsamples checks whether a sensor type's raw states can be rolled into
+// aggregates, rather than only pruned.
+func Downsamples(sensor string) bool {
+	return sensorTables[sensor].AggregateTable != ""
+}
+
+// SetPolicy replaces the retention policy of a sensor type.
+// It returns an error reflecting the invalid state.
+func SetPolicy(sensor string, policy RetentionPolicy) error {
+	if _, ok := sensorTables[sensor]; !ok {
+		return fmt.Errorf("unknown sensor '%s', expected one of %v", sensor, SensorNames())
+	}
+	if policy.RawDays != 0 && !Downsamples(sensor) {
+		return fmt.Errorf("%s telemetry can't be rolled into aggregates, only pruned", sensor)
+	}
+	if policy.MaxDays != 0 && policy.RawDays > policy.MaxDays {
+		return fmt.Errorf("%s raw retention of %d days exceeds its max retention of %d days", sensor, policy.RawDays, policy.MaxDays)
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	statuses[sensor].Policy = policy
+	return nil
+}
+
+// GetStatus returns the retention state of each sensor type.
+func GetStatus() []RetentionStatus {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	sensors := []RetentionStatus{}
+	for _, sensor := range SensorNames() {
+		sensors = append(sensors, *statuses[sensor])
+	}
+	return sensors
+}
+
+// GetInterval returns the interval between retention runs, 0 when retention
+// isn't running.
+func GetInterval() time.Duration {
+	mutex.Lock()
+	defer mutex.Unlock()
+	return interval
+}
+
+// days returns the duration of the given number of days.
+func days(n uint) time.Duration {
+	return time.Duration(n) * 24 * time.Hour
+}
+
+// apply downsamples then prunes a sensor type's telemetry according to its
+// policy, recording the rows handled into its status.
+func apply(sensor string, policy RetentionPolicy, now time.Time) *RetentionStatus {
+	db := database.DbInstance
+	table := sensorTables[sensor]
+	status := &RetentionStatus{
+		Sensor:    sensor,
+		Policy:    policy,
+		LastRunAt: &now,
+	}
+
+	if policy.RawDays != 0 {
+		downsampled, aggregated, err := database.DownsampleTelemetry(db, table, now.Add(-days(policy.RawDays)))
+		if err != nil {
+			status.LastError = err.Error()
+			return status
+		}
+		status.Downsampled = uint64(downsampled)
+		status.Aggregated = uint64(aggregated)
+	}
+
+	if policy.MaxDays != 0 {
+		pruned, err := database.PruneTelemetry(db, table, now.Add(-days(policy.MaxDays)))
+		status.Pruned = uint64(pruned)
+		if err != nil {
+			status.LastError = err.Error()
+			return status
+		}
+	}
+	return status
+}
+
+// Run applies the retention policy of each sensor type.
+func Run() {
+	now := time.Now().UTC()
+	for _, sensor := range SensorNames() {
+		mutex.Lock()
+		policy := statuses[sensor].Policy
+		mutex.Unlock()
+
+		status := apply(sensor, policy, now)
+		if status.LastError != "" {
+			log.Printf("Retention of %s telemetry failed: %s\n", sensor, status.LastError)
+		} else if status.Downsampled > 0 || status.Pruned > 0 {
+			log.Printf(
+				"Retention of %s telemetry rolled %d states into %d aggregates, pruned %d rows\n",
+				sensor,
+				status.Downsampled,
+				status.Aggregated,
+				status.Pruned,
+			)
+		}
+
+		mutex.Lock()
+		status.Policy = statuses[sensor].Policy
+		status.TotalPruned = statuses[sensor].TotalPruned + status.Pruned
+		statuses[sensor] = status
+		mutex.Unlock()
+	}
+}
+
+// Start applies the retention policies right away, then on every interval.
+func Start(every time.Duration) {
+	if isRunning {
+		log.Println("Retention is already running")
+		return
+	}
+	isRunning = true
+
+	mutex.Lock()
+	interval = every
+	mutex.Unlock()
+	log.Printf("Starting telemetry retention every %v\n", every)
+
+	for {
+		Run()
+		time.Sleep(every)
+	}
+}
@@ -0,0 +1,142 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Shorthands of common cron expressions.
+var cronAliases = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@weekly":  "0 0 * * 0",
+	"@monthly": "0 0 1 * *",
+}
+
+// Cron expression of the standard five fields, "minute hour day-of-month month
+// day-of-week", each supporting '*', lists, ranges and steps, ie. "*/15 8-18 * * 1-5".
+type Cron struct {
+	Expression string
+
+	// Bitsets of the values matched by each field.
+	minute, hour, dom, month, dow uint64
+
+	// Whether the day fields were unrestricted, determining how they combine.
+	domStar, dowStar bool
+}
+
+// parseCronField parses a single cron field into a bitset of the matched values
+// within the given bounds.
+func parseCronField(field string, min uint64, max uint64) (uint64, error) {
+	bits := uint64(0)
+	for _, part := range strings.Split(field, ",") {
+		rangePart, step := part, uint64(1)
+		if idx := strings.Index(part, "/"); idx >= 0 {
+			parsedStep, err := strconv.ParseUint(part[idx+1:], 10, 64)
+			if err != nil || parsedStep == 0 {
+				return 0, fmt.Errorf("invalid step in '%s'", part)
+			}
+			rangePart, step = part[:idx], parsedStep
+		}
+
+		start, end := min, max
+		if rangePart != "*" {
+			bounds := strings.SplitN(rangePart, "-", 2)
+			parsedStart, err := strconv.ParseUint(bounds[0], 10, 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid value in '%s'", part)
+			}
+			start, end = parsedStart, parsedStart
+
+			if len(bounds) == 2 {
+				if end, err = strconv.ParseUint(bounds[1], 10, 64); err != nil {
+					return 0, fmt.Errorf("invalid range in '%s'", part)
+				}
+			} else if step > 1 {
+				// A stepped single value runs up to the field's bound, ie. "5/15".
+				end = max
+			}
+		}
+		if start < min || end > max || start > end {
+			return 0, fmt.Errorf("'%s' out of range %d-%d", part, min, max)
+		}
+
+		for value := start; value <= end; value += step {
+			bits |= 1 << value
+		}
+	}
+	return bits, nil
+}
+
+// ParseCron parses a cron expression, or one of its aliases such as "@daily".
+// It returns the parsed cron along with an error reflecting the failure state.
+func ParseCron(expression string) (*Cron, error) {
+	expression = strings.TrimSpace(expression)
+	fields := strings.Fields(expression)
+	if alias, ok := cronAliases[expression]; ok {
+		fields = strings.Fields(alias)
+	}
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("expected 5 fields in cron expression '%s', got %d", expression, len(fields))
+	}
+
+	cron := &Cron{
+		Expression: expression,
+		domStar:    fields[2] == "*",
+		dowStar:    fields[4] == "*",
+	}
+	bounds := []struct {
+		bits     *uint64
+		min, max uint64
+	}{
+		{&cron.minute, 0, 59},
+		{&cron.hour, 0, 23},
+		{&cron.dom, 1, 31},
+		{&cron.month, 1, 12},
+		{&cron.dow, 0, 7},
+	}
+	for idx, bound := range bounds {
+		bits, err := parseCronField(fields[idx], bound.min, bound.max)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron field '%s': %v", fields[idx], err)
+		}
+		*bound.bits = bits
+	}
+
+	// Both 0 and 7 refer to sunday.
+	if cron.dow&(1<<7) != 0 {
+		cron.dow |= 1
+	}
+	return cron, nil
+}
+
+// Matches checks whether the cron fires on the given time's minute.
+func (cron *Cron) Matches(t time.Time) bool {
+	if cron.minute&(1<<uint(t.Minute())) == 0 ||
+		cron.hour&(1<<uint(t.Hour())) == 0 ||
+		cron.month&(1<<uint(t.Month())) == 0 {
+		return false
+	}
+
+	// Restricting both day fields matches either one of them.
+	domMatch := cron.dom&(1<<uint(t.Day())) != 0
+	dowMatch := cron.dow&(1<<uint(t.Weekday())) != 0
+	if !cron.domStar && !cron.dowStar {
+		return domMatch || dowMatch
+	}
+	return domMatch && dowMatch
+}
+
+// Next returns the first time after the given time the cron fires on, or a zero
+// time if it doesn't fire within a year.
+func (cron *Cron) Next(after time.Time) time.Time {
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	for end := t.AddDate(1, 0, 0); t.Before(end); t = t.Add(time.Minute) {
+		if cron.Matches(t) {
+			return t
+		}
+	}
+	return time.Time{}
+}
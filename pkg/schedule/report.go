@@ -0,0 +1,187 @@
+package schedule
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"4bit.api/v0/database"
+	"4bit.api/v0/pkg/camera"
+	"4bit.api/v0/pkg/notify"
+	"4bit.api/v0/server/route/parking"
+)
+
+// Supported reports.
+const (
+	REPORT_SNAPSHOT  = "snapshot"  // Collage of every camera's snapshot.
+	REPORT_TELEMETRY = "telemetry" // Temperature & energy summary of nodes.
+	REPORT_PARKING   = "parking"   // Current parking floor of vehicles.
+)
+
+const (
+	// Window of readings summarized by the telemetry report.
+	TELEMETRY_WINDOW = 24 * time.Hour
+
+	// Largest gap between power readings integrated into the energy used.
+	// Larger gaps are assumed to be the node being offline.
+	MAX_ENERGY_READING_GAP = 15 * time.Minute
+)
+
+// snapshotReport generates a collage of the current snapshots of all cameras.
+func snapshotReport(schedule database.Schedule) ([]notify.Message, error) {
+	camPoller := camera.CameraPollerInstance
+	if camPoller == nil {
+		return nil, fmt.Errorf("camera poller not running")
+	}
+
+	workers := []*camera.CameraPollWorker{}
+	for _, worker := range camPoller.GetAllWorkers() {
+		workers = append(workers, worker)
+	}
+	sort.Slice(workers, func(i, j int) bool { return workers[i].Name < workers[j].Name })
+
+	collage, err := camera.NewCollage(workers, camera.CollageOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return []notify.Message{{
+		Title: "📷 Cameras",
+		Body:  fmt.Sprintf("Taken at %v", time.Now().Format(time.RFC822)),
+		Image: collage,
+	}}, nil
+}
+
+// reportNodeIds returns the node a schedule is limited to, or all nodes.
+func reportNodeIds(schedule database.Schedule) ([]uint64, error) {
+	if schedule.NodeId != 0 {
+		return []uint64{schedule.NodeId}, nil
+	}
+
+	nodes, err := database.GetNodes()
+	if err != nil {
+		return nil, err
+	}
+	nodeIds := []uint64{}
+	for _, node := range nodes {
+		nodeIds = append(nodeIds, node.Id)
+	}
+	return nodeIds, nil
+}
+
+// integrateEnergy computes the energy used in mWh from power readings ordered by
+// time, skipping gaps where the node was offline.
+func integrateEnergy(powerStates []database.NodePowerState) float64 {
+	energy := 0.0
+	for idx := 1; idx < len(powerStates); idx++ {
+		prev, curr := powerStates[idx-1], powerStates[idx]
+		elapsed := curr.Timestamp.Sub(prev.Timestamp)
+		if elapsed <= 0 || elapsed > MAX_ENERGY_READING_GAP {
+			continue
+		}
+		energy += float64(prev.Power_mW+curr.Power_mW) / 2 * elapsed.Hours()
+	}
+	return energy
+}
+
+// telemetryReport generates a summary of each node's temperature & energy used
+// over the telemetry window.
+func telemetryReport(schedule database.Schedule) ([]notify.Message, error) {
+	nodeIds, err := reportNodeIds(schedule)
+	if err != nil {
+		return nil, err
+	}
+
+	db := database.DbInstance
+	since := time.Now().UTC().Add(-TELEMETRY_WINDOW)
+	body := ""
+	for _, nodeId := range nodeIds {
+		temperature := struct {
+			Min, Max, Avg float64
+			Count         uint64
+		}{}
+		if err := db.Model((*database.NodeBarometerState)(nil)).
+			ColumnExpr("MIN(temperature) AS min, MAX(temperature) AS max, AVG(temperature) AS avg, COUNT(*) AS count").
+			Where("node_id = ?", nodeId).
+			Where("timestamp >= ?", since).
+			Select(&temperature.Min, &temperature.Max, &temperature.Avg, &temperature.Count); err != nil {
+			return nil, fmt.Errorf("failed to summarize temperature of node %d: %v", nodeId, err)
+		}
+
+		powerStates := []database.NodePowerState{}
+		if err := db.Model(&powerStates).
+			Where("node_id = ?", nodeId).
+			Where("timestamp >= ?", since).
+			Order("timestamp ASC").
+			Select(); err != nil {
+			return nil, fmt.Errorf("failed to query power of node %d: %v", nodeId, err)
+		}
+
+		if temperature.Count == 0 && len(powerStates) == 0 {
+			body += fmt.Sprintf("Node[%d]: No readings\n", nodeId)
+			continue
+		}
+
+		body += fmt.Sprintf("Node[%d]:\n", nodeId)
+		if temperature.Count > 0 {
+			body += fmt.Sprintf(
+				"  Temperature: %.1f-%.1f°C (avg %.1f°C)\n",
+				temperature.Min,
+				temperature.Max,
+				temperature.Avg,
+			)
+		}
+		if len(powerStates) > 0 {
+			body += fmt.Sprintf("  Energy: %.1f mWh\n", integrateEnergy(powerStates))
+		}
+	}
+	if body == "" {
+		body = "No nodes found."
+	}
+
+	return []notify.Message{{
+		Title: fmt.Sprintf("📊 Telemetry over the last %v", TELEMETRY_WINDOW),
+		Body:  body,
+	}}, nil
+}
+
+// parkingReport generates the current parking floor of each vehicle.
+func parkingReport(schedule database.Schedule) ([]notify.Message, error) {
+	nodeIds := []uint64{schedule.NodeId}
+	if schedule.NodeId == 0 {
+		var err error
+		if nodeIds, err = parking.GetVehicleNodeIds(); err != nil {
+			return nil, err
+		}
+	}
+
+	body := ""
+	for _, nodeId := range nodeIds {
+		barEntry, err := parking.GetLastKnownBarometerEntry(nodeId)
+		if err != nil {
+			body += fmt.Sprintf("Node[%d]: Unknown - %v\n", nodeId, err)
+			continue
+		}
+
+		estimate, err := parking.GetParkingFloor(barEntry)
+		if err != nil {
+			body += fmt.Sprintf("Node[%d]: Unknown floor - %v\n", nodeId, err)
+			continue
+		}
+		body += fmt.Sprintf(
+			"Node[%d]: Floor %d (%s, %.0f%%) as of %v\n",
+			nodeId,
+			estimate.Band.Floor,
+			estimate.Band.Garage.Name,
+			estimate.Confidence*100,
+			barEntry.Timestamp.Local().Format(time.RFC822),
+		)
+	}
+	if body == "" {
+		body = "No vehicles found."
+	}
+
+	return []notify.Message{{
+		Title: "🅿️ Parking",
+		Body:  body,
+	}}, nil
+}
@@ -0,0 +1,155 @@
+// The schedule package periodically delivers reports, such as camera snapshots
+// or node telemetry digests, according to persisted cron schedules.
+package schedule
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"time"
+
+	"4bit.api/v0/database"
+	"4bit.api/v0/pkg/notify"
+)
+
+// Report generates the messages delivered by a schedule.
+type Report func(schedule database.Schedule) ([]notify.Message, error)
+
+var (
+	// Reports schedules can deliver, keyed by name.
+	Reports = map[string]Report{
+		REPORT_SNAPSHOT:  snapshotReport,
+		REPORT_TELEMETRY: telemetryReport,
+		REPORT_PARKING:   parkingReport,
+	}
+
+	isRunning = false
+)
+
+// ReportNames returns the names of the supported reports.
+func ReportNames() []string {
+	names := []string{}
+	for name := range Reports {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Validate verifies that a schedule's cron & report are supported.
+// It returns an error reflecting the invalid state.
+func Validate(schedule *database.Schedule) error {
+	if _, err := ParseCron(schedule.Cron); err != nil {
+		return err
+	}
+	if _, ok := Reports[schedule.Report]; !ok {
+		return fmt.Errorf("unknown report '%s', expected one of %v", schedule.Report, ReportNames())
+	}
+	if schedule.Route != "" {
+		if _, err := notify.GetRoute(schedule.Route); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetSchedules retrieves all schedules.
+func GetSchedules() ([]database.Schedule, error) {
+	db := database.DbInstance
+	schedules := []database.Schedule{}
+	if err := db.Model(&schedules).Order("id ASC").Select(); err != nil {
+		return nil, fmt.Errorf("failed to query schedules: %v", err)
+	}
+	return schedules, nil
+}
+
+// Run generates and delivers a schedule's report.
+// It returns an error reflecting the failure state.
+func Run(schedule database.Schedule) error {
+	report, ok := Reports[schedule.Report]
+	if !ok {
+		return fmt.Errorf("unknown report '%s'", schedule.Report)
+	}
+	messages, err := report(schedule)
+	if err != nil {
+		return fmt.Errorf("failed to generate %s report: %v", schedule.Report, err)
+	}
+
+	// Deliver through the schedule's route, falling back on its telegram chat.
+	var notifier notify.Notifier
+	if schedule.Route != "" {
+		route, err := notify.GetRoute(schedule.Route)
+		if err != nil {
+			return err
+		}
+		notifier, err = notify.New(route.Kind, route.Config)
+		if err != nil {
+			return fmt.Errorf("invalid notification route '%s': %v", route.Name, err)
+		}
+	} else {
+		notifier, err = notify.New(notify.KIND_TELEGRAM, map[string]string{
+			"chat_id": strconv.FormatInt(schedule.ChatId, 10),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, msg := range messages {
+		if err := notifier.Notify(msg); err != nil {
+			return fmt.Errorf("failed to deliver %s report: %v", schedule.Report, err)
+		}
+	}
+	return nil
+}
+
+// runDue runs the schedules firing on the given minute.
+func runDue(now time.Time) {
+	schedules, err := GetSchedules()
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	db := database.DbInstance
+	for _, schedule := range schedules {
+		cron, err := ParseCron(schedule.Cron)
+		if err != nil {
+			log.Printf("Skipping schedule[%d]: %v\n", schedule.Id, err)
+			continue
+		}
+		if !cron.Matches(now) {
+			continue
+		}
+
+		schedule.LastRunAt = &now
+		if _, err := db.Model(&schedule).Column("last_run_at").WherePK().Update(); err != nil {
+			log.Printf("Failed to record run of schedule[%d]: %v\n", schedule.Id, err)
+		}
+
+		go func(schedule database.Schedule) {
+			log.Printf("Running %s report of schedule[%d]\n", schedule.Report, schedule.Id)
+			if err := Run(schedule); err != nil {
+				log.Printf("Schedule[%d] failed: %v\n", schedule.Id, err)
+			}
+		}(schedule)
+	}
+}
+
+// Start runs the due schedules at the start of every minute.
+func Start() {
+	if isRunning {
+		log.Println("Scheduler is already running")
+		return
+	}
+	isRunning = true
+	log.Println("Starting scheduler")
+
+	for {
+		now := time.Now()
+		next := now.Truncate(time.Minute).Add(time.Minute)
+		time.Sleep(next.Sub(now))
+		runDue(next)
+	}
+}
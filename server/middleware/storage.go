@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+
+	"4bit.api/v0/database"
+)
+
+// RequirePostgres rejects requests to routes which are only stored in postgres,
+// when the server runs with a different storage backend.
+func RequirePostgres(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := database.RequirePostgres(); err != nil {
+			log.Printf("%s: %v\n", r.URL.Path, err)
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,11 @@
+package admin
+
+import (
+	"context"
+
+	"github.com/gorilla/mux"
+)
+
+func CreateRoutes(ctx *context.Context, r *mux.Router) {
+	CreateRetentionRoutes(r)
+}
@@ -0,0 +1,12 @@
+package interfaces
+
+import (
+	"time"
+
+	"4bit.api/v0/pkg/retention"
+)
+
+type RetentionStatusResponse struct {
+	Interval time.Duration // Interval between runs, 0 when retention is disabled.
+	Sensors  []retention.RetentionStatus
+}
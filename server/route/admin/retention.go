@@ -0,0 +1,34 @@
+package admin
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"4bit.api/v0/pkg/retention"
+	"4bit.api/v0/server/route/admin/interfaces"
+	"github.com/gorilla/mux"
+)
+
+// GET endpoint request for retrieving the telemetry retention policies along
+// with the rows handled by their last run.
+// Returns a RetentionStatusResponse.
+func getRetentionHandler(w http.ResponseWriter, r *http.Request) {
+	res := interfaces.RetentionStatusResponse{
+		Interval: retention.GetInterval(),
+		Sensors:  retention.GetStatus(),
+	}
+	respBody, err := json.Marshal(res)
+	if err != nil {
+		log.Printf("/admin/retention: failed to serialize response: %v\n", err)
+		http.Error(w, "failed to serialize response body", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	w.Write(respBody)
+}
+
+func CreateRetentionRoutes(r *mux.Router) {
+	r.HandleFunc("/retention", getRetentionHandler).Methods("GET")
+}
@@ -0,0 +1,186 @@
+package alert
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"4bit.api/v0/database"
+	"4bit.api/v0/pkg/alert"
+	"4bit.api/v0/server/route/alert/interfaces"
+	"github.com/gorilla/mux"
+)
+
+// GET endpoint request for retrieving active alerts along with the latest
+// alert events.
+// Expects the Request to be of type ListAlertsRequest.
+// Returns a ListAlertsResponse.
+func getAlertsHandler(w http.ResponseWriter, r *http.Request) {
+	bodyBuffer, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "failed to parse the request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	req := interfaces.ListAlertsRequest{}
+	if err := json.Unmarshal(bodyBuffer, &req); err != nil {
+		log.Printf("/alerts: failed to de-serialize request: %v\n", err)
+		http.Error(w, "failed to de-serialize request body", http.StatusBadRequest)
+		return
+	}
+
+	// Set default limit.
+	if req.Limit == 0 {
+		req.Limit = 10
+	}
+
+	db := database.DbInstance
+	events := []database.AlertEvent{}
+	if err := db.Model(&events).
+		Relation("Rule").
+		Order("alert_event.timestamp DESC").
+		Limit(int(req.Limit)).
+		Select(); err != nil {
+		log.Printf("/alerts: failed to query alert events: %v\n", err)
+		http.Error(w, "failed to query alert events", http.StatusInternalServerError)
+		return
+	}
+
+	respBody, err := json.Marshal(interfaces.ListAlertsResponse{
+		Active: alert.GetActiveAlerts(),
+		Events: events,
+	})
+	if err != nil {
+		log.Printf("/alerts: failed to serialize response: %v\n", err)
+		http.Error(w, "failed to serialize response body", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	w.Write(respBody)
+}
+
+// GET endpoint request for retrieving all alert rules.
+// Returns a ListRulesResponse.
+func getRulesHandler(w http.ResponseWriter, r *http.Request) {
+	db := database.DbInstance
+	rules := []database.AlertRule{}
+	if err := db.Model(&rules).Order("id ASC").Select(); err != nil {
+		log.Printf("/alerts/rules: failed to query alert rules: %v\n", err)
+		http.Error(w, "failed to query alert rules", http.StatusInternalServerError)
+		return
+	}
+
+	respBody, err := json.Marshal(interfaces.ListRulesResponse{
+		Rules: rules,
+	})
+	if err != nil {
+		log.Printf("/alerts/rules: failed to serialize response: %v\n", err)
+		http.Error(w, "failed to serialize response body", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	w.Write(respBody)
+}
+
+// Adds a new alert rule.
+// Request expected to be of type AddRuleRequest.
+// On success, responds with the new database entry.
+func postAddRuleHandler(w http.ResponseWriter, r *http.Request) {
+	bodyBuffer, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "failed to parse the request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	req := interfaces.AddRuleRequest{}
+	if err := json.Unmarshal(bodyBuffer, &req); err != nil {
+		log.Printf("/alerts/rules/add: failed to de-serialize request: %v\n", err)
+		http.Error(w, "failed to de-serialize request body", http.StatusBadRequest)
+		return
+	}
+
+	rule := req.Rule
+	rule.Name = strings.TrimSpace(rule.Name)
+	if rule.Name == "" {
+		http.Error(w, "invalid empty name entry", http.StatusBadRequest)
+		return
+	}
+	if err := alert.ValidateRule(&rule); err != nil {
+		log.Printf("/alerts/rules/add: invalid rule '%s': %v\n", rule.Name, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	db := database.DbInstance
+	rule.Id = 0
+	rule.Timestamp = time.Now().UTC()
+	if _, err := db.Model(&rule).Insert(); err != nil {
+		log.Printf("/alerts/rules/add: failed to add rule '%s': %v\n", rule.Name, err)
+		http.Error(w, "failed to add alert rule", http.StatusInternalServerError)
+		return
+	}
+	log.Printf("/alerts/rules/add: added rule[%d] '%s'\n", rule.Id, rule.Name)
+
+	respBody, err := json.Marshal(rule)
+	if err != nil {
+		http.Error(w, "failed to serialize response body", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	w.Write(respBody)
+}
+
+// Removes an alert rule along with its events.
+// Request expected to be of type RemoveRuleRequest.
+// On success, responds with emtpy message.
+func postRemoveRuleHandler(w http.ResponseWriter, r *http.Request) {
+	bodyBuffer, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "failed to parse the request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	req := interfaces.RemoveRuleRequest{}
+	if err := json.Unmarshal(bodyBuffer, &req); err != nil {
+		log.Printf("/alerts/rules/remove: failed to de-serialize request: %v\n", err)
+		http.Error(w, "failed to de-serialize request body", http.StatusBadRequest)
+		return
+	}
+
+	db := database.DbInstance
+	res, err := db.Model((*database.AlertRule)(nil)).Where("id = ?", req.Id).Delete()
+	if err != nil {
+		log.Printf("/alerts/rules/remove: failed to remove rule[%d]: %v\n", req.Id, err)
+		http.Error(w, "failed to remove alert rule", http.StatusInternalServerError)
+		return
+	}
+	if res.RowsAffected() == 0 {
+		http.Error(w, "alert rule not found", http.StatusNotFound)
+		return
+	}
+	if _, err := db.Model((*database.AlertEvent)(nil)).Where("rule_id = ?", req.Id).Delete(); err != nil {
+		log.Printf("/alerts/rules/remove: failed to remove events of rule[%d]: %v\n", req.Id, err)
+	}
+	alert.ForgetRule(req.Id)
+
+	log.Printf("/alerts/rules/remove: removed rule[%d]\n", req.Id)
+	w.Header().Add("Content-Type", "application/json")
+	w.Write([]byte("{}"))
+}
+
+// Create routes & handlers.
+func CreateAlertRoutes(r *mux.Router) {
+	r.HandleFunc("", getAlertsHandler).Methods("GET")
+	r.HandleFunc("/rules", getRulesHandler).Methods("GET")
+	r.HandleFunc("/rules/add", postAddRuleHandler).Methods("POST")
+	r.HandleFunc("/rules/remove", postRemoveRuleHandler).Methods("POST")
+}
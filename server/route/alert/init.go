@@ -0,0 +1,26 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"4bit.api/v0/database"
+	"4bit.api/v0/pkg/alert"
+	"github.com/gorilla/mux"
+)
+
+func CreateRoutes(ctx *context.Context, r *mux.Router) error {
+	CreateAlertRoutes(r)
+
+	// Restore the alert states, since they're reported by those routes.
+	if err := database.RequirePostgres(); err != nil {
+		log.Printf("Skipping alert states: alerts %v", err)
+		return nil
+	}
+	if err := alert.Init(); err != nil {
+		return fmt.Errorf("failed to initialize alerts: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,27 @@
+package interfaces
+
+import (
+	"4bit.api/v0/database"
+	"4bit.api/v0/pkg/alert"
+)
+
+type ListAlertsRequest struct {
+	Limit uint64 `json:"limit"` // Limits the number of events.
+}
+
+type ListAlertsResponse struct {
+	Active []alert.ActiveAlert
+	Events []database.AlertEvent
+}
+
+type ListRulesResponse struct {
+	Rules []database.AlertRule
+}
+
+type AddRuleRequest struct {
+	Rule database.AlertRule
+}
+
+type RemoveRuleRequest struct {
+	Id uint64 `json:"id"`
+}
@@ -8,6 +8,8 @@ import (
 	"mime/multipart"
 	"net"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"4bit.api/v0/database"
@@ -16,6 +18,60 @@ import (
 	"github.com/gorilla/mux"
 )
 
+// Largest length of a hostname.
+const MAX_HOSTNAME_LENGTH = 253
+
+// validateCameraHost verifies the host is an IP address or a hostname.
+func validateCameraHost(host string) error {
+	if net.ParseIP(host) != nil {
+		return nil
+	}
+	if host == "" || len(host) > MAX_HOSTNAME_LENGTH {
+		return fmt.Errorf("expected an ip address or hostname")
+	}
+	for _, label := range strings.Split(strings.TrimSuffix(host, "."), ".") {
+		if label == "" || strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+			return fmt.Errorf("invalid hostname label '%s'", label)
+		}
+		for _, c := range label {
+			if !(c >= 'a' && c <= 'z') && !(c >= 'A' && c <= 'Z') && !(c >= '0' && c <= '9') && c != '-' {
+				return fmt.Errorf("invalid hostname label '%s'", label)
+			}
+		}
+	}
+	return nil
+}
+
+// findCameraEntry retrieves a camera entry along with its adjustment by ID, or by
+// IP when the host has a single camera.
+// It returns the http status & error reflecting the failure state.
+func findCameraEntry(id uint64, ip string) (*database.CameraEntry, int, error) {
+	cameras := database.StoreInstance.Cameras
+	if id != 0 {
+		camEntry, err := cameras.Get(id)
+		if err == database.ErrNotFound {
+			return nil, http.StatusNotFound, fmt.Errorf("camera entry with id '%d' not found", id)
+		} else if err != nil {
+			return nil, http.StatusInternalServerError, fmt.Errorf("failed to query camera entry: %v", err)
+		}
+		return camEntry, http.StatusOK, nil
+	} else if ip == "" {
+		return nil, http.StatusBadRequest, fmt.Errorf("expected a camera id or ip")
+	}
+
+	hostCameras, err := cameras.FindByIP(ip)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to query camera entry: %v", err)
+	}
+	switch {
+	case len(hostCameras) == 0:
+		return nil, http.StatusNotFound, fmt.Errorf("camera entry with ip '%s' not found", ip)
+	case len(hostCameras) > 1:
+		return nil, http.StatusConflict, fmt.Errorf("multiple camera entries with ip '%s', expected a camera id", ip)
+	}
+	return &hostCameras[0], http.StatusOK, nil
+}
+
 // Adds a new unique Camera entry to track & poll.
 // Request expected to be of type AddCameraRequest.
 // On success, responds with new database entry.
@@ -46,9 +102,9 @@ func postAddCameraHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Validate required IP entry is valid.
-	if ip := net.ParseIP(req.Camera.IP); ip == nil {
-		log.Printf("/camera/add: failed to create new camera entry. Invalid IP entry '%s'\n", req.Camera.IP)
+	// Validate required IP entry is a valid address or hostname.
+	if err := validateCameraHost(req.Camera.IP); err != nil {
+		log.Printf("/camera/add: failed to create new camera entry. Invalid IP entry '%s': %v\n", req.Camera.IP, err)
 
 		http.Error(
 			w,
@@ -58,6 +114,19 @@ func postAddCameraHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if req.Camera.Path == "" {
+		req.Camera.Path = camera.DEFAULT_STREAM_PATH
+	} else if !strings.HasPrefix(req.Camera.Path, "/") {
+		log.Printf("/camera/add: failed to create new camera entry. Invalid Path entry '%s'\n", req.Camera.Path)
+
+		http.Error(
+			w,
+			"invalid path entry",
+			http.StatusBadRequest,
+		)
+		return
+	}
+
 	if req.Camera.Port == 0 {
 		log.Printf("/camera/add: failed to create new camera entry. Invalid Port entry '%d'\n", req.Camera.Port)
 
@@ -80,14 +149,39 @@ func postAddCameraHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Find whether this entry already exists.
-	db := database.DbInstance
-	if err := db.Model(&req.Camera).Where("camera_entry.ip = ?", req.Camera.IP).Select(); err == nil {
-		log.Printf("/camera/add: failed to add camera entry with IP '%s', because it already exists\n", req.Camera.IP)
+	if req.Camera.Adjustment != nil {
+		if err := camera.ValidateOverlayCorner(req.Camera.Adjustment.OverlayCorner); err != nil {
+			log.Printf("/camera/add: failed to create new camera entry. %v\n", err)
+
+			http.Error(
+				w,
+				err.Error(),
+				http.StatusBadRequest,
+			)
+			return
+		}
+
+		if err := camera.ValidatePrivacyMasks(req.Camera.Adjustment.PrivacyMasks); err != nil {
+			log.Printf("/camera/add: failed to create new camera entry. %v\n", err)
+
+			http.Error(
+				w,
+				err.Error(),
+				http.StatusBadRequest,
+			)
+			return
+		}
+	}
+
+	// Find whether this stream already exists, as a single host may serve multiple
+	// streams on different ports or paths.
+	cameras := database.StoreInstance.Cameras
+	if exists, err := cameras.StreamExists(req.Camera.IP, req.Camera.Port, req.Camera.Path, 0); err != nil || exists {
+		log.Printf("/camera/add: failed to add camera entry with IP '%s', because it already exists: %v\n", camera.StreamEndpoint(req.Camera), err)
 
 		http.Error(
 			w,
-			fmt.Sprintf("camera entry with IP '%s' already exists", req.Camera.IP),
+			fmt.Sprintf("camera entry with stream '%s' already exists", camera.StreamEndpoint(req.Camera)),
 			http.StatusConflict,
 		)
 		return
@@ -105,26 +199,22 @@ func postAddCameraHandler(w http.ResponseWriter, r *http.Request) {
 		CropFrameY:      0,
 		Rotate:          0.0,
 	}
-	if _, err := db.Model(&camAdjust).Insert(); err != nil {
-		log.Printf("/camera/add: failed to add new camera adjustment with ip '%s': %v\n", req.Camera.IP, err)
-
-		http.Error(
-			w,
-			fmt.Sprintf("Failed to add new camera adjustment entry with ip '%s'\n", req.Camera.IP),
-			http.StatusInternalServerError,
-		)
-		return
+	if req.Camera.Adjustment != nil {
+		camAdjust.OverlayName = req.Camera.Adjustment.OverlayName
+		camAdjust.OverlayTimestamp = req.Camera.Adjustment.OverlayTimestamp
+		camAdjust.OverlayText = req.Camera.Adjustment.OverlayText
+		camAdjust.OverlayCorner = req.Camera.Adjustment.OverlayCorner
+		camAdjust.PrivacyMasks = req.Camera.Adjustment.PrivacyMasks
 	}
-
-	// Add new entry to database.
+	// Add the adjustment & its camera entry together, so that a failure leaves
+	// no orphaned adjustment behind.
 	log.Printf("/camera/add: adding new camera entry with ip '%s:%d'\n", req.Camera.IP, req.Camera.Port)
 	camEntry := req.Camera
 	camEntry.CreatedAt = time.Now()
 	camEntry.ModifiedAt = camEntry.CreatedAt
 	camEntry.Adjustment = &camAdjust
-	camEntry.AdjustmentId = camAdjust.Id
-	if _, err := db.Model(&camEntry).Relation("Adjustment").Insert(); err != nil {
-		log.Printf("Failed to add new camera entry with ip '%s': %v\n", camEntry.IP, err)
+	if err := cameras.Add(&camEntry); err != nil {
+		log.Printf("/camera/add: failed to add new camera entry with ip '%s': %v\n", camEntry.IP, err)
 
 		http.Error(
 			w,
@@ -186,62 +276,135 @@ func postRemoveCameraHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Validate required IP entry is valid.
-	if ip := net.ParseIP(req.Camera.IP); ip == nil {
-		log.Printf("/camera/remove: failed to remove camera entry. Invalid IP entry '%s'\n", req.Camera.IP)
+	// Grab the camera entry by ID, or by IP for compatibility.
+	camEntry, status, err := findCameraEntry(req.Camera.Id, req.Camera.IP)
+	if err != nil {
+		log.Printf("/camera/remove: %v\n", err)
 
 		http.Error(
 			w,
-			"invalid ip entry",
-			http.StatusBadRequest,
+			err.Error(),
+			status,
 		)
 		return
 	}
+	req.Camera = *camEntry
 
-	db := database.DbInstance
-
-	// Grab the camera entry
-	if err := db.Model(&req.Camera).Where("camera_entry.ip = ?", req.Camera.IP).Relation("Adjustment").Select(); err != nil {
-		log.Printf("/camera/remove: failed to find camera entry with ip '%s': %v\n", req.Camera.IP, err)
+	// Remove the camera entry along with its group memberships & adjustment.
+	log.Printf("/camera/remove: Removing camera entry with ip '%s' & adjustment id='%d'\n", req.Camera.IP, req.Camera.AdjustmentId)
+	if err := database.StoreInstance.Cameras.Remove(&req.Camera); err != nil {
+		log.Printf("/camera/remove: failed to remove camera entry with ip '%s': %v\n", req.Camera.IP, err)
 
 		http.Error(
 			w,
-			fmt.Sprintf("Failed to find camera entry with ip '%s'", req.Camera.IP),
-			http.StatusNotFound,
+			fmt.Sprintf("Failed to remove camera entry with ip '%s'", req.Camera.IP),
+			http.StatusInternalServerError,
 		)
 		return
 	}
 
-	// Remove camera entry
-	if _, err := db.Model(&req.Camera).Where("camera_entry.ip = ?", req.Camera.IP).Delete(); err != nil {
-		log.Printf("/camera/remove: failed to remove camera entry with ip '%s': %v\n", req.Camera.IP, err)
+	log.Printf("/camera/remove: Successfuly removed camera entry with ip '%s'\n", req.Camera.IP)
+	w.Header().Add("Content-Type", "application/json")
+	w.Write([]byte("{}"))
+
+	// Update poller state.
+	if camera.CameraPollerInstance != nil {
+		camera.CameraPollerInstance.ShouldUpdateEntries = true
+	}
+}
+
+// selectWorkers retrieves the poll workers of the camera with the given ID, of
+// the cameras of the given host IP, or of all cameras within the optional group
+// and tag filters.
+// It returns the http status & error reflecting the failure state.
+func selectWorkers(id uint64, ip string, group string, tag string) (map[uint64]*camera.CameraPollWorker, int, error) {
+	camPoller := camera.CameraPollerInstance
+	switch {
+	case id != 0:
+		worker, ok := camPoller.GetWorker(id)
+		if !ok {
+			return nil, http.StatusBadRequest, fmt.Errorf("camera not found")
+		}
+		return map[uint64]*camera.CameraPollWorker{id: worker}, http.StatusOK, nil
+
+	case ip != "":
+		workers := camPoller.GetWorkersByIP(ip)
+		if len(workers) == 0 {
+			return nil, http.StatusBadRequest, fmt.Errorf("camera not found")
+		}
+		return workers, http.StatusOK, nil
+	}
+
+	workers, err := camPoller.GetWorkers(group, tag)
+	if err != nil {
+		return nil, http.StatusNotFound, err
+	}
+	return workers, http.StatusOK, nil
+}
+
+// newCameraResponse constructs the response of a camera's last snapshot.
+func newCameraResponse(worker *camera.CameraPollWorker) interfaces.CameraResponseBase {
+	snapshot := worker.GetSnapshot()
+	return interfaces.CameraResponseBase{
+		Id:   worker.Id,
+		IP:   worker.IP,
+		Name: worker.Name,
+		Data: snapshot.ImageData,
+	}
+}
+
+// addCameraResponse adds a camera's last snapshot to the responses keyed by ID,
+// and by IP unless a camera of the same host with a lower ID was added.
+func addCameraResponse(byIP map[string]interfaces.CameraResponseBase, byId map[string]interfaces.CameraResponseBase, worker *camera.CameraPollWorker) {
+	camResp := newCameraResponse(worker)
+	byId[strconv.FormatUint(worker.Id, 10)] = camResp
+	if existing, ok := byIP[worker.IP]; !ok || worker.Id < existing.Id {
+		byIP[worker.IP] = camResp
+	}
+}
+
+// writeSnapResponse responds with the snapshots of the requested cameras.
+func writeSnapResponse(w http.ResponseWriter, req interfaces.SnapCameraRequest) {
+	workers, status, err := selectWorkers(req.Id, req.IP, req.Group, req.Tag)
+	if err != nil {
+		log.Printf("/camera/snap: failed snap camera request for camera[id=%d|ip=%s|group=%s]: %v\n", req.Id, req.IP, req.Group, err)
 
 		http.Error(
 			w,
-			fmt.Sprintf("Failed to remove camera entry with ip '%s'", req.Camera.IP),
-			http.StatusBadRequest,
+			err.Error(),
+			status,
 		)
 		return
 	}
 
-	// Remove adjustment entry relation
-	log.Printf("/camera/remove: Removing associated camera adjustment id='%d'\n", req.Camera.AdjustmentId)
-	if _, err := db.Model(req.Camera.Adjustment).WherePK().Delete(); err != nil {
-		log.Printf("Failed to remove camera adjustment id='%d' for camera entry with ip '%s': %v\n", req.Camera.AdjustmentId, req.Camera.IP, err)
+	// Obtain the image buffer of each camera.
+	resp := interfaces.SnapCameraResponse{
+		Cameras:     map[string]interfaces.CameraResponseBase{},
+		CamerasById: map[string]interfaces.CameraResponseBase{},
+	}
+	for _, worker := range workers {
+		addCameraResponse(resp.Cameras, resp.CamerasById, worker)
 	}
 
-	log.Printf("/camera/remove: Successfuly removed camera entry with ip '%s'\n", req.Camera.IP)
-	w.Header().Add("Content-Type", "application/json")
-	w.Write([]byte("{}"))
+	// Serialize response.
+	resBody, err := json.Marshal(resp)
+	if err != nil {
+		log.Printf("/camera/snap: failed to serialize snap camera response: %v\n", err)
 
-	// Update poller state.
-	if camera.CameraPollerInstance != nil {
-		camera.CameraPollerInstance.ShouldUpdateEntries = true
+		http.Error(
+			w,
+			"failed to serialize response",
+			http.StatusInternalServerError,
+		)
+		return
 	}
+
+	w.Header().Add("Content-Type", "application/json")
+	w.Write(resBody)
 }
 
-// Gets the current state of all listening cameras or the state of a given camera
-// IP address.
+// Gets the current state of all listening cameras, of a given camera ID or of
+// the cameras of a given IP address.
 // Expects a request of type SnapCameraRequest.
 // On success, responds with SnapCameraResponse.
 func getSnapCameraHandler(w http.ResponseWriter, r *http.Request) {
@@ -271,56 +434,18 @@ func getSnapCameraHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Obtain the buffer of the specified camera or all cameras.
-	resp := interfaces.SnapCameraResponse{
-		Cameras: map[string]interfaces.CameraResponseBase{},
-	}
-
-	if ip := net.ParseIP(req.IP); ip == nil {
-		// No specific camera snap request.
-		// Obtain the image buffer.
-		for ip, entry := range camera.CameraPollerInstance.PollWorkers {
-			snapshot := entry.GetSnapshot()
-			resp.Cameras[ip] = interfaces.CameraResponseBase{
-				Name: entry.Name,
-				Data: snapshot.ImageData,
-			}
-		}
-	} else {
-		// Verify the ip exists.
-		if cam, ok := camera.CameraPollerInstance.PollWorkers[req.IP]; !ok {
-			log.Printf("/camera/snap: failed snap camera request for '%s'. Camera not found.\n", req.IP)
-
-			http.Error(
-				w,
-				"camera not found",
-				http.StatusBadRequest,
-			)
-			return
-		} else {
-			snapshot := cam.GetSnapshot()
-			resp.Cameras[req.IP] = interfaces.CameraResponseBase{
-				Name: cam.Name,
-				Data: snapshot.ImageData,
-			}
-		}
-	}
+	writeSnapResponse(w, req)
+}
 
-	// Serialize response.
-	resBody, err := json.Marshal(resp)
+// Gets the current state of the camera with the ID given in the path.
+// On success, responds with SnapCameraResponse.
+func getSnapCameraByIdHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
 	if err != nil {
-		log.Printf("/camera/snap: failed to serialize snap camera response: %v\n", err)
-
-		http.Error(
-			w,
-			"failed to serialize response",
-			http.StatusInternalServerError,
-		)
+		http.Error(w, "invalid camera id", http.StatusBadRequest)
 		return
 	}
-
-	w.Header().Add("Content-Type", "application/json")
-	w.Write(resBody)
+	writeSnapResponse(w, interfaces.SnapCameraRequest{Id: id})
 }
 
 // Streaming endpoint for continuously listening to new camera data.
@@ -366,22 +491,47 @@ func getSubscribeCameraHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Resolve the cameras of the requested group once, rather than on every state.
+	hasGroupFilter := streamReq.Group != "" || streamReq.Tag != ""
+	groupIds := map[uint64]bool{}
+	if hasGroupFilter {
+		cameras, err := database.StoreInstance.Cameras.GetGroupCameras(streamReq.Group, streamReq.Tag)
+		if err != nil {
+			log.Printf("/camera/subscribe: failed to query camera group: %v\n", err)
+
+			http.Error(
+				w,
+				err.Error(),
+				http.StatusNotFound,
+			)
+			return
+		}
+		for _, camEntry := range cameras {
+			groupIds[camEntry.Id] = true
+		}
+	}
+
 	// Send initial camera states.
 	sendState := func() error {
 		resp := interfaces.StreamCameraResponse{
-			Cameras: map[string]interfaces.CameraResponseBase{},
+			Cameras:     map[string]interfaces.CameraResponseBase{},
+			CamerasById: map[string]interfaces.CameraResponseBase{},
 		}
-		for ip, entry := range camera.CameraPollerInstance.PollWorkers {
-			// Filter on specific camera IP. Otherwise, stream all cameras.
-			if streamReq.IP != "" && ip != streamReq.IP {
+		for id, entry := range camera.CameraPollerInstance.GetAllWorkers() {
+			// Filter on the requested group.
+			if hasGroupFilter && !groupIds[id] {
 				continue
 			}
 
-			snapshot := entry.GetSnapshot()
-			resp.Cameras[ip] = interfaces.CameraResponseBase{
-				Name: entry.Name,
-				Data: snapshot.ImageData,
+			// Filter on specific camera ID or IP. Otherwise, stream all cameras.
+			if streamReq.Id != 0 && id != streamReq.Id {
+				continue
+			}
+			if streamReq.IP != "" && entry.IP != streamReq.IP {
+				continue
 			}
+
+			addCameraResponse(resp.Cameras, resp.CamerasById, entry)
 		}
 		resBody, err := json.Marshal(resp)
 		if err != nil {
@@ -442,5 +592,6 @@ func CreateCameraRoutes(r *mux.Router) {
 	r.HandleFunc("/add", postAddCameraHandler).Methods("POST")
 	r.HandleFunc("/remove", postRemoveCameraHandler).Methods("POST")
 	r.HandleFunc("/snap", getSnapCameraHandler).Methods("GET")
+	r.HandleFunc("/{id:[0-9]+}/snap", getSnapCameraByIdHandler).Methods("GET")
 	r.HandleFunc("/subscribe", getSubscribeCameraHandler).Methods("GET")
 }
@@ -0,0 +1,105 @@
+package camera
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+
+	"4bit.api/v0/pkg/camera"
+	"4bit.api/v0/server/route/camera/interfaces"
+	"github.com/gorilla/mux"
+)
+
+// GET endpoint request for tiling the current snapshots of cameras into a single
+// labeled grid image.
+// Expects a request of type CollageCameraRequest.
+// On success, responds with CollageCameraResponse.
+func getCollageCameraHandler(w http.ResponseWriter, r *http.Request) {
+	bodyBytes, err := io.ReadAll(r.Body)
+	defer r.Body.Close()
+	if err != nil {
+		log.Printf("/camera/collage: failed to read request body:%v\n", err)
+		http.Error(w, "failed to read request body", http.StatusBadRequest)
+		return
+	}
+
+	req := interfaces.CollageCameraRequest{}
+	if err := json.Unmarshal(bodyBytes, &req); err != nil {
+		log.Printf("/camera/collage: failed to deserialize collage camera request :%v\n", err)
+		http.Error(w, "failed to deserialize body", http.StatusBadRequest)
+		return
+	}
+
+	opts := camera.CollageOptions{
+		CellWidth:  req.CellWidth,
+		CellHeight: req.CellHeight,
+		Columns:    req.Columns,
+	}
+	if err := camera.ValidateCollageOptions(opts); err != nil {
+		log.Printf("/camera/collage: invalid collage options: %v\n", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Grab the requested cameras, or all of them.
+	camPoller := camera.CameraPollerInstance
+	workers := []*camera.CameraPollWorker{}
+	if len(req.Ids) == 0 && len(req.IPs) == 0 {
+		groupWorkers, err := camPoller.GetWorkers(req.Group, req.Tag)
+		if err != nil {
+			log.Printf("/camera/collage: failed collage request for group '%s': %v\n", req.Group, err)
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		for _, worker := range groupWorkers {
+			workers = append(workers, worker)
+		}
+		sort.Slice(workers, func(i, j int) bool { return workers[i].Name < workers[j].Name })
+	}
+	for _, id := range req.Ids {
+		worker, ok := camPoller.GetWorker(id)
+		if !ok {
+			log.Printf("/camera/collage: failed collage request for camera '%d'. Camera not found.\n", id)
+			http.Error(w, "camera not found", http.StatusBadRequest)
+			return
+		}
+		workers = append(workers, worker)
+	}
+	for _, ip := range req.IPs {
+		hostWorkers := []*camera.CameraPollWorker{}
+		for _, worker := range camPoller.GetWorkersByIP(ip) {
+			hostWorkers = append(hostWorkers, worker)
+		}
+		if len(hostWorkers) == 0 {
+			log.Printf("/camera/collage: failed collage request for '%s'. Camera not found.\n", ip)
+			http.Error(w, "camera not found", http.StatusBadRequest)
+			return
+		}
+		sort.Slice(hostWorkers, func(i, j int) bool { return hostWorkers[i].Id < hostWorkers[j].Id })
+		workers = append(workers, hostWorkers...)
+	}
+
+	collage, err := camera.NewCollage(workers, opts)
+	if err != nil {
+		log.Printf("/camera/collage: failed to create collage: %v\n", err)
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	resBody, err := json.Marshal(interfaces.CollageCameraResponse{Data: collage})
+	if err != nil {
+		log.Printf("/camera/collage: failed to serialize collage camera response: %v\n", err)
+		http.Error(w, "failed to serialize response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	w.Write(resBody)
+}
+
+// Creates request routes & handlers.
+func CreateCameraCollageRoute(r *mux.Router) {
+	r.HandleFunc("/collage", getCollageCameraHandler).Methods("GET")
+}
@@ -0,0 +1,54 @@
+package camera
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"4bit.api/v0/database"
+	"4bit.api/v0/pkg/camera"
+	"4bit.api/v0/server/route/camera/interfaces"
+	"github.com/gorilla/mux"
+)
+
+// GET endpoint request for discovering cameras advertised on the LAN through mDNS.
+// On success, responds with DiscoverCamerasResponse.
+func getDiscoverCamerasHandler(w http.ResponseWriter, r *http.Request) {
+	discovered, err := camera.DiscoverCameras(r.Context(), camera.CameraPollerInstance.Resolver)
+	if err != nil {
+		log.Printf("/camera/discover: failed to discover cameras: %v\n", err)
+		http.Error(w, "failed to discover cameras", http.StatusInternalServerError)
+		return
+	}
+
+	cameras := database.StoreInstance.Cameras
+	resp := interfaces.DiscoverCamerasResponse{
+		Cameras: []interfaces.DiscoveredCamera{},
+	}
+	for _, camEntry := range discovered {
+		exists, err := cameras.StreamExists(camEntry.IP, camEntry.Port, camEntry.Path, 0)
+		if err != nil {
+			log.Printf("/camera/discover: failed to query camera entry '%s': %v\n", camera.StreamEndpoint(camEntry), err)
+		}
+		resp.Cameras = append(resp.Cameras, interfaces.DiscoveredCamera{
+			Camera: camEntry,
+			Added:  exists,
+		})
+	}
+	log.Printf("/camera/discover: discovered %d cameras\n", len(resp.Cameras))
+
+	resBody, err := json.Marshal(resp)
+	if err != nil {
+		log.Printf("/camera/discover: failed to serialize discover cameras response: %v\n", err)
+		http.Error(w, "failed to serialize response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	w.Write(resBody)
+}
+
+// Creates request routes & handlers.
+func CreateCameraDiscoverRoute(r *mux.Router) {
+	r.HandleFunc("/discover", getDiscoverCamerasHandler).Methods("GET")
+}
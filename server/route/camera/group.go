@@ -0,0 +1,265 @@
+package camera
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"4bit.api/v0/database"
+	"4bit.api/v0/server/route/camera/interfaces"
+	"github.com/gorilla/mux"
+)
+
+// GET endpoint request for listing camera groups along with their cameras.
+// On success, responds with ListCameraGroupsResponse.
+func getListCameraGroupsHandler(w http.ResponseWriter, r *http.Request) {
+	cameraRepo := database.StoreInstance.Cameras
+	groups, err := cameraRepo.ListGroups()
+	if err != nil {
+		log.Printf("/camera/group/list: failed to query camera groups: %v\n", err)
+		http.Error(w, "failed to query camera groups", http.StatusInternalServerError)
+		return
+	}
+
+	resp := interfaces.ListCameraGroupsResponse{
+		Groups: []interfaces.CameraGroupResponse{},
+	}
+	for _, group := range groups {
+		cameras, err := cameraRepo.GetGroupCameras(group.Name, "")
+		if err != nil {
+			log.Printf("/camera/group/list: %v\n", err)
+			http.Error(w, "failed to query camera group members", http.StatusInternalServerError)
+			return
+		}
+
+		groupResp := interfaces.CameraGroupResponse{
+			Group: group,
+			Ids:   []uint64{},
+			IPs:   []string{},
+		}
+		for _, camEntry := range cameras {
+			groupResp.Ids = append(groupResp.Ids, camEntry.Id)
+			groupResp.IPs = append(groupResp.IPs, camEntry.IP)
+		}
+		resp.Groups = append(resp.Groups, groupResp)
+	}
+
+	resBody, err := json.Marshal(resp)
+	if err != nil {
+		log.Printf("/camera/group/list: failed to serialize response: %v\n", err)
+		http.Error(w, "failed to serialize response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	w.Write(resBody)
+}
+
+// POST endpoint request for adding a camera group, replacing the tags of an
+// existing group with the same name.
+// Expects a request of type AddCameraGroupRequest.
+// On success, responds with the group's database entry.
+func postAddCameraGroupHandler(w http.ResponseWriter, r *http.Request) {
+	bodyBytes, err := io.ReadAll(r.Body)
+	defer r.Body.Close()
+	if err != nil {
+		log.Printf("/camera/group/add: failed to read request body:%v\n", err)
+		http.Error(w, "failed to read request body", http.StatusBadRequest)
+		return
+	}
+
+	req := interfaces.AddCameraGroupRequest{}
+	if err := json.Unmarshal(bodyBytes, &req); err != nil {
+		log.Printf("/camera/group/add: failed to deserialize add camera group request :%v\n", err)
+		http.Error(w, "failed to deserialize body", http.StatusBadRequest)
+		return
+	}
+	if req.Name == "" {
+		http.Error(w, "invalid empty name entry", http.StatusBadRequest)
+		return
+	}
+
+	group := database.CameraGroup{
+		Name: req.Name,
+		Tags: req.Tags,
+	}
+	group.Timestamp = time.Now().UTC()
+	if group.Tags == nil {
+		group.Tags = []string{}
+	}
+
+	if err := database.StoreInstance.Cameras.SaveGroup(&group); err != nil {
+		log.Printf("/camera/group/add: failed to add camera group '%s': %v\n", req.Name, err)
+		http.Error(w, "failed to add camera group", http.StatusInternalServerError)
+		return
+	}
+	log.Printf("/camera/group/add: added camera group '%s' with tags %v\n", group.Name, group.Tags)
+
+	resBody, err := json.Marshal(group)
+	if err != nil {
+		log.Printf("/camera/group/add: failed to serialize response: %v\n", err)
+		http.Error(w, "failed to serialize response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	w.Write(resBody)
+}
+
+// POST endpoint request for removing a camera group, leaving its cameras intact.
+// Expects a request of type RemoveCameraGroupRequest.
+// On success, responds with emtpy message.
+func postRemoveCameraGroupHandler(w http.ResponseWriter, r *http.Request) {
+	bodyBytes, err := io.ReadAll(r.Body)
+	defer r.Body.Close()
+	if err != nil {
+		log.Printf("/camera/group/remove: failed to read request body:%v\n", err)
+		http.Error(w, "failed to read request body", http.StatusBadRequest)
+		return
+	}
+
+	req := interfaces.RemoveCameraGroupRequest{}
+	if err := json.Unmarshal(bodyBytes, &req); err != nil {
+		log.Printf("/camera/group/remove: failed to deserialize remove camera group request :%v\n", err)
+		http.Error(w, "failed to deserialize body", http.StatusBadRequest)
+		return
+	}
+
+	cameraRepo := database.StoreInstance.Cameras
+	group, err := cameraRepo.GetGroup(req.Name)
+	if err == database.ErrNotFound {
+		log.Printf("/camera/group/remove: camera group '%s' not found\n", req.Name)
+		http.Error(w, fmt.Sprintf("camera group '%s' not found", req.Name), http.StatusNotFound)
+		return
+	} else if err != nil {
+		log.Printf("/camera/group/remove: %v\n", err)
+		http.Error(w, "failed to query camera group", http.StatusInternalServerError)
+		return
+	}
+
+	if err := cameraRepo.RemoveGroup(group); err != nil {
+		log.Printf("/camera/group/remove: failed to remove camera group '%s': %v\n", req.Name, err)
+		http.Error(w, "failed to remove camera group", http.StatusInternalServerError)
+		return
+	}
+	log.Printf("/camera/group/remove: removed camera group '%s'\n", req.Name)
+
+	w.Header().Add("Content-Type", "application/json")
+	w.Write([]byte("{}"))
+}
+
+// parseAssignRequest deserializes an assignment request, resolving its group and
+// cameras.
+func parseAssignRequest(r *http.Request) (*database.CameraGroup, []database.CameraEntry, int, error) {
+	bodyBytes, err := io.ReadAll(r.Body)
+	defer r.Body.Close()
+	if err != nil {
+		return nil, nil, http.StatusBadRequest, fmt.Errorf("failed to read request body")
+	}
+
+	req := interfaces.AssignCameraGroupRequest{}
+	if err := json.Unmarshal(bodyBytes, &req); err != nil {
+		return nil, nil, http.StatusBadRequest, fmt.Errorf("failed to deserialize body")
+	}
+	if len(req.Ids) == 0 && len(req.IPs) == 0 {
+		return nil, nil, http.StatusBadRequest, fmt.Errorf("no camera ids or ips given")
+	}
+
+	cameraRepo := database.StoreInstance.Cameras
+	group, err := cameraRepo.GetGroup(req.Group)
+	if err == database.ErrNotFound {
+		return nil, nil, http.StatusNotFound, fmt.Errorf("camera group '%s' not found", req.Group)
+	} else if err != nil {
+		return nil, nil, http.StatusInternalServerError, err
+	}
+
+	// Resolve the cameras by ID, along with every camera of the given hosts.
+	cameras := []database.CameraEntry{}
+	for _, id := range req.Ids {
+		camEntry, err := cameraRepo.Get(id)
+		if err == database.ErrNotFound {
+			return nil, nil, http.StatusNotFound, fmt.Errorf("camera entry with id '%d' not found", id)
+		} else if err != nil {
+			return nil, nil, http.StatusInternalServerError, fmt.Errorf("failed to query cameras: %v", err)
+		}
+		cameras = append(cameras, *camEntry)
+	}
+	for _, ip := range req.IPs {
+		hostCameras, err := cameraRepo.FindByIP(ip)
+		if err != nil {
+			return nil, nil, http.StatusInternalServerError, fmt.Errorf("failed to query cameras: %v", err)
+		}
+		if len(hostCameras) == 0 {
+			return nil, nil, http.StatusNotFound, fmt.Errorf("camera entry with ip '%s' not found", ip)
+		}
+		cameras = append(cameras, hostCameras...)
+	}
+	return group, cameras, http.StatusOK, nil
+}
+
+// POST endpoint request for assigning cameras to a group.
+// Expects a request of type AssignCameraGroupRequest.
+// On success, responds with emtpy message.
+func postAssignCameraGroupHandler(w http.ResponseWriter, r *http.Request) {
+	group, cameras, status, err := parseAssignRequest(r)
+	if err != nil {
+		log.Printf("/camera/group/assign: %v\n", err)
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	cameraIds := []uint64{}
+	for _, camEntry := range cameras {
+		cameraIds = append(cameraIds, camEntry.Id)
+	}
+
+	if err := database.StoreInstance.Cameras.AssignGroup(group.Id, cameraIds); err != nil {
+		log.Printf("/camera/group/assign: failed to assign cameras to group '%s': %v\n", group.Name, err)
+		http.Error(w, "failed to assign cameras", http.StatusInternalServerError)
+		return
+	}
+	log.Printf("/camera/group/assign: assigned %d cameras to group '%s'\n", len(cameraIds), group.Name)
+
+	w.Header().Add("Content-Type", "application/json")
+	w.Write([]byte("{}"))
+}
+
+// POST endpoint request for unassigning cameras from a group.
+// Expects a request of type AssignCameraGroupRequest.
+// On success, responds with emtpy message.
+func postUnassignCameraGroupHandler(w http.ResponseWriter, r *http.Request) {
+	group, cameras, status, err := parseAssignRequest(r)
+	if err != nil {
+		log.Printf("/camera/group/unassign: %v\n", err)
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	cameraIds := []uint64{}
+	for _, camEntry := range cameras {
+		cameraIds = append(cameraIds, camEntry.Id)
+	}
+
+	removed, err := database.StoreInstance.Cameras.UnassignGroup(group.Id, cameraIds)
+	if err != nil {
+		log.Printf("/camera/group/unassign: failed to unassign cameras from group '%s': %v\n", group.Name, err)
+		http.Error(w, "failed to unassign cameras", http.StatusInternalServerError)
+		return
+	}
+	log.Printf("/camera/group/unassign: unassigned %d cameras from group '%s'\n", removed, group.Name)
+
+	w.Header().Add("Content-Type", "application/json")
+	w.Write([]byte("{}"))
+}
+
+// Creates request routes & handlers.
+func CreateCameraGroupRoutes(r *mux.Router) {
+	r.HandleFunc("/group/list", getListCameraGroupsHandler).Methods("GET")
+	r.HandleFunc("/group/add", postAddCameraGroupHandler).Methods("POST")
+	r.HandleFunc("/group/remove", postRemoveCameraGroupHandler).Methods("POST")
+	r.HandleFunc("/group/assign", postAssignCameraGroupHandler).Methods("POST")
+	r.HandleFunc("/group/unassign", postUnassignCameraGroupHandler).Methods("POST")
+}
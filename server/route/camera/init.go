@@ -10,7 +10,13 @@ import (
 
 func CreateRoutes(ctx *context.Context, r *mux.Router) error {
 	CreateCameraRoutes(r)
+	CreateCameraUpdateRoute(r)
 	CreateCameraListRoute(r)
+	CreateCameraCollageRoute(r)
+	CreateCameraOverlayRoute(r)
+	CreateCameraMaskRoute(r)
+	CreateCameraGroupRoutes(r)
+	CreateCameraDiscoverRoute(r)
 
 	// Create & start poller, since the poller is a dependency of those routes.
 	camPoller, err := camera.NewCameraPoller(ctx)
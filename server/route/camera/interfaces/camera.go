@@ -4,6 +4,10 @@ import "4bit.api/v0/database"
 
 type ListCamerasRequest struct {
 	Limit uint64 `json:"limit"`
+
+	// Optional filters on the cameras' groups.
+	Group string `json:"group"`
+	Tag   string `json:"tag"`
 }
 
 type ListCameraResponse struct {
@@ -19,24 +23,139 @@ type RemoveCameraRequest struct {
 }
 
 type SnapCameraRequest struct {
+	// Camera to snap by ID, or the cameras of a host by IP.
+	Id uint64 `json:"id"`
 	IP string `json:"ip"`
+
+	// Optional filters on the cameras' groups.
+	Group string `json:"group"`
+	Tag   string `json:"tag"`
 }
 
 type CameraResponseBase struct {
+	Id   uint64 `json:"id"`
+	IP   string `json:"ip"`
 	Name string `json:"name"`
 	Data []byte `json:"data"`
 }
 
 type SnapCameraResponse struct {
-	// Key value pair of each camera ip and it's corresponding buffer.
+	// Key value pair of each camera ip and it's corresponding buffer, kept for
+	// clients predating camera IDs. Holds the lowest camera ID of hosts serving
+	// multiple streams.
 	Cameras map[string]CameraResponseBase `json:"cameras"`
+
+	// Key value pair of each camera id and it's corresponding buffer.
+	CamerasById map[string]CameraResponseBase `json:"camerasById"`
 }
 
 type StreamCameraRequest struct {
+	// Camera to stream by ID, or the cameras of a host by IP.
+	Id uint64 `json:"id"`
 	IP string `json:"ip"`
+
+	// Optional filters on the cameras' groups.
+	Group string `json:"group"`
+	Tag   string `json:"tag"`
 }
 
 type StreamCameraResponse struct {
-	// Key value pair of each camera ip and it's corresponding buffer.
+	// Key value pair of each camera ip and it's corresponding buffer, kept for
+	// clients predating camera IDs. Holds the lowest camera ID of hosts serving
+	// multiple streams.
 	Cameras map[string]CameraResponseBase `json:"cameras"`
+
+	// Key value pair of each camera id and it's corresponding buffer.
+	CamerasById map[string]CameraResponseBase `json:"camerasById"`
+}
+
+type CollageCameraRequest struct {
+	// Cameras to include by ID, or by the IP of their host. Includes all cameras
+	// if both are empty.
+	Ids []uint64 `json:"ids"`
+	IPs []string `json:"ips"`
+
+	// Optional filters on the cameras' groups, when no cameras are given.
+	Group string `json:"group"`
+	Tag   string `json:"tag"`
+
+	// Optional layout of the collage.
+	Columns    int `json:"columns"`
+	CellWidth  int `json:"cellWidth"`
+	CellHeight int `json:"cellHeight"`
+}
+
+type CollageCameraResponse struct {
+	// JPEG-encoded collage.
+	Data []byte `json:"data"`
+}
+
+type OverlayCameraRequest struct {
+	// Camera to configure by ID, or by IP when its host has a single camera.
+	Id uint64 `json:"id"`
+	IP string `json:"ip"`
+
+	// Overlay burned into the camera's frames.
+	Name      bool   `json:"name"`
+	Timestamp bool   `json:"timestamp"`
+	Text      string `json:"text"`
+	Corner    string `json:"corner"`
+}
+
+type MaskCameraRequest struct {
+	// Camera to configure by ID, or by IP when its host has a single camera.
+	Id uint64 `json:"id"`
+	IP string `json:"ip"`
+
+	// Polygons blacked out of the camera's frames, replacing existing ones.
+	Masks []database.CameraPrivacyMask `json:"masks"`
+}
+
+type CameraGroupResponse struct {
+	Group database.CameraGroup `json:"group"`
+
+	// IDs & IPs of the cameras within the group.
+	Ids []uint64 `json:"ids"`
+	IPs []string `json:"ips"`
+}
+
+type ListCameraGroupsResponse struct {
+	Groups []CameraGroupResponse `json:"groups"`
+}
+
+type AddCameraGroupRequest struct {
+	Name string   `json:"name"`
+	Tags []string `json:"tags"`
+}
+
+type RemoveCameraGroupRequest struct {
+	Name string `json:"name"`
+}
+
+type AssignCameraGroupRequest struct {
+	Group string `json:"group"`
+
+	// Cameras to assign to, or unassign from, the group by ID, or by the IP of
+	// their host.
+	Ids []uint64 `json:"ids"`
+	IPs []string `json:"ips"`
+}
+
+type DiscoveredCamera struct {
+	// Entry which can be added as is through /camera/add.
+	Camera database.CameraEntry `json:"camera"`
+
+	// Whether a camera entry with the same stream already exists.
+	Added bool `json:"added"`
+}
+
+type DiscoverCamerasResponse struct {
+	Cameras []DiscoveredCamera `json:"cameras"`
+}
+
+type UpdateCameraRequest struct {
+	Id uint64 `json:"id"`
+
+	// Fields replacing those of the camera, left unchanged when empty.
+	Camera database.CameraEntry `json:"camera"`
 }
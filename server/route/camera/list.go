@@ -40,10 +40,20 @@ func getCameraListHandler(w http.ResponseWriter, r *http.Request) {
 		req.Limit = 10
 	}
 
-	// Query all cameras.
-	db := database.DbInstance
-	cameras := []database.CameraEntry{}
-	if err := db.Model(&cameras).Relation("Adjustment").Limit(int(req.Limit)).Select(); err != nil {
+	// Query all cameras, or those of the requested group.
+	var cameras []database.CameraEntry
+	if req.Group != "" || req.Tag != "" {
+		groupCameras, err := database.StoreInstance.Cameras.GetGroupCameras(req.Group, req.Tag)
+		if err != nil {
+			log.Printf("Failed to query camera group for camera list request: %v\n", err)
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if len(groupCameras) > int(req.Limit) {
+			groupCameras = groupCameras[:req.Limit]
+		}
+		cameras = groupCameras
+	} else if cameras, err = database.StoreInstance.Cameras.List(int(req.Limit)); err != nil {
 		log.Printf("Failed to query cameras for camera list request: %v\n", err)
 		http.Error(
 			w,
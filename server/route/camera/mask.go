@@ -0,0 +1,81 @@
+package camera
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+
+	"4bit.api/v0/database"
+	"4bit.api/v0/pkg/camera"
+	"4bit.api/v0/server/route/camera/interfaces"
+	"github.com/gorilla/mux"
+)
+
+// POST endpoint request for configuring the regions blacked out of a camera's
+// frames.
+// Expects a request of type MaskCameraRequest.
+// On success, responds with the updated camera entry.
+func postMaskCameraHandler(w http.ResponseWriter, r *http.Request) {
+	bodyBytes, err := io.ReadAll(r.Body)
+	defer r.Body.Close()
+	if err != nil {
+		log.Printf("/camera/mask: failed to read request body:%v\n", err)
+		http.Error(w, "failed to read request body", http.StatusBadRequest)
+		return
+	}
+
+	req := interfaces.MaskCameraRequest{}
+	if err := json.Unmarshal(bodyBytes, &req); err != nil {
+		log.Printf("/camera/mask: failed to deserialize mask camera request :%v\n", err)
+		http.Error(w, "failed to deserialize body", http.StatusBadRequest)
+		return
+	}
+
+	if err := camera.ValidatePrivacyMasks(req.Masks); err != nil {
+		log.Printf("/camera/mask: invalid privacy masks for camera[id=%d|ip=%s]: %v\n", req.Id, req.IP, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Grab the camera entry along with its adjustment.
+	camEntry, status, err := findCameraEntry(req.Id, req.IP)
+	if err != nil {
+		log.Printf("/camera/mask: %v\n", err)
+		http.Error(w, err.Error(), status)
+		return
+	}
+	if camEntry.Adjustment == nil {
+		log.Printf("/camera/mask: camera entry[%d] has no adjustment\n", camEntry.Id)
+		http.Error(w, "camera adjustment not found", http.StatusInternalServerError)
+		return
+	}
+
+	camEntry.Adjustment.PrivacyMasks = req.Masks
+	if err := database.StoreInstance.Cameras.UpdateAdjustment(camEntry.Adjustment); err != nil {
+		log.Printf("/camera/mask: failed to update privacy masks of camera entry[%d]: %v\n", camEntry.Id, err)
+		http.Error(w, "failed to update camera privacy masks", http.StatusInternalServerError)
+		return
+	}
+	log.Printf("/camera/mask: updated %d privacy masks of camera entry[%d]\n", len(req.Masks), camEntry.Id)
+
+	resBody, err := json.Marshal(camEntry)
+	if err != nil {
+		log.Printf("/camera/mask: failed to serialize camera entry response: %v\n", err)
+		http.Error(w, "failed to serialize response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	w.Write(resBody)
+
+	// Update poller state.
+	if camera.CameraPollerInstance != nil {
+		camera.CameraPollerInstance.ShouldUpdateEntries = true
+	}
+}
+
+// Creates request routes & handlers.
+func CreateCameraMaskRoute(r *mux.Router) {
+	r.HandleFunc("/mask", postMaskCameraHandler).Methods("POST")
+}
@@ -0,0 +1,84 @@
+package camera
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+
+	"4bit.api/v0/database"
+	"4bit.api/v0/pkg/camera"
+	"4bit.api/v0/server/route/camera/interfaces"
+	"github.com/gorilla/mux"
+)
+
+// POST endpoint request for configuring the overlay burned into a camera's
+// frames.
+// Expects a request of type OverlayCameraRequest.
+// On success, responds with the updated camera entry.
+func postOverlayCameraHandler(w http.ResponseWriter, r *http.Request) {
+	bodyBytes, err := io.ReadAll(r.Body)
+	defer r.Body.Close()
+	if err != nil {
+		log.Printf("/camera/overlay: failed to read request body:%v\n", err)
+		http.Error(w, "failed to read request body", http.StatusBadRequest)
+		return
+	}
+
+	req := interfaces.OverlayCameraRequest{}
+	if err := json.Unmarshal(bodyBytes, &req); err != nil {
+		log.Printf("/camera/overlay: failed to deserialize overlay camera request :%v\n", err)
+		http.Error(w, "failed to deserialize body", http.StatusBadRequest)
+		return
+	}
+
+	if err := camera.ValidateOverlayCorner(req.Corner); err != nil {
+		log.Printf("/camera/overlay: invalid overlay for camera[id=%d|ip=%s]: %v\n", req.Id, req.IP, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Grab the camera entry along with its adjustment.
+	camEntry, status, err := findCameraEntry(req.Id, req.IP)
+	if err != nil {
+		log.Printf("/camera/overlay: %v\n", err)
+		http.Error(w, err.Error(), status)
+		return
+	}
+	if camEntry.Adjustment == nil {
+		log.Printf("/camera/overlay: camera entry[%d] has no adjustment\n", camEntry.Id)
+		http.Error(w, "camera adjustment not found", http.StatusInternalServerError)
+		return
+	}
+
+	camEntry.Adjustment.OverlayName = req.Name
+	camEntry.Adjustment.OverlayTimestamp = req.Timestamp
+	camEntry.Adjustment.OverlayText = req.Text
+	camEntry.Adjustment.OverlayCorner = req.Corner
+	if err := database.StoreInstance.Cameras.UpdateAdjustment(camEntry.Adjustment); err != nil {
+		log.Printf("/camera/overlay: failed to update overlay of camera entry[%d]: %v\n", camEntry.Id, err)
+		http.Error(w, "failed to update camera overlay", http.StatusInternalServerError)
+		return
+	}
+	log.Printf("/camera/overlay: updated overlay of camera entry[%d]\n", camEntry.Id)
+
+	resBody, err := json.Marshal(camEntry)
+	if err != nil {
+		log.Printf("/camera/overlay: failed to serialize camera entry response: %v\n", err)
+		http.Error(w, "failed to serialize response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	w.Write(resBody)
+
+	// Update poller state.
+	if camera.CameraPollerInstance != nil {
+		camera.CameraPollerInstance.ShouldUpdateEntries = true
+	}
+}
+
+// Creates request routes & handlers.
+func CreateCameraOverlayRoute(r *mux.Router) {
+	r.HandleFunc("/overlay", postOverlayCameraHandler).Methods("POST")
+}
@@ -0,0 +1,112 @@
+package camera
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"4bit.api/v0/database"
+	"4bit.api/v0/pkg/camera"
+	"4bit.api/v0/server/route/camera/interfaces"
+	"github.com/gorilla/mux"
+)
+
+// POST endpoint request for updating a camera's name & stream in place, keeping
+// its adjustment, groups and history.
+// Expects a request of type UpdateCameraRequest.
+// On success, responds with the updated camera entry.
+func postUpdateCameraHandler(w http.ResponseWriter, r *http.Request) {
+	bodyBytes, err := io.ReadAll(r.Body)
+	defer r.Body.Close()
+	if err != nil {
+		log.Printf("/camera/update: failed to read request body:%v\n", err)
+		http.Error(w, "failed to read request body", http.StatusBadRequest)
+		return
+	}
+
+	req := interfaces.UpdateCameraRequest{}
+	if err := json.Unmarshal(bodyBytes, &req); err != nil {
+		log.Printf("/camera/update: failed to deserialize update camera request :%v\n", err)
+		http.Error(w, "failed to deserialize body", http.StatusBadRequest)
+		return
+	}
+	if req.Id == 0 {
+		http.Error(w, "expected a camera id", http.StatusBadRequest)
+		return
+	}
+
+	camEntry, status, err := findCameraEntry(req.Id, "")
+	if err != nil {
+		log.Printf("/camera/update: %v\n", err)
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	// Apply the given fields.
+	if req.Camera.Name != "" {
+		camEntry.Name = req.Camera.Name
+	}
+	if req.Camera.IP != "" {
+		if err := validateCameraHost(req.Camera.IP); err != nil {
+			log.Printf("/camera/update: invalid IP entry '%s': %v\n", req.Camera.IP, err)
+			http.Error(w, "invalid ip entry", http.StatusBadRequest)
+			return
+		}
+		camEntry.IP = req.Camera.IP
+	}
+	if req.Camera.Port != 0 {
+		camEntry.Port = req.Camera.Port
+	}
+	if req.Camera.Path != "" {
+		if !strings.HasPrefix(req.Camera.Path, "/") {
+			log.Printf("/camera/update: invalid Path entry '%s'\n", req.Camera.Path)
+			http.Error(w, "invalid path entry", http.StatusBadRequest)
+			return
+		}
+		camEntry.Path = req.Camera.Path
+	}
+
+	// Verify the stream doesn't belong to another camera.
+	cameras := database.StoreInstance.Cameras
+	if exists, err := cameras.StreamExists(camEntry.IP, camEntry.Port, camEntry.Path, camEntry.Id); err != nil || exists {
+		log.Printf("/camera/update: failed to update camera entry[%d], stream '%s' already exists: %v\n", camEntry.Id, camera.StreamEndpoint(*camEntry), err)
+		http.Error(
+			w,
+			fmt.Sprintf("camera entry with stream '%s' already exists", camera.StreamEndpoint(*camEntry)),
+			http.StatusConflict,
+		)
+		return
+	}
+
+	camEntry.ModifiedAt = time.Now()
+	if err := cameras.Update(camEntry); err != nil {
+		log.Printf("/camera/update: failed to update camera entry[%d]: %v\n", camEntry.Id, err)
+		http.Error(w, "failed to update camera entry", http.StatusInternalServerError)
+		return
+	}
+	log.Printf("/camera/update: updated camera entry[%d] to '%s' at '%s'\n", camEntry.Id, camEntry.Name, camera.StreamEndpoint(*camEntry))
+
+	resBody, err := json.Marshal(camEntry)
+	if err != nil {
+		log.Printf("/camera/update: failed to serialize camera entry response: %v\n", err)
+		http.Error(w, "failed to serialize response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	w.Write(resBody)
+
+	// Update poller state, restarting the camera's worker if its stream changed.
+	if camera.CameraPollerInstance != nil {
+		camera.CameraPollerInstance.ShouldUpdateEntries = true
+	}
+}
+
+// Creates request routes & handlers.
+func CreateCameraUpdateRoute(r *mux.Router) {
+	r.HandleFunc("/update", postUpdateCameraHandler).Methods("POST")
+}
@@ -0,0 +1,221 @@
+package node
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"4bit.api/v0/database"
+	"4bit.api/v0/internal/utils"
+	"4bit.api/v0/server/route/node/interfaces"
+	"github.com/gorilla/mux"
+)
+
+// Certificate fingerprints configured as admins through NODE_ADMIN_FINGERPRINTS,
+// which may link certificates to any node.
+var ADMIN_FINGERPRINTS = map[string]bool{}
+
+// Node queries of the link handler, replaced by tests running without postgres.
+var (
+	getNodeByFingerprint  = database.GetNodeByFingerprint
+	getFingerprintOwner   = database.GetFingerprintOwner
+	relinkNodeFingerprint = database.RelinkNodeFingerprint
+)
+
+// parseAdminFingerprints parses a comma-separated list of certificate fingerprints.
+func parseAdminFingerprints(value string) map[string]bool {
+	fingerprints := map[string]bool{}
+	for _, field := range strings.Split(value, ",") {
+		field = strings.ToUpper(strings.TrimSpace(field))
+		if field == "" {
+			continue
+		}
+		fingerprints[field] = true
+	}
+	return fingerprints
+}
+
+// Resolves a fingerprint from either a PEM-encoded certificate or a raw fingerprint.
+func resolveFingerprint(certificate string, fingerprint string) (string, error) {
+	if certificate != "" {
+		cert, err := utils.ParseCertificateFromPEMBytes([]byte(certificate))
+		if err != nil {
+			return "", err
+		}
+		return extractCertificateFingerprint(cert), nil
+	}
+
+	fingerprint = strings.ToUpper(strings.TrimSpace(fingerprint))
+	if fingerprint == "" {
+		return "", fmt.Errorf("either a certificate or fingerprint is required")
+	}
+	return fingerprint, nil
+}
+
+// Lists the fingerprints associated with the current node.
+func nodeFingerprintsGetHandler(w http.ResponseWriter, r *http.Request) {
+	fingerprint := extractCertificateFingerprint(r.TLS.PeerCertificates[0])
+	node, err := database.GetNodeByFingerprint(fingerprint)
+	if err != nil {
+		http.Error(w, "node does not exist. create a node entry first", http.StatusUnauthorized)
+		return
+	}
+
+	fingerprints, err := database.GetNodeFingerprints(node.Id)
+	if err != nil {
+		log.Printf("Failed to query fingerprints for node[%d]: %v", node.Id, err)
+		http.Error(w, "failed to query fingerprints", http.StatusInternalServerError)
+		return
+	}
+
+	// Serialize response.
+	serializedResponse, err := json.Marshal(fingerprints)
+	if err != nil {
+		http.Error(w, "failed to serialize response", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Add("Content-Type", "application/json")
+	w.Write(serializedResponse)
+}
+
+// Registers a successor certificate for the current node, such that the node
+// retains its history once its certificate is reissued.
+func nodeRotatePostHandler(w http.ResponseWriter, r *http.Request) {
+	bodyBuffer, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "failed to parse the request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	rotateRequest := interfaces.RotateRequest{}
+	if err := json.Unmarshal(bodyBuffer, &rotateRequest); err != nil {
+		http.Error(w, "failed to de-serialize request body", http.StatusBadRequest)
+		return
+	}
+
+	// Verify client node already exists in the DB.
+	fingerprint := extractCertificateFingerprint(r.TLS.PeerCertificates[0])
+	node, err := database.GetNodeByFingerprint(fingerprint)
+	if err != nil {
+		http.Error(w, "node does not exist. create a node entry first", http.StatusUnauthorized)
+		return
+	}
+
+	successor, err := resolveFingerprint(rotateRequest.Certificate, rotateRequest.Fingerprint)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid successor: %v", err), http.StatusBadRequest)
+		return
+	}
+	if successor == fingerprint {
+		http.Error(w, "successor fingerprint matches the current fingerprint", http.StatusBadRequest)
+		return
+	}
+
+	validFrom := time.Now().UTC()
+	if rotateRequest.ValidFrom != nil {
+		validFrom = rotateRequest.ValidFrom.UTC()
+	}
+
+	log.Printf("Rotating node[%d] fingerprint '%s' -> '%s'", node.Id, fingerprint, successor)
+	nodeFingerprint, err := database.RotateNodeFingerprint(node, successor, validFrom, rotateRequest.RetireAt)
+	if err != nil {
+		log.Printf("Failed to rotate node[%d] fingerprint: %v", node.Id, err)
+		http.Error(w, "failed to register successor fingerprint", http.StatusConflict)
+		return
+	}
+
+	// Serialize the new entry.
+	serializedResponse, err := json.Marshal(nodeFingerprint)
+	if err != nil {
+		http.Error(w, "failed to serialize response", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Add("Content-Type", "application/json")
+	w.Write(serializedResponse)
+}
+
+// Links a certificate to an existing node. This is used to re-attach a reissued
+// certificate which was already registered as a new node.
+func nodeLinkPostHandler(w http.ResponseWriter, r *http.Request) {
+	bodyBuffer, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "failed to parse the request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	linkRequest := interfaces.LinkRequest{}
+	if err := json.Unmarshal(bodyBuffer, &linkRequest); err != nil {
+		http.Error(w, "failed to de-serialize request body", http.StatusBadRequest)
+		return
+	}
+
+	if linkRequest.NodeId == 0 {
+		http.Error(w, "invalid node id", http.StatusBadRequest)
+		return
+	}
+
+	fingerprint, err := resolveFingerprint(linkRequest.Certificate, linkRequest.Fingerprint)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid certificate: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	// Only admins may link certificates to nodes other than their own, or link
+	// certificates of other nodes, as linking merges the certificate's node into
+	// the linked node, taking over its identity & history.
+	callerFingerprint := extractCertificateFingerprint(r.TLS.PeerCertificates[0])
+	if !ADMIN_FINGERPRINTS[callerFingerprint] {
+		node, err := getNodeByFingerprint(callerFingerprint)
+		if err != nil || node.Id != linkRequest.NodeId {
+			log.Printf("Rejected linking by non-admin fingerprint '%s' to node[%d]", callerFingerprint, linkRequest.NodeId)
+			http.Error(w, "only admins may link certificates to other nodes", http.StatusForbidden)
+			return
+		}
+
+		owner, err := getFingerprintOwner(fingerprint)
+		if err != nil {
+			log.Printf("Failed to query owner of fingerprint '%s': %v", fingerprint, err)
+			http.Error(w, "failed to query fingerprint", http.StatusInternalServerError)
+			return
+		}
+		if owner != nil && owner.Id != linkRequest.NodeId {
+			log.Printf("Rejected linking by non-admin fingerprint '%s' of node[%d] fingerprint '%s'", callerFingerprint, owner.Id, fingerprint)
+			http.Error(w, "only admins may link certificates of other nodes", http.StatusForbidden)
+			return
+		}
+	}
+
+	validFrom := time.Now().UTC()
+	if linkRequest.ValidFrom != nil {
+		validFrom = linkRequest.ValidFrom.UTC()
+	}
+
+	log.Printf("Linking fingerprint '%s' to node[%d]", fingerprint, linkRequest.NodeId)
+	nodeFingerprint, err := relinkNodeFingerprint(linkRequest.NodeId, fingerprint, validFrom, linkRequest.ValidUntil)
+	if err != nil {
+		log.Printf("Failed to link fingerprint '%s' to node[%d]: %v", fingerprint, linkRequest.NodeId, err)
+		http.Error(w, "failed to link fingerprint", http.StatusBadRequest)
+		return
+	}
+
+	// Serialize the new entry.
+	serializedResponse, err := json.Marshal(nodeFingerprint)
+	if err != nil {
+		http.Error(w, "failed to serialize response", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Add("Content-Type", "application/json")
+	w.Write(serializedResponse)
+}
+
+func CreateFingerprintRoute(r *mux.Router) {
+	r.HandleFunc("/fingerprints", nodeFingerprintsGetHandler).Methods("GET")
+	r.HandleFunc("/rotate", nodeRotatePostHandler).Methods("POST")
+	r.HandleFunc("/link", nodeLinkPostHandler).Methods("POST")
+}
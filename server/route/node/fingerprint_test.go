@@ -0,0 +1,120 @@
+package node
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"4bit.api/v0/database"
+)
+
+// Stubs the node queries of the link handler with the given fingerprint owners,
+// recording the fingerprints linked to nodes.
+func stubLinkQueries(t *testing.T, owners map[string]uint64) map[string]uint64 {
+	linked := map[string]uint64{}
+	getNodeByFingerprint = func(fingerprint string) (*database.Node, error) {
+		nodeId, ok := owners[fingerprint]
+		if !ok {
+			return nil, fmt.Errorf("failed to find node with fingerprint '%s'", fingerprint)
+		}
+		node := &database.Node{CertificateFingerprint: fingerprint}
+		node.Id = nodeId
+		return node, nil
+	}
+	getFingerprintOwner = func(fingerprint string) (*database.Node, error) {
+		nodeId, ok := owners[fingerprint]
+		if !ok {
+			return nil, nil
+		}
+		node := &database.Node{CertificateFingerprint: fingerprint}
+		node.Id = nodeId
+		return node, nil
+	}
+	relinkNodeFingerprint = func(nodeId uint64, fingerprint string, validFrom time.Time, validUntil *time.Time) (*database.NodeFingerprint, error) {
+		linked[fingerprint] = nodeId
+		return &database.NodeFingerprint{Fingerprint: fingerprint, ValidFrom: validFrom, NodeId: nodeId}, nil
+	}
+	t.Cleanup(func() {
+		getNodeByFingerprint = database.GetNodeByFingerprint
+		getFingerprintOwner = database.GetFingerprintOwner
+		relinkNodeFingerprint = database.RelinkNodeFingerprint
+		ADMIN_FINGERPRINTS = map[string]bool{}
+	})
+	return linked
+}
+
+// Posts a link request from a client presenting the given certificate.
+func postLink(cert *x509.Certificate, nodeId uint64, fingerprint string) *httptest.ResponseRecorder {
+	body := fmt.Sprintf(`{"NodeId": %d, "Fingerprint": "%s"}`, nodeId, fingerprint)
+	r := httptest.NewRequest("POST", "/node/link", strings.NewReader(body))
+	r.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+	w := httptest.NewRecorder()
+	nodeLinkPostHandler(w, r)
+	return w
+}
+
+func TestNodeLinkRejectsFingerprintOfOtherNode(t *testing.T) {
+	caller := &x509.Certificate{Raw: []byte("caller")}
+	callerFingerprint := extractCertificateFingerprint(caller)
+	linked := stubLinkQueries(t, map[string]uint64{
+		callerFingerprint: 1,
+		"AA:BB":           2,
+	})
+
+	w := postLink(caller, 1, "AA:BB")
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected %d linking another node's fingerprint, got %d", http.StatusForbidden, w.Code)
+	}
+	if len(linked) != 0 {
+		t.Errorf("expected nothing to be linked, got %v", linked)
+	}
+}
+
+func TestNodeLinkRejectsOtherNode(t *testing.T) {
+	caller := &x509.Certificate{Raw: []byte("caller")}
+	linked := stubLinkQueries(t, map[string]uint64{
+		extractCertificateFingerprint(caller): 1,
+	})
+
+	w := postLink(caller, 2, "AA:BB")
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected %d linking to another node, got %d", http.StatusForbidden, w.Code)
+	}
+	if len(linked) != 0 {
+		t.Errorf("expected nothing to be linked, got %v", linked)
+	}
+}
+
+func TestNodeLinkAllowsUnownedFingerprintToCaller(t *testing.T) {
+	caller := &x509.Certificate{Raw: []byte("caller")}
+	linked := stubLinkQueries(t, map[string]uint64{
+		extractCertificateFingerprint(caller): 1,
+	})
+
+	w := postLink(caller, 1, "aa:bb")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if linked["AA:BB"] != 1 {
+		t.Errorf("expected the fingerprint to be linked to node[1], got %v", linked)
+	}
+}
+
+func TestNodeLinkAllowsAdminToMergeNodes(t *testing.T) {
+	admin := &x509.Certificate{Raw: []byte("admin")}
+	linked := stubLinkQueries(t, map[string]uint64{"AA:BB": 2})
+	ADMIN_FINGERPRINTS = map[string]bool{extractCertificateFingerprint(admin): true}
+
+	w := postLink(admin, 1, "AA:BB")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if linked["AA:BB"] != 1 {
+		t.Errorf("expected the fingerprint to be linked to node[1], got %v", linked)
+	}
+}
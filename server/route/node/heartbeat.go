@@ -0,0 +1,138 @@
+package node
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"4bit.api/v0/database"
+	"4bit.api/v0/internal/config"
+	"4bit.api/v0/server/route/node/interfaces"
+	"github.com/gorilla/mux"
+)
+
+const (
+	// Assumed interval for nodes which did not declare one.
+	DEFAULT_HEARTBEAT_INTERVAL = 5 * time.Minute
+
+	// Leeway given to a node past its declared interval before being flagged as stale.
+	HEARTBEAT_GRACE_PERIOD = 30 * time.Second
+)
+
+// Computes the liveness of all nodes based on their last heartbeat.
+func GetNodesLiveness() ([]interfaces.NodeLiveness, error) {
+	nodes, err := database.StoreInstance.Nodes.List()
+	if err != nil {
+		return nil, err
+	}
+
+	heartbeats, err := database.StoreInstance.NodeStates.GetLastHeartbeats()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	nodesLiveness := []interfaces.NodeLiveness{}
+	for _, node := range nodes {
+		liveness := interfaces.NodeLiveness{
+			NodeId:                 node.Id,
+			CertificateFingerprint: node.CertificateFingerprint,
+		}
+
+		// Nodes without heartbeats are not tracked for liveness.
+		if heartbeat, ok := heartbeats[node.Id]; ok {
+			interval := DEFAULT_HEARTBEAT_INTERVAL
+			if heartbeat.Interval > 0 {
+				interval = time.Duration(heartbeat.Interval) * time.Second
+			}
+
+			lastSeen := heartbeat.Timestamp
+			liveness.LastSeen = &lastSeen
+			liveness.LastHeartbeat = &heartbeat.Heartbeat
+			liveness.IsStale = now.Sub(lastSeen) > interval+HEARTBEAT_GRACE_PERIOD
+		}
+		nodesLiveness = append(nodesLiveness, liveness)
+	}
+
+	return nodesLiveness, nil
+}
+
+// POST request handler for recording a heartbeat of the current node.
+// The current node is determined by the request certificate.
+func nodeHeartbeatPostHandler(w http.ResponseWriter, r *http.Request) {
+	// Parse the request.
+	bodyBuffer, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "failed to parse the request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	heartbeat := interfaces.Heartbeat{}
+	if err := json.Unmarshal(bodyBuffer, &heartbeat); err != nil {
+		log.Println("Internal Error: Failed to de-serialize Heartbeat request body")
+		http.Error(w, "failed to de-serialize request body", http.StatusBadRequest)
+		return
+	}
+
+	// Verify client node already exists in the DB.
+	clientCert := r.TLS.PeerCertificates[0]
+	fingerprint := extractCertificateFingerprint(clientCert)
+	node, err := database.StoreInstance.Nodes.GetByFingerprint(fingerprint)
+	if err != nil {
+		http.Error(w, "node does not exist. create a node entry first", http.StatusUnauthorized)
+		return
+	}
+
+	// Fallback on the request's address when the node does not report one.
+	if heartbeat.IP == "" {
+		if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+			heartbeat.IP = host
+		}
+	}
+
+	nodeHeartbeatEntry := database.NodeHeartbeat{
+		Heartbeat: heartbeat,
+		NodeId:    node.Id,
+	}
+	nodeHeartbeatEntry.Timestamp = time.Now().UTC()
+
+	if err := database.StoreInstance.NodeStates.AddHeartbeat(&nodeHeartbeatEntry); err != nil {
+		log.Printf("New heartbeat entry failed for node '%s': %v", node.CertificateFingerprint, err)
+		http.Error(w, "failed to create new heartbeat entry", http.StatusInternalServerError)
+		return
+	}
+	if config.Verbose {
+		log.Printf("New heartbeat entry[%d] created for node '%s'", nodeHeartbeatEntry.Id, node.CertificateFingerprint)
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// GET request handler for listing all nodes along with their liveness.
+func nodeListGetHandler(w http.ResponseWriter, r *http.Request) {
+	nodesLiveness, err := GetNodesLiveness()
+	if err != nil {
+		log.Printf("Failed to query nodes liveness: %v", err)
+		http.Error(w, "failed to query nodes", http.StatusInternalServerError)
+		return
+	}
+
+	// Serialize response.
+	serializedResponse, err := json.Marshal(interfaces.ListNodesResponse{
+		Nodes: nodesLiveness,
+	})
+	if err != nil {
+		http.Error(w, "failed to serialize response", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Add("Content-Type", "application/json")
+	w.Write(serializedResponse)
+}
+
+func CreateHeartbeatRoute(r *mux.Router) {
+	r.HandleFunc("/heartbeat", nodeHeartbeatPostHandler).Methods("POST")
+	r.HandleFunc("/list", nodeListGetHandler).Methods("GET")
+}
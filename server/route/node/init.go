@@ -2,7 +2,10 @@ package node
 
 import (
 	"context"
+	"log"
+	"os"
 
+	"4bit.api/v0/server/middleware"
 	"github.com/gorilla/mux"
 )
 
@@ -10,4 +13,17 @@ import (
 func CreateRoutes(ctx *context.Context, r *mux.Router) {
 	CreateNodeRoute(r)
 	CreateStateRoute(r)
+
+	// Extract the admins allowed to link certificates to any node from .env.
+	ADMIN_FINGERPRINTS = parseAdminFingerprints(os.Getenv("NODE_ADMIN_FINGERPRINTS"))
+	if len(ADMIN_FINGERPRINTS) == 0 {
+		log.Println("NODE_ADMIN_FINGERPRINTS not set, nodes may only link certificates to themselves")
+	}
+
+	// Merging a node's history spans subsystems only stored in postgres.
+	fingerprintRouter := r.NewRoute().Subrouter()
+	fingerprintRouter.Use(middleware.RequirePostgres)
+	CreateFingerprintRoute(fingerprintRouter)
+
+	CreateHeartbeatRoute(r)
 }
@@ -0,0 +1,26 @@
+package interfaces
+
+import "time"
+
+// Register a successor certificate for the current node.
+type RotateRequest struct {
+	// Successor's PEM-encoded certificate or its fingerprint.
+	Certificate string
+	Fingerprint string
+
+	ValidFrom *time.Time // Defaults to now.
+	RetireAt  *time.Time // Retires the current certificate. Never retired if nil.
+}
+
+// Link a certificate to an existing node, merging the history of the node
+// which currently owns that certificate.
+type LinkRequest struct {
+	NodeId uint64
+
+	// PEM-encoded certificate or its fingerprint.
+	Certificate string
+	Fingerprint string
+
+	ValidFrom  *time.Time // Defaults to now.
+	ValidUntil *time.Time // Never expires if nil.
+}
@@ -0,0 +1,25 @@
+package interfaces
+
+import "time"
+
+// Heartbeat reported by a node.
+type Heartbeat struct {
+	Uptime          uint64 // Seconds since the node booted.
+	FirmwareVersion string
+	IP              string
+	FreeMemory      uint64 // Bytes.
+	Interval        uint64 // Seconds between heartbeats.
+}
+
+// Liveness of a node, determined by its last heartbeat.
+type NodeLiveness struct {
+	NodeId                 uint64
+	CertificateFingerprint string
+	LastSeen               *time.Time
+	LastHeartbeat          *Heartbeat
+	IsStale                bool
+}
+
+type ListNodesResponse struct {
+	Nodes []NodeLiveness
+}
@@ -35,13 +35,14 @@ func nodeGetHandler(w http.ResponseWriter, r *http.Request) {
 	fingerprint := extractCertificateFingerprint(cert)
 
 	// Query the database to get the node with the matching fingerprint.
-	node, err := database.GetNodeByFingerprint(fingerprint)
+	node, err := database.StoreInstance.Nodes.GetByFingerprint(fingerprint)
 	if err != nil {
 		http.Error(
 			w,
 			fmt.Sprintf("failed to find node with fingerprint '%s'", fingerprint),
 			http.StatusNotFound,
 		)
+		return
 	}
 
 	// Serialize response.
@@ -62,20 +63,26 @@ func nodePostHandler(w http.ResponseWriter, r *http.Request) {
 
 	// Query the database to check if node entry already exists.
 	// Node already exists.
-	if _, err := database.GetNodeByFingerprint(fingerprint); err == nil {
+	if _, err := database.StoreInstance.Nodes.GetByFingerprint(fingerprint); err == nil {
 		http.Error(w, "node already exists", http.StatusConflict)
 		return
 	}
 
+	// Fingerprint belongs to a node, though outside of its validity window.
+	nodes := database.StoreInstance.Nodes
+	if exists, _ := nodes.FingerprintExists(fingerprint); exists {
+		http.Error(w, "fingerprint is registered outside of its validity window", http.StatusConflict)
+		return
+	}
+
 	// Create new entry for node.
 	log.Printf("Creating new node entry with fingerprint %s", fingerprint)
-	db := database.DbInstance
 	node := database.Node{
 		CertificateFingerprint: fingerprint,
 	}
 	node.Timestamp = time.Now().UTC()
 
-	if _, err := db.Model(&node).Insert(); err != nil {
+	if err := nodes.Add(&node); err != nil {
 		log.Printf("Failed to create node entry for fingerprint '%s': %v", fingerprint, err)
 		http.Error(w, "failed to create node entry", http.StatusInternalServerError)
 		return
@@ -9,7 +9,9 @@ import (
 	"time"
 
 	"4bit.api/v0/database"
+	"4bit.api/v0/pkg/alert"
 	"4bit.api/v0/server/route/node/interfaces"
+	"4bit.api/v0/server/route/parking"
 	"github.com/gorilla/mux"
 )
 
@@ -35,7 +37,7 @@ func nodeStateGetHandler(w http.ResponseWriter, r *http.Request) {
 	// Verify client node already exists in the DB.
 	clientCert := r.TLS.PeerCertificates[0]
 	fingerprint := extractCertificateFingerprint(clientCert)
-	node, err := database.GetNodeByFingerprint(fingerprint)
+	node, err := database.StoreInstance.Nodes.GetByFingerprint(fingerprint)
 	if err != nil {
 		http.Error(w, "node does not exist. create a node entry first", http.StatusUnauthorized)
 		return
@@ -47,12 +49,12 @@ func nodeStateGetHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Handle request based on type.
-	db := database.DbInstance
+	nodeStates := database.StoreInstance.NodeStates
 	var responseBuffer []byte
 	switch stateRequest.Type {
 	case interfaces.BAROMETER:
-		barometerStates := []database.NodeBarometerState{}
-		if err := db.Model(&barometerStates).Relation("Node").Where("node_id = ?", node.Id).Limit(int(*stateRequest.Limit)).Select(); err != nil {
+		barometerStates, err := nodeStates.GetBarometerStates(node.Id, int(*stateRequest.Limit))
+		if err != nil {
 			log.Printf("Failed to requeste barometer data client '%s': %v", node.CertificateFingerprint, err)
 			http.Error(w, "failed to request barometer entries", http.StatusInternalServerError)
 			return
@@ -68,8 +70,8 @@ func nodeStateGetHandler(w http.ResponseWriter, r *http.Request) {
 		responseBuffer = buffer
 
 	case interfaces.POWER:
-		powerStates := []database.NodePowerState{}
-		if err := db.Model(&powerStates).Relation("Node").Where("node_id = ?", node.Id).Limit(int(*stateRequest.Limit)).Select(); err != nil {
+		powerStates, err := nodeStates.GetPowerStates(node.Id, int(*stateRequest.Limit))
+		if err != nil {
 			log.Printf("Failed to requeste power data client '%s': %v", node.CertificateFingerprint, err)
 			http.Error(w, "failed to request power entries", http.StatusInternalServerError)
 			return
@@ -121,7 +123,7 @@ func nodeStatePostHandler(w http.ResponseWriter, r *http.Request) {
 	// Verify client node already exists in the DB.
 	clientCert := r.TLS.PeerCertificates[0]
 	fingerprint := extractCertificateFingerprint(clientCert)
-	node, err := database.GetNodeByFingerprint(fingerprint)
+	node, err := database.StoreInstance.Nodes.GetByFingerprint(fingerprint)
 	if err != nil {
 		http.Error(w, "node does not exist. create a node entry first", http.StatusUnauthorized)
 		return
@@ -129,7 +131,7 @@ func nodeStatePostHandler(w http.ResponseWriter, r *http.Request) {
 
 	// Handle request based on the given states.
 	// TODO: Bulk apply to the database.
-	db := database.DbInstance
+	nodeStates := database.StoreInstance.NodeStates
 	if stateRequest.BarometerState != nil {
 		// Create a node state entry associated with the client node.
 		nodeBarStateEntry := database.NodeBarometerState{
@@ -139,7 +141,7 @@ func nodeStatePostHandler(w http.ResponseWriter, r *http.Request) {
 		nodeBarStateEntry.Timestamp = time.Now().UTC()
 
 		// Create new entry.
-		if _, err := db.Model(&nodeBarStateEntry).Insert(); err != nil {
+		if err := nodeStates.AddBarometerState(&nodeBarStateEntry); err != nil {
 			log.Printf("New Barometer entry failed for node '%s': %v", node.CertificateFingerprint, err)
 			http.Error(
 				w,
@@ -149,6 +151,8 @@ func nodeStatePostHandler(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		log.Printf("New barometer entry[%d] created for node '%s'", nodeBarStateEntry.Id, node.CertificateFingerprint)
+		alert.Evaluate(node.Id, interfaces.BAROMETER, nodeBarStateEntry.BarometerState, nodeBarStateEntry.Timestamp)
+		parking.ObserveBarometerEntry(&nodeBarStateEntry)
 	}
 
 	if stateRequest.Power != nil {
@@ -160,7 +164,7 @@ func nodeStatePostHandler(w http.ResponseWriter, r *http.Request) {
 		nodePowerStateEntry.Timestamp = time.Now().UTC()
 
 		// Create new entry.
-		if _, err := db.Model(&nodePowerStateEntry).Insert(); err != nil {
+		if err := nodeStates.AddPowerState(&nodePowerStateEntry); err != nil {
 			log.Printf("New Power entry failed for node '%s': %v", node.CertificateFingerprint, err)
 			http.Error(
 				w,
@@ -170,6 +174,8 @@ func nodeStatePostHandler(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		log.Printf("New power entry[%d] created for node '%s'", nodePowerStateEntry.Id, node.CertificateFingerprint)
+		alert.Evaluate(node.Id, interfaces.POWER, nodePowerStateEntry.PowerState, nodePowerStateEntry.Timestamp)
+		parking.ObservePowerEntry(&nodePowerStateEntry)
 	}
 	w.WriteHeader(http.StatusOK)
 }
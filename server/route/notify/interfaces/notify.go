@@ -0,0 +1,25 @@
+package interfaces
+
+import (
+	"4bit.api/v0/database"
+	"4bit.api/v0/pkg/notify"
+)
+
+// Request delivering a message through a configured route.
+type NotifyRequest struct {
+	Route   string         `json:"route"`
+	Message notify.Message `json:"message"`
+}
+
+type ListRoutesResponse struct {
+	Routes []database.NotifyRoute // Routes with their secrets redacted.
+	Kinds  []string               // Supported notifier kinds.
+}
+
+type AddRouteRequest struct {
+	Route database.NotifyRoute
+}
+
+type RemoveRouteRequest struct {
+	Name string `json:"name"`
+}
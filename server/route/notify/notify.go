@@ -0,0 +1,221 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"4bit.api/v0/database"
+	"4bit.api/v0/pkg/alert"
+	"4bit.api/v0/pkg/notify"
+	"4bit.api/v0/server/route/notify/interfaces"
+	"github.com/gorilla/mux"
+)
+
+// notifyAlertEvent is an alert handler which delivers alert transitions of rules
+// with a notification route through that route.
+func notifyAlertEvent(rule database.AlertRule, event database.AlertEvent) {
+	if rule.Route == "" {
+		return
+	}
+	if err := notify.Send(rule.Route, alert.FormatEvent(rule, event)); err != nil {
+		log.Printf("Failed to deliver alert rule[%d]: %v\n", rule.Id, err)
+	}
+}
+
+// Delivers a message through a notification route.
+// Request expected to be of type NotifyRequest.
+// On success, responds with empty message.
+func postNotifyHandler(w http.ResponseWriter, r *http.Request) {
+	bodyBuffer, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "failed to parse the request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	req := interfaces.NotifyRequest{}
+	if err := json.Unmarshal(bodyBuffer, &req); err != nil {
+		log.Printf("/notify: failed to de-serialize request: %v\n", err)
+		http.Error(w, "failed to de-serialize request body", http.StatusBadRequest)
+		return
+	}
+	if req.Message.Body == "" && len(req.Message.Image) == 0 {
+		http.Error(w, "message cannot be empty", http.StatusBadRequest)
+		return
+	}
+
+	if req.Route == "" {
+		http.Error(w, "invalid empty route", http.StatusBadRequest)
+		return
+	}
+
+	// Only deliver through configured routes, such that callers can't direct
+	// the server at arbitrary hosts.
+	route, err := notify.GetRoute(req.Route)
+	if err != nil {
+		log.Printf("/notify: %v\n", err)
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	notifier, err := notify.New(route.Kind, route.Config)
+	if err != nil {
+		log.Printf("/notify: invalid route '%s': %v\n", route.Name, err)
+		http.Error(w, "invalid notification route", http.StatusInternalServerError)
+		return
+	}
+	if err := notifier.Notify(req.Message); err != nil {
+		log.Printf("/notify: failed to notify route '%s': %v\n", route.Name, err)
+		http.Error(w, "failed to deliver message", http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	w.Write([]byte("{}"))
+}
+
+// GET endpoint request for retrieving all notification routes.
+// Returns a ListRoutesResponse.
+func getRoutesHandler(w http.ResponseWriter, r *http.Request) {
+	db := database.DbInstance
+	routes := []database.NotifyRoute{}
+	if err := db.Model(&routes).Order("name ASC").Select(); err != nil {
+		log.Printf("/notify/routes: failed to query notification routes: %v\n", err)
+		http.Error(w, "failed to query notification routes", http.StatusInternalServerError)
+		return
+	}
+	for idx, route := range routes {
+		routes[idx] = notify.Redact(route)
+	}
+
+	respBody, err := json.Marshal(interfaces.ListRoutesResponse{
+		Routes: routes,
+		Kinds:  notify.Kinds(),
+	})
+	if err != nil {
+		log.Printf("/notify/routes: failed to serialize response: %v\n", err)
+		http.Error(w, "failed to serialize response body", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	w.Write(respBody)
+}
+
+// Adds a new notification route.
+// Request expected to be of type AddRouteRequest.
+// On success, responds with the new database entry.
+func postAddRouteHandler(w http.ResponseWriter, r *http.Request) {
+	bodyBuffer, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "failed to parse the request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	req := interfaces.AddRouteRequest{}
+	if err := json.Unmarshal(bodyBuffer, &req); err != nil {
+		log.Printf("/notify/routes/add: failed to de-serialize request: %v\n", err)
+		http.Error(w, "failed to de-serialize request body", http.StatusBadRequest)
+		return
+	}
+
+	route := req.Route
+	route.Name = strings.TrimSpace(route.Name)
+	if route.Name == "" {
+		http.Error(w, "invalid empty name entry", http.StatusBadRequest)
+		return
+	}
+	if _, err := notify.New(route.Kind, route.Config); err != nil {
+		log.Printf("/notify/routes/add: invalid route '%s': %v\n", route.Name, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	db := database.DbInstance
+	if exists, err := db.Model((*database.NotifyRoute)(nil)).Where("name = ?", route.Name).Exists(); err != nil {
+		log.Printf("/notify/routes/add: failed to query route '%s': %v\n", route.Name, err)
+		http.Error(w, "failed to query notification route", http.StatusInternalServerError)
+		return
+	} else if exists {
+		http.Error(w, "notification route already exists", http.StatusConflict)
+		return
+	}
+
+	route.Id = 0
+	route.Timestamp = time.Now().UTC()
+	if _, err := db.Model(&route).Insert(); err != nil {
+		log.Printf("/notify/routes/add: failed to add route '%s': %v\n", route.Name, err)
+		http.Error(w, "failed to add notification route", http.StatusInternalServerError)
+		return
+	}
+	log.Printf("/notify/routes/add: added %s route[%d] '%s'\n", route.Kind, route.Id, route.Name)
+
+	respBody, err := json.Marshal(notify.Redact(route))
+	if err != nil {
+		http.Error(w, "failed to serialize response body", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	w.Write(respBody)
+}
+
+// Removes a notification route, given no alert rules are routed to it.
+// Request expected to be of type RemoveRouteRequest.
+// On success, responds with empty message.
+func postRemoveRouteHandler(w http.ResponseWriter, r *http.Request) {
+	bodyBuffer, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "failed to parse the request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	req := interfaces.RemoveRouteRequest{}
+	if err := json.Unmarshal(bodyBuffer, &req); err != nil {
+		log.Printf("/notify/routes/remove: failed to de-serialize request: %v\n", err)
+		http.Error(w, "failed to de-serialize request body", http.StatusBadRequest)
+		return
+	}
+
+	db := database.DbInstance
+	if inUse, err := db.Model((*database.AlertRule)(nil)).Where("route = ?", req.Name).Exists(); err != nil {
+		log.Printf("/notify/routes/remove: failed to query rules of route '%s': %v\n", req.Name, err)
+		http.Error(w, "failed to query alert rules", http.StatusInternalServerError)
+		return
+	} else if inUse {
+		http.Error(w, "notification route is used by alert rules", http.StatusConflict)
+		return
+	}
+
+	res, err := db.Model((*database.NotifyRoute)(nil)).Where("name = ?", req.Name).Delete()
+	if err != nil {
+		log.Printf("/notify/routes/remove: failed to remove route '%s': %v\n", req.Name, err)
+		http.Error(w, "failed to remove notification route", http.StatusInternalServerError)
+		return
+	}
+	if res.RowsAffected() == 0 {
+		http.Error(w, "notification route not found", http.StatusNotFound)
+		return
+	}
+
+	log.Printf("/notify/routes/remove: removed route '%s'\n", req.Name)
+	w.Header().Add("Content-Type", "application/json")
+	w.Write([]byte("{}"))
+}
+
+func CreateRoutes(ctx *context.Context, r *mux.Router) {
+	r.HandleFunc("", postNotifyHandler).Methods("POST")
+	r.HandleFunc("/routes", getRoutesHandler).Methods("GET")
+	r.HandleFunc("/routes/add", postAddRouteHandler).Methods("POST")
+	r.HandleFunc("/routes/remove", postRemoveRouteHandler).Methods("POST")
+
+	// Deliver alerts of routed rules.
+	alert.RegisterHandler(notifyAlertEvent)
+}
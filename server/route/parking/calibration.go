@@ -0,0 +1,132 @@
+package parking
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"4bit.api/v0/database"
+	"github.com/go-pg/pg/v10"
+)
+
+const (
+	// Garage used when calibrating without specifying one.
+	DEFAULT_GARAGE = "default"
+
+	// Oldest barometer reading accepted as a floor's reference sample.
+	MAX_CALIBRATION_READING_AGE = 10 * time.Minute
+)
+
+// Retrieves the garage entry of the given name, creating it if it doesn't exist.
+func GetOrCreateGarage(name string) (*database.ParkingGarage, error) {
+	db := database.DbInstance
+	garage := database.ParkingGarage{
+		Name: strings.ToLower(strings.TrimSpace(name)),
+	}
+	garage.Timestamp = time.Now().UTC()
+
+	if _, err := db.Model(&garage).
+		Where("name = ?name").
+		SelectOrInsert(); err != nil {
+		return nil, fmt.Errorf("failed to get or create garage '%s': %v", garage.Name, err)
+	}
+	return &garage, nil
+}
+
+// Records the node's current barometer altitude as a reference sample for the
+// given garage floor, re-calibrating that floor's band.
+// Returns the updated floor band.
+func CalibrateFloor(nodeId uint64, garageName string, floor int) (*database.ParkingFloorBand, error) {
+	barEntry, err := GetLastKnownBarometerEntry(nodeId)
+	if err != nil {
+		return nil, err
+	}
+	if age := time.Since(barEntry.Timestamp); age > MAX_CALIBRATION_READING_AGE {
+		return nil, fmt.Errorf("last barometer reading of node %d is too old (%v)", nodeId, age.Round(time.Second))
+	}
+
+	garage, err := GetOrCreateGarage(garageName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Record the altitude relative to the garage's reference node, if possible.
+	var relativeAltitude *float32
+	if garage.ReferenceNodeId != 0 {
+		if refEntry, err := GetReferenceEntry(garage.ReferenceNodeId, barEntry.Timestamp); err != nil {
+			log.Printf("Calibrating floor %d without a reference: %v", floor, err)
+		} else {
+			altitude := RelativeAltitude(barEntry.Pressure, refEntry.Pressure)
+			relativeAltitude = &altitude
+		}
+	}
+
+	band := database.ParkingFloorBand{}
+	err = database.DbInstance.RunInTransaction(context.Background(), func(tx *pg.Tx) error {
+		sample := database.ParkingFloorSample{
+			Floor:            floor,
+			Altitude:         barEntry.Altitude,
+			RelativeAltitude: relativeAltitude,
+			GarageId:         garage.Id,
+			NodeId:           nodeId,
+			BarometerStateId: barEntry.Id,
+		}
+		sample.Timestamp = time.Now().UTC()
+		if _, err := tx.Model(&sample).Insert(); err != nil {
+			return fmt.Errorf("failed to record floor sample: %v", err)
+		}
+
+		// Re-compute the band from all of the floor's samples.
+		var mean, min, max, relMean, relMin, relMax float64
+		var count, relCount uint64
+		if err := tx.Model((*database.ParkingFloorSample)(nil)).
+			ColumnExpr("avg(altitude), min(altitude), max(altitude), count(*)").
+			ColumnExpr("coalesce(avg(relative_altitude), 0), coalesce(min(relative_altitude), 0), coalesce(max(relative_altitude), 0), count(relative_altitude)").
+			Where("garage_id = ?", garage.Id).
+			Where("node_id = ?", nodeId).
+			Where("floor = ?", floor).
+			Select(&mean, &min, &max, &count, &relMean, &relMin, &relMax, &relCount); err != nil {
+			return fmt.Errorf("failed to aggregate floor samples: %v", err)
+		}
+
+		err := tx.Model(&band).
+			Where("garage_id = ?", garage.Id).
+			Where("node_id = ?", nodeId).
+			Where("floor = ?", floor).
+			Select()
+		if err != nil && err != pg.ErrNoRows {
+			return fmt.Errorf("failed to query floor band: %v", err)
+		}
+
+		band.Timestamp = sample.Timestamp
+		band.Floor = floor
+		band.Altitude = float32(mean)
+		band.MinAltitude = float32(min)
+		band.MaxAltitude = float32(max)
+		band.Samples = count
+		band.RelativeAltitude = float32(relMean)
+		band.MinRelativeAltitude = float32(relMin)
+		band.MaxRelativeAltitude = float32(relMax)
+		band.RelativeSamples = relCount
+		band.GarageId = garage.Id
+		band.NodeId = nodeId
+
+		if err == pg.ErrNoRows {
+			_, err = tx.Model(&band).Insert()
+		} else {
+			_, err = tx.Model(&band).WherePK().Update()
+		}
+		if err != nil {
+			return fmt.Errorf("failed to store floor band: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	band.Garage = garage
+	return &band, nil
+}
@@ -0,0 +1,51 @@
+package interfaces
+
+import "4bit.api/v0/database"
+
+// Inferred parking floor of a barometer entry.
+type FloorEstimate struct {
+	Band *database.ParkingFloorBand
+
+	// Altitude compared against the band, which is relative to the reference
+	// entry if one was used.
+	Altitude   float32
+	Distance   float64 // Distance from the band's range.
+	Confidence float64 // Ranges between [0, 1].
+
+	// Reference barometer entry used to compensate for drift. Nil if absolute
+	// altitude was used.
+	Reference *database.NodeBarometerState
+}
+
+// Current parking state of a vehicle node.
+type VehicleParking struct {
+	NodeId    uint64
+	Barometer *database.NodeBarometerState
+	Estimate  *FloorEstimate
+	Session   *database.ParkingSession // Ongoing session, if parked.
+
+	// Reason for which the floor could not be inferred.
+	Error string `json:",omitempty"`
+}
+
+type GetParkingResponse struct {
+	Vehicles []VehicleParking
+}
+
+type ListBandsRequest struct {
+	NodeId uint64 `json:"nodeId"` // Filters on a node if non-zero.
+}
+
+type ListBandsResponse struct {
+	Garages []database.ParkingGarage
+	Bands   []database.ParkingFloorBand
+}
+
+type ListSessionsRequest struct {
+	NodeId uint64 `json:"nodeId"` // Filters on a node if non-zero.
+	Limit  uint64 `json:"limit"`
+}
+
+type ListSessionsResponse struct {
+	Sessions []database.ParkingSession
+}
@@ -2,8 +2,20 @@ package parking
 
 import (
 	"fmt"
+	"math"
 
 	"4bit.api/v0/database"
+	"4bit.api/v0/server/route/parking/interfaces"
+)
+
+const (
+	// Furthest an altitude may be from a calibrated band for it to be mapped
+	// onto that band's floor.
+	MAX_FLOOR_DISTANCE = 10.0
+
+	// Confidence penalty applied when relying on absolute altitude, which drifts
+	// with the weather.
+	ABSOLUTE_ALTITUDE_CONFIDENCE = 0.5
 )
 
 // Retrieves the last known barometric entry for a given node id.
@@ -26,26 +38,154 @@ func GetLastKnownBarometerEntry(nodeId uint64) (*database.NodeBarometerState, er
 	return &barometerStates[0], nil
 }
 
-func GetParkingFloor(altitude float32) (uint8, error) {
-	// Parking Floor 1.
-	// non-charging floor 1 -> ~3-4.5m
+// Retrieves the calibrated floor bands of a given node across all garages, or the
+// bands of all nodes if the node id is zero.
+func GetFloorBands(nodeId uint64) ([]database.ParkingFloorBand, error) {
+	db := database.DbInstance
+	bands := []database.ParkingFloorBand{}
+	query := db.Model(&bands).
+		Relation("Garage").
+		Order("parking_floor_band.node_id ASC", "garage.name ASC", "parking_floor_band.floor ASC")
+	if nodeId != 0 {
+		query = query.Where("parking_floor_band.node_id = ?", nodeId)
+	}
+
+	if err := query.Select(); err != nil {
+		return nil, fmt.Errorf("failed to query floor bands for node %d: %v", nodeId, err)
+	}
+	return bands, nil
+}
 
-	// Parking Floor 2.
-	// Non-charging Floor 2 -> ~39m.
-	if altitude <= 40.0 && altitude > 38.0 {
-		return 2, nil
+// Distance of an altitude from a band's range, being zero within the range.
+func rangeDistance(altitude float32, min float32, max float32) float64 {
+	if altitude < min {
+		return float64(min - altitude)
 	}
+	if altitude > max {
+		return float64(altitude - max)
+	}
+	return 0
+}
+
+// Candidate band along with the altitude it was compared against.
+type floorCandidate struct {
+	estimate     interfaces.FloorEstimate
+	meanDistance float64
+}
+
+// Maps a barometer entry onto the nearest calibrated floor band of its node.
+// Bands of garages with a reference node are compared using the altitude relative
+// to the reference entry at the same time, compensating for pressure drift.
+// Otherwise, the absolute altitude is used.
+func GetParkingFloor(barEntry *database.NodeBarometerState) (*interfaces.FloorEstimate, error) {
+	if barEntry.NodeId == 0 {
+		return nil, fmt.Errorf("barometer entry has no node")
+	}
+	bands, err := GetFloorBands(barEntry.NodeId)
+	if err != nil {
+		return nil, err
+	}
+	if len(bands) == 0 {
+		return nil, fmt.Errorf("node %d has no calibrated floors", barEntry.NodeId)
+	}
+
+	// Cache of reference entries, keyed by the reference node id.
+	references := map[uint64]*database.NodeBarometerState{}
+	getReference := func(garage *database.ParkingGarage) *database.NodeBarometerState {
+		if garage == nil || garage.ReferenceNodeId == 0 || barEntry.Pressure <= 0 {
+			return nil
+		}
+		if ref, ok := references[garage.ReferenceNodeId]; ok {
+			return ref
+		}
+		ref, _ := GetReferenceEntry(garage.ReferenceNodeId, barEntry.Timestamp)
+		references[garage.ReferenceNodeId] = ref
+		return ref
+	}
+
+	candidates := []floorCandidate{}
+	for i := range bands {
+		band := &bands[i]
+		candidate := floorCandidate{}
+		candidate.estimate.Band = band
 
-	// Parking Floor 3.
-	// NOTE: -30/-35 might be wrong...
-	// Charging on Floor 3 -> ~72-74m
-	// Non-charging Floor 3 -> ~20m
-	if altitude <= 21.0 && altitude > 19.0 {
-		return 3, nil
+		if ref := getReference(band.Garage); ref != nil && band.RelativeSamples > 0 {
+			altitude := RelativeAltitude(barEntry.Pressure, ref.Pressure)
+			candidate.estimate.Altitude = altitude
+			candidate.estimate.Reference = ref
+			candidate.estimate.Distance = rangeDistance(altitude, band.MinRelativeAltitude, band.MaxRelativeAltitude)
+			candidate.meanDistance = math.Abs(float64(altitude - band.RelativeAltitude))
+		} else {
+			candidate.estimate.Altitude = barEntry.Altitude
+			candidate.estimate.Distance = rangeDistance(barEntry.Altitude, band.MinAltitude, band.MaxAltitude)
+			candidate.meanDistance = math.Abs(float64(barEntry.Altitude - band.Altitude))
+		}
+		candidates = append(candidates, candidate)
 	}
-	// Parking Floor 4.
-	// Parking Floor 5.
 
-	// Unknown floor.
-	return 0, fmt.Errorf("altitude value of %.2f does not have a floor mapped", altitude)
+	// Find the nearest band, breaking ties on the distance from the band's mean.
+	nearest := &candidates[0]
+	for i := range candidates {
+		candidate := &candidates[i]
+		if candidate.estimate.Distance < nearest.estimate.Distance ||
+			(candidate.estimate.Distance == nearest.estimate.Distance && candidate.meanDistance < nearest.meanDistance) {
+			nearest = candidate
+		}
+	}
+
+	if nearest.estimate.Distance > MAX_FLOOR_DISTANCE {
+		return nil, fmt.Errorf(
+			"altitude value of %.2f is %.2fm away from the nearest calibrated floor",
+			nearest.estimate.Altitude,
+			nearest.estimate.Distance,
+		)
+	}
+
+	// Confidence drops the further away the altitude is from the band, the closer
+	// it is to the runner-up band, and without a reference.
+	estimate := nearest.estimate
+	estimate.Confidence = 1.0 - estimate.Distance/MAX_FLOOR_DISTANCE
+
+	runnerUpDistance := math.Inf(1)
+	for i := range candidates {
+		candidate := &candidates[i]
+		if candidate != nearest && candidate.estimate.Band.GarageId == estimate.Band.GarageId {
+			runnerUpDistance = math.Min(runnerUpDistance, candidate.meanDistance)
+		}
+	}
+	if !math.IsInf(runnerUpDistance, 1) && runnerUpDistance+nearest.meanDistance > 0 {
+		estimate.Confidence *= runnerUpDistance / (runnerUpDistance + nearest.meanDistance)
+	}
+
+	if estimate.Reference != nil {
+		skew := estimate.Reference.Timestamp.Sub(barEntry.Timestamp)
+		estimate.Confidence *= 1.0 - 0.5*math.Abs(skew.Seconds())/MAX_REFERENCE_SKEW.Seconds()
+	} else {
+		estimate.Confidence *= ABSOLUTE_ALTITUDE_CONFIDENCE
+	}
+
+	return &estimate, nil
+}
+
+// Retrieves the ids of all vehicle nodes, being nodes with calibrated floors.
+func GetVehicleNodeIds() ([]uint64, error) {
+	db := database.DbInstance
+	nodeIds := []uint64{}
+	if err := db.Model((*database.ParkingFloorBand)(nil)).
+		ColumnExpr("DISTINCT node_id").
+		Order("node_id ASC").
+		Select(&nodeIds); err != nil {
+		return nil, fmt.Errorf("failed to query vehicle nodes: %v", err)
+	}
+	return nodeIds, nil
+}
+
+// Retrieves all garages.
+func GetGarages() ([]database.ParkingGarage, error) {
+	db := database.DbInstance
+	garages := []database.ParkingGarage{}
+	if err := db.Model(&garages).Order("name ASC").Select(); err != nil {
+		return nil, fmt.Errorf("failed to query garages: %v", err)
+	}
+	return garages, nil
 }
@@ -0,0 +1,66 @@
+package parking
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"4bit.api/v0/database"
+)
+
+const (
+	// Largest time difference allowed between a reading and its reference reading.
+	MAX_REFERENCE_SKEW = 5 * time.Minute
+)
+
+// Computes the altitude of a pressure reading relative to a reference pressure
+// reading, using the barometric formula. Both pressures must share the same unit.
+func RelativeAltitude(pressure float32, referencePressure float32) float32 {
+	return float32(44330.0 * (1.0 - math.Pow(float64(pressure)/float64(referencePressure), 1.0/5.255)))
+}
+
+// Retrieves the reference node's barometer entry nearest to the given time, within
+// MAX_REFERENCE_SKEW.
+func GetReferenceEntry(referenceNodeId uint64, at time.Time) (*database.NodeBarometerState, error) {
+	db := database.DbInstance
+	barometerStates := []database.NodeBarometerState{}
+	if err := db.Model(&barometerStates).
+		Where("node_id = ?", referenceNodeId).
+		Where("timestamp BETWEEN ? AND ?", at.Add(-MAX_REFERENCE_SKEW), at.Add(MAX_REFERENCE_SKEW)).
+		OrderExpr("abs(extract(epoch FROM timestamp - ?)) ASC", at).
+		Limit(1).
+		Select(); err != nil {
+		return nil, fmt.Errorf("failed to find reference barometer entry for node %d: %v", referenceNodeId, err)
+	}
+
+	if len(barometerStates) == 0 {
+		return nil, fmt.Errorf("reference node %d has no entries within %v of %v", referenceNodeId, MAX_REFERENCE_SKEW, at)
+	}
+	if barometerStates[0].Pressure <= 0 {
+		return nil, fmt.Errorf("reference node %d reported an invalid pressure", referenceNodeId)
+	}
+
+	return &barometerStates[0], nil
+}
+
+// Sets the reference node of a garage, creating the garage if it doesn't exist.
+// A zero node id removes the garage's reference.
+func SetGarageReference(garageName string, referenceNodeId uint64) (*database.ParkingGarage, error) {
+	garage, err := GetOrCreateGarage(garageName)
+	if err != nil {
+		return nil, err
+	}
+
+	db := database.DbInstance
+	if referenceNodeId != 0 {
+		if exists, err := db.Model((*database.Node)(nil)).Where("id = ?", referenceNodeId).Exists(); err != nil || !exists {
+			return nil, fmt.Errorf("reference node %d does not exist", referenceNodeId)
+		}
+	}
+
+	garage.ReferenceNodeId = referenceNodeId
+	if _, err := db.Model(garage).Column("reference_node_id").WherePK().Update(); err != nil {
+		return nil, fmt.Errorf("failed to update garage '%s' reference: %v", garage.Name, err)
+	}
+	return garage, nil
+}
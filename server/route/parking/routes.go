@@ -0,0 +1,154 @@
+package parking
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+
+	"4bit.api/v0/server/route/parking/interfaces"
+	"github.com/gorilla/mux"
+)
+
+// GET endpoint request for retrieving the current inferred floor of each vehicle node.
+// Returns a GetParkingResponse.
+func getParkingHandler(w http.ResponseWriter, r *http.Request) {
+	nodeIds, err := GetVehicleNodeIds()
+	if err != nil {
+		log.Printf("/parking: %v\n", err)
+		http.Error(w, "failed to query vehicle nodes", http.StatusInternalServerError)
+		return
+	}
+
+	resp := interfaces.GetParkingResponse{
+		Vehicles: []interfaces.VehicleParking{},
+	}
+	for _, nodeId := range nodeIds {
+		vehicle := interfaces.VehicleParking{
+			NodeId: nodeId,
+		}
+
+		if session, err := GetOpenSession(nodeId); err != nil {
+			log.Printf("/parking: %v\n", err)
+		} else {
+			vehicle.Session = session
+		}
+
+		if barEntry, err := GetLastKnownBarometerEntry(nodeId); err != nil {
+			vehicle.Error = err.Error()
+		} else {
+			vehicle.Barometer = barEntry
+			if vehicle.Estimate, err = GetParkingFloor(barEntry); err != nil {
+				vehicle.Error = err.Error()
+			}
+		}
+		resp.Vehicles = append(resp.Vehicles, vehicle)
+	}
+
+	respBody, err := json.Marshal(resp)
+	if err != nil {
+		log.Printf("/parking: failed to serialize response: %v\n", err)
+		http.Error(w, "failed to serialize response body", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	w.Write(respBody)
+}
+
+// GET endpoint request for retrieving the garages along with their calibrated
+// floor bands.
+// Expects the Request to be of type ListBandsRequest.
+// Returns a ListBandsResponse.
+func getBandsHandler(w http.ResponseWriter, r *http.Request) {
+	bodyBuffer, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "failed to parse the request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	req := interfaces.ListBandsRequest{}
+	if err := json.Unmarshal(bodyBuffer, &req); err != nil {
+		log.Printf("/parking/bands: failed to de-serialize request: %v\n", err)
+		http.Error(w, "failed to de-serialize request body", http.StatusBadRequest)
+		return
+	}
+
+	garages, err := GetGarages()
+	if err != nil {
+		log.Printf("/parking/bands: %v\n", err)
+		http.Error(w, "failed to query garages", http.StatusInternalServerError)
+		return
+	}
+
+	bands, err := GetFloorBands(req.NodeId)
+	if err != nil {
+		log.Printf("/parking/bands: %v\n", err)
+		http.Error(w, "failed to query floor bands", http.StatusInternalServerError)
+		return
+	}
+
+	respBody, err := json.Marshal(interfaces.ListBandsResponse{
+		Garages: garages,
+		Bands:   bands,
+	})
+	if err != nil {
+		log.Printf("/parking/bands: failed to serialize response: %v\n", err)
+		http.Error(w, "failed to serialize response body", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	w.Write(respBody)
+}
+
+// GET endpoint request for retrieving the parking session history.
+// Expects the Request to be of type ListSessionsRequest.
+// Returns a ListSessionsResponse.
+func getSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	bodyBuffer, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "failed to parse the request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	req := interfaces.ListSessionsRequest{}
+	if err := json.Unmarshal(bodyBuffer, &req); err != nil {
+		log.Printf("/parking/sessions: failed to de-serialize request: %v\n", err)
+		http.Error(w, "failed to de-serialize request body", http.StatusBadRequest)
+		return
+	}
+
+	// Set default limit.
+	if req.Limit == 0 {
+		req.Limit = 10
+	}
+
+	sessions, err := GetSessions(req.NodeId, int(req.Limit))
+	if err != nil {
+		log.Printf("/parking/sessions: %v\n", err)
+		http.Error(w, "failed to query parking sessions", http.StatusInternalServerError)
+		return
+	}
+
+	respBody, err := json.Marshal(interfaces.ListSessionsResponse{
+		Sessions: sessions,
+	})
+	if err != nil {
+		log.Printf("/parking/sessions: failed to serialize response: %v\n", err)
+		http.Error(w, "failed to serialize response body", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	w.Write(respBody)
+}
+
+// Creates all routes for the Parking endpoint.
+func CreateRoutes(r *mux.Router) {
+	r.HandleFunc("", getParkingHandler).Methods("GET")
+	r.HandleFunc("/bands", getBandsHandler).Methods("GET")
+	r.HandleFunc("/sessions", getSessionsHandler).Methods("GET")
+}
@@ -0,0 +1,226 @@
+package parking
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"4bit.api/v0/database"
+	"github.com/go-pg/pg/v10"
+)
+
+const (
+	// Window of barometer readings checked for the altitude stabilizing.
+	SESSION_STABLE_WINDOW = 3 * time.Minute
+
+	// Least number of readings within the window to determine stability.
+	SESSION_MIN_READINGS = 3
+
+	// Largest altitude variation within the window for a vehicle to be considered parked.
+	SESSION_STABLE_ALTITUDE_RANGE = 1.5
+
+	// Load voltage below which a vehicle node is considered powered down.
+	SESSION_POWER_DOWN_VOLTAGE = 1.0
+)
+
+// Reasons for which a parking session was started.
+const (
+	SESSION_REASON_STABILIZED = "stabilized"
+	SESSION_REASON_POWER_DOWN = "power_down"
+)
+
+// Checks whether a node is a vehicle, being a node with calibrated floors.
+func isVehicleNode(nodeId uint64) bool {
+	// Floor bands are only stored in postgres.
+	db := database.DbInstance
+	if db == nil {
+		return false
+	}
+
+	exists, err := db.Model((*database.ParkingFloorBand)(nil)).Where("node_id = ?", nodeId).Exists()
+	if err != nil {
+		log.Printf("Failed to check whether node[%d] is a vehicle: %v", nodeId, err)
+	}
+	return exists
+}
+
+// Checks whether a node reported being powered within the stability window,
+// in which case the vehicle is assumed to still be driving.
+func isRecentlyPowered(nodeId uint64, at time.Time) bool {
+	db := database.DbInstance
+	powerState := database.NodePowerState{}
+	if err := db.Model(&powerState).
+		Where("node_id = ?", nodeId).
+		Where("timestamp BETWEEN ? AND ?", at.Add(-SESSION_STABLE_WINDOW), at).
+		Order("timestamp DESC").
+		First(); err != nil {
+		return false
+	}
+	return powerState.LoadVoltage >= SESSION_POWER_DOWN_VOLTAGE
+}
+
+// Retrieves the ongoing parking session of a node, returning nil if there is none.
+func GetOpenSession(nodeId uint64) (*database.ParkingSession, error) {
+	db := database.DbInstance
+	session := database.ParkingSession{}
+	if err := db.Model(&session).
+		Relation("Garage").
+		Where("parking_session.node_id = ?", nodeId).
+		Where("parking_session.ended_at IS NULL").
+		Order("parking_session.started_at DESC").
+		First(); err != nil {
+		if err == pg.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to query open session for node %d: %v", nodeId, err)
+	}
+	return &session, nil
+}
+
+// Retrieves the latest parking sessions, optionally filtered on a node.
+func GetSessions(nodeId uint64, limit int) ([]database.ParkingSession, error) {
+	db := database.DbInstance
+	sessions := []database.ParkingSession{}
+	query := db.Model(&sessions).
+		Relation("Garage").
+		Order("parking_session.started_at DESC").
+		Limit(limit)
+	if nodeId != 0 {
+		query = query.Where("parking_session.node_id = ?", nodeId)
+	}
+
+	if err := query.Select(); err != nil {
+		return nil, fmt.Errorf("failed to query parking sessions: %v", err)
+	}
+	return sessions, nil
+}
+
+// Starts a new parking session for the barometer entry's node, inferring its floor.
+func startSession(barEntry *database.NodeBarometerState, startedAt time.Time, reason string) {
+	session := database.ParkingSession{
+		StartedAt:   startedAt,
+		StartReason: reason,
+		Altitude:    barEntry.Altitude,
+		NodeId:      barEntry.NodeId,
+	}
+	session.Timestamp = time.Now().UTC()
+
+	if estimate, err := GetParkingFloor(barEntry); err != nil {
+		log.Printf("Parking session of node[%d] started on an unknown floor: %v", barEntry.NodeId, err)
+	} else {
+		floor := estimate.Band.Floor
+		session.Floor = &floor
+		session.Confidence = estimate.Confidence
+		session.GarageId = estimate.Band.GarageId
+	}
+
+	db := database.DbInstance
+	if _, err := db.Model(&session).Insert(); err != nil {
+		log.Printf("Failed to start parking session for node[%d]: %v", barEntry.NodeId, err)
+		return
+	}
+	log.Printf("Parking session[%d] started for node[%d]: %s", session.Id, session.NodeId, reason)
+}
+
+// Ends an ongoing parking session.
+func endSession(session *database.ParkingSession, endedAt time.Time) {
+	session.EndedAt = &endedAt
+
+	db := database.DbInstance
+	if _, err := db.Model(session).Column("ended_at").WherePK().Update(); err != nil {
+		log.Printf("Failed to end parking session[%d]: %v", session.Id, err)
+		return
+	}
+	log.Printf("Parking session[%d] ended for node[%d]", session.Id, session.NodeId)
+}
+
+// Detects parking events from a new barometer entry of a vehicle node. A session
+// starts once the altitude stabilizes while the vehicle is not powered, and ends
+// once the altitude changes again.
+func ObserveBarometerEntry(barEntry *database.NodeBarometerState) {
+	if !isVehicleNode(barEntry.NodeId) {
+		return
+	}
+
+	db := database.DbInstance
+	barometerStates := []database.NodeBarometerState{}
+	if err := db.Model(&barometerStates).
+		Where("node_id = ?", barEntry.NodeId).
+		Where("timestamp BETWEEN ? AND ?", barEntry.Timestamp.Add(-SESSION_STABLE_WINDOW), barEntry.Timestamp).
+		Order("timestamp ASC").
+		Select(); err != nil {
+		log.Printf("Failed to query barometer window for node[%d]: %v", barEntry.NodeId, err)
+		return
+	}
+	if len(barometerStates) < SESSION_MIN_READINGS {
+		return
+	}
+
+	minAltitude, maxAltitude := barometerStates[0].Altitude, barometerStates[0].Altitude
+	for _, state := range barometerStates {
+		if state.Altitude < minAltitude {
+			minAltitude = state.Altitude
+		}
+		if state.Altitude > maxAltitude {
+			maxAltitude = state.Altitude
+		}
+	}
+	isStable := maxAltitude-minAltitude <= SESSION_STABLE_ALTITUDE_RANGE
+
+	session, err := GetOpenSession(barEntry.NodeId)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	if session == nil && isStable && !isRecentlyPowered(barEntry.NodeId, barEntry.Timestamp) {
+		startSession(barEntry, barometerStates[0].Timestamp, SESSION_REASON_STABILIZED)
+	} else if session != nil && !isStable {
+		endSession(session, barEntry.Timestamp)
+	}
+}
+
+// Detects parking events from a new power entry of a vehicle node. A session
+// starts once the node powers down and ends once it powers back up.
+func ObservePowerEntry(powerEntry *database.NodePowerState) {
+	if !isVehicleNode(powerEntry.NodeId) {
+		return
+	}
+
+	db := database.DbInstance
+	previous := database.NodePowerState{}
+	if err := db.Model(&previous).
+		Where("node_id = ?", powerEntry.NodeId).
+		Where("id != ?", powerEntry.Id).
+		Where("timestamp <= ?", powerEntry.Timestamp).
+		Order("timestamp DESC").
+		First(); err != nil {
+		if err != pg.ErrNoRows {
+			log.Printf("Failed to query previous power entry for node[%d]: %v", powerEntry.NodeId, err)
+		}
+		return
+	}
+
+	wasPowered := previous.LoadVoltage >= SESSION_POWER_DOWN_VOLTAGE
+	isPowered := powerEntry.LoadVoltage >= SESSION_POWER_DOWN_VOLTAGE
+	if wasPowered == isPowered {
+		return
+	}
+
+	session, err := GetOpenSession(powerEntry.NodeId)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	if session == nil && !isPowered {
+		barEntry, err := GetLastKnownBarometerEntry(powerEntry.NodeId)
+		if err != nil {
+			log.Printf("Failed to start parking session on power down: %v", err)
+			return
+		}
+		startSession(barEntry, powerEntry.Timestamp, SESSION_REASON_POWER_DOWN)
+	} else if session != nil && isPowered {
+		endSession(session, powerEntry.Timestamp)
+	}
+}
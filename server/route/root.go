@@ -3,8 +3,13 @@ package route
 import (
 	"context"
 
+	"4bit.api/v0/server/middleware"
+	"4bit.api/v0/server/route/admin"
+	"4bit.api/v0/server/route/alert"
 	"4bit.api/v0/server/route/camera"
 	"4bit.api/v0/server/route/node"
+	"4bit.api/v0/server/route/notify"
+	"4bit.api/v0/server/route/parking"
 	"4bit.api/v0/server/route/ping"
 	"4bit.api/v0/server/route/telegram"
 	mux "github.com/gorilla/mux"
@@ -19,15 +24,44 @@ func InitRootRoute(ctx *context.Context, r *mux.Router) error {
 	telegramMessageSubrouter := r.PathPrefix("/telegram").Subrouter()
 	telegram.CreateRoute(ctx, telegramMessageSubrouter)
 
+	// Notify endpoint.
+	notifySubrouter := r.PathPrefix("/notify").Subrouter()
+	notifySubrouter.Use(middleware.RequirePostgres)
+	notify.CreateRoutes(ctx, notifySubrouter)
+
 	// Node endpoint.
 	nodeSubrouter := r.PathPrefix("/node").Subrouter()
 	node.CreateRoutes(ctx, nodeSubrouter)
 
+	// Parking endpoint.
+	parkingSubrouter := r.PathPrefix("/parking").Subrouter()
+	parkingSubrouter.Use(middleware.RequirePostgres)
+	parking.CreateRoutes(parkingSubrouter)
+
 	// Camera endpoint.
 	cameraSubrouter := r.PathPrefix("/camera").Subrouter()
 	if err := camera.CreateRoutes(ctx, cameraSubrouter); err != nil {
 		return err
 	}
 
+	// Alert endpoint.
+	alertSubrouter := r.PathPrefix("/alerts").Subrouter()
+	alertSubrouter.Use(middleware.RequirePostgres)
+	if err := alert.CreateRoutes(ctx, alertSubrouter); err != nil {
+		return err
+	}
+
+	// Admin endpoint.
+	adminSubrouter := r.PathPrefix("/admin").Subrouter()
+	adminSubrouter.Use(middleware.RequirePostgres)
+	admin.CreateRoutes(ctx, adminSubrouter)
+
 	return nil
 }
+
+// InitWebhookRoute adds the telegram webhook endpoint, served separately from
+// the root routes.
+func InitWebhookRoute(ctx *context.Context, r *mux.Router) {
+	telegramWebhookSubrouter := r.PathPrefix("/telegram").Subrouter()
+	telegram.CreateWebhookRoute(ctx, telegramWebhookSubrouter)
+}
@@ -0,0 +1,37 @@
+package route
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"4bit.api/v0/database"
+	"4bit.api/v0/database/sqlite"
+	"github.com/gorilla/mux"
+)
+
+func TestInitRootRouteWithSqlite(t *testing.T) {
+	store, err := sqlite.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	database.StoreInstance = store
+	database.DbInstance = nil
+
+	ctx := context.Background()
+	r := mux.NewRouter()
+	if err := InitRootRoute(&ctx, r); err != nil {
+		t.Fatalf("failed to init routes: %v", err)
+	}
+
+	// Subsystems only stored in postgres are rejected rather than served.
+	for _, path := range []string{"/alerts", "/parking", "/admin/retention"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("expected %d from '%s', got %d", http.StatusServiceUnavailable, path, w.Code)
+		}
+	}
+}
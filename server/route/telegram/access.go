@@ -0,0 +1,220 @@
+package telegram
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"4bit.api/v0/database"
+	"github.com/go-pg/pg/v10"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Users configured as admins through TELEGRAM_ADMIN_IDS, regardless of the
+// persisted allowlist.
+var ADMIN_IDS = map[int64]bool{}
+
+// parseAdminIds parses a comma-separated list of telegram user IDs.
+func parseAdminIds(value string) (map[int64]bool, error) {
+	adminIds := map[int64]bool{}
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		userId, err := strconv.ParseInt(field, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid user id '%s'", field)
+		}
+		adminIds[userId] = true
+	}
+	return adminIds, nil
+}
+
+// getAccess retrieves the access granted to a subject, returning nil if there is none.
+func getAccess(kind string, subjectId int64) (*database.TelegramAccess, error) {
+	db := database.DbInstance
+	if db == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	access := database.TelegramAccess{}
+	if err := db.Model(&access).
+		Where("kind = ?", kind).
+		Where("subject_id = ?", subjectId).
+		First(); err != nil {
+		if err == pg.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to query %s[%d] access: %v", kind, subjectId, err)
+	}
+	return &access, nil
+}
+
+// isAdmin checks whether a user may manage the allowlist.
+func isAdmin(user *tgbotapi.User) bool {
+	if user == nil {
+		return false
+	}
+	if ADMIN_IDS[user.ID] {
+		return true
+	}
+
+	access, err := getAccess(database.TELEGRAM_SUBJECT_USER, user.ID)
+	if err != nil {
+		log.Println(err)
+		return false
+	}
+	return access != nil && access.Role == database.TELEGRAM_ROLE_ADMIN
+}
+
+// isAuthorized checks whether the message's sender or chat was granted access
+// to the bot.
+func isAuthorized(msg *tgbotapi.Message) bool {
+	if isAdmin(msg.From) {
+		return true
+	}
+
+	subjects := map[string]int64{database.TELEGRAM_SUBJECT_CHAT: msg.Chat.ID}
+	if msg.From != nil {
+		subjects[database.TELEGRAM_SUBJECT_USER] = msg.From.ID
+	}
+	for kind, subjectId := range subjects {
+		access, err := getAccess(kind, subjectId)
+		if err != nil {
+			log.Println(err)
+			return false
+		}
+		if access != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// rejectUnauthorized logs a message from an unauthorized sender along with the
+// IDs an admin may allow, without replying to it.
+func rejectUnauthorized(msg *tgbotapi.Message) {
+	userId := int64(0)
+	if msg.From != nil {
+		userId = msg.From.ID
+	}
+	log.Printf("[!] Bot '%s' rejected message from unauthorized user '%s'[%d] in chat[%d]: %s\n", BOT.UserName(), msg.From.String(), userId, msg.Chat.ID, msg.Text)
+}
+
+// parseAccessSubject extracts the subject of an access command from its
+// "user=<id>" or "chat=<id>" argument. A chat of "here" refers to the current chat.
+func parseAccessSubject(msg *tgbotapi.Message, cmd *ParsedCommand) (string, int64, error) {
+	userArg, hasUser := cmd.KwArgs[database.TELEGRAM_SUBJECT_USER]
+	chatArg, hasChat := cmd.KwArgs[database.TELEGRAM_SUBJECT_CHAT]
+	if hasUser == hasChat {
+		return "", 0, fmt.Errorf("expected either a user or a chat")
+	}
+
+	if hasChat {
+		if chatArg == "here" {
+			return database.TELEGRAM_SUBJECT_CHAT, msg.Chat.ID, nil
+		}
+		chatId, err := strconv.ParseInt(chatArg, 10, 64)
+		if err != nil {
+			return "", 0, fmt.Errorf("invalid chat id '%s'", chatArg)
+		}
+		return database.TELEGRAM_SUBJECT_CHAT, chatId, nil
+	}
+
+	userId, err := strconv.ParseInt(userArg, 10, 64)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid user id '%s'", userArg)
+	}
+	return database.TELEGRAM_SUBJECT_USER, userId, nil
+}
+
+// Grants a user or chat access to the bot.
+func handleAllowCommand(msg *tgbotapi.Message, cmd *ParsedCommand) tgbotapi.Chattable {
+	kind, subjectId, err := parseAccessSubject(msg, cmd)
+	if err != nil {
+		return usageReply(msg, "allow", err.Error())
+	}
+
+	role := cmd.KwArg("role", database.TELEGRAM_ROLE_MEMBER)
+	if role != database.TELEGRAM_ROLE_MEMBER && role != database.TELEGRAM_ROLE_ADMIN {
+		return usageReply(msg, "allow", fmt.Sprintf("Unknown role '%s'.", role))
+	}
+	if role == database.TELEGRAM_ROLE_ADMIN && kind != database.TELEGRAM_SUBJECT_USER {
+		return usageReply(msg, "allow", "Only users can be admins.")
+	}
+
+	access := database.TelegramAccess{
+		Kind:      kind,
+		SubjectId: subjectId,
+		Role:      role,
+		GrantedBy: msg.From.ID,
+	}
+	access.Timestamp = time.Now().UTC()
+
+	db := database.DbInstance
+	if _, err := db.Model(&access).
+		OnConflict("(kind, subject_id) DO UPDATE").
+		Set("role = EXCLUDED.role, granted_by = EXCLUDED.granted_by").
+		Insert(); err != nil {
+		log.Printf("Failed to allow %s[%d]: %v\n", kind, subjectId, err)
+		return tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("internal failure: %v", err))
+	}
+	log.Printf("User[%d] allowed %s[%d] as %s\n", msg.From.ID, kind, subjectId, role)
+
+	return tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("Allowed %s %d as %s.", kind, subjectId, role))
+}
+
+// Revokes a user or chat's access to the bot.
+func handleDenyCommand(msg *tgbotapi.Message, cmd *ParsedCommand) tgbotapi.Chattable {
+	kind, subjectId, err := parseAccessSubject(msg, cmd)
+	if err != nil {
+		return usageReply(msg, "deny", err.Error())
+	}
+	if kind == database.TELEGRAM_SUBJECT_USER && ADMIN_IDS[subjectId] {
+		return tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("User %d is configured as an admin through TELEGRAM_ADMIN_IDS.", subjectId))
+	}
+
+	db := database.DbInstance
+	res, err := db.Model((*database.TelegramAccess)(nil)).
+		Where("kind = ?", kind).
+		Where("subject_id = ?", subjectId).
+		Delete()
+	if err != nil {
+		log.Printf("Failed to deny %s[%d]: %v\n", kind, subjectId, err)
+		return tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("internal failure: %v", err))
+	}
+	if res.RowsAffected() == 0 {
+		return tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("No access granted to %s %d.", kind, subjectId))
+	}
+	log.Printf("User[%d] denied %s[%d]\n", msg.From.ID, kind, subjectId)
+
+	return tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("Denied %s %d.", kind, subjectId))
+}
+
+// Constructs a reply listing the users and chats allowed to use the bot.
+func handleAclCommand(msg *tgbotapi.Message, cmd *ParsedCommand) tgbotapi.Chattable {
+	db := database.DbInstance
+	accessList := []database.TelegramAccess{}
+	if err := db.Model(&accessList).
+		Order("kind ASC", "subject_id ASC").
+		Select(); err != nil {
+		log.Printf("Failed to query telegram access list: %v\n", err)
+		return tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("internal failure: %v", err))
+	}
+
+	replyMsg := ""
+	for userId := range ADMIN_IDS {
+		replyMsg += fmt.Sprintf("user %d: admin (configured)\n", userId)
+	}
+	for _, access := range accessList {
+		replyMsg += fmt.Sprintf("%s %d: %s, granted by %d on %v\n", access.Kind, access.SubjectId, access.Role, access.GrantedBy, access.Timestamp.Local())
+	}
+	if replyMsg == "" {
+		replyMsg = "No users or chats allowed."
+	}
+	return tgbotapi.NewMessage(msg.Chat.ID, replyMsg)
+}
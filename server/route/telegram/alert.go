@@ -0,0 +1,49 @@
+package telegram
+
+import (
+	"fmt"
+	"log"
+
+	"4bit.api/v0/database"
+	"4bit.api/v0/pkg/alert"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// sendAlertEvent is an alert handler which delivers alert transitions to the
+// rule's chat, falling back on the alert chat. Rules with a notification route
+// are delivered through their route instead.
+func sendAlertEvent(rule database.AlertRule, event database.AlertEvent) {
+	if rule.Route != "" {
+		return
+	}
+
+	chatId := rule.ChatId
+	if chatId == 0 {
+		chatId = ALERT_CHAT_ID
+	}
+	if chatId == 0 {
+		log.Printf("No chat to deliver alert rule[%d] to\n", rule.Id)
+		return
+	}
+
+	notifier := &TelegramNotifier{ChatId: chatId}
+	if err := notifier.Notify(alert.FormatEvent(rule, event)); err != nil {
+		log.Printf("Failed to send alert for rule[%d]: %v\n", rule.Id, err)
+	}
+}
+
+// Constructs a reply listing all active alerts.
+func handleAlertsCommand(msg *tgbotapi.Message, cmd *ParsedCommand) tgbotapi.Chattable {
+	activeAlerts := alert.GetActiveAlerts()
+	if len(activeAlerts) == 0 {
+		return tgbotapi.NewMessage(msg.Chat.ID, "No active alerts.")
+	}
+
+	replyMsg := ""
+	for _, activeAlert := range activeAlerts {
+		replyMsg += fmt.Sprintf("[%s] Node[%d] since %v\n", activeAlert.State, activeAlert.NodeId, activeAlert.Since.Local())
+		replyMsg += fmt.Sprintf("  %s\n", alert.FormatRule(activeAlert.Rule))
+		replyMsg += fmt.Sprintf("  Value: %.2f\n", activeAlert.Value)
+	}
+	return tgbotapi.NewMessage(msg.Chat.ID, replyMsg)
+}
@@ -3,15 +3,21 @@ package telegram
 import (
 	"fmt"
 	"log"
+	"sort"
 	"strings"
 
-	"4bit.api/v0/pkg/camera"
-	"4bit.api/v0/server/route/parking"
+	"4bit.api/v0/database"
+	"4bit.api/v0/pkg/schedule"
+	"4bit.api/v0/server/route/node"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
 type BotCommand struct {
-	MethodHandler func(*tgbotapi.Message) tgbotapi.Chattable
+	Usage         string // Arguments accepted by the command, ie. "<floor> [garage]".
+	Description   string
+	AdminOnly     bool // Restricts the command to admins.
+	PostgresOnly  bool // Requires the postgres storage backend.
+	MethodHandler func(*tgbotapi.Message, *ParsedCommand) tgbotapi.Chattable
 }
 
 var (
@@ -19,6 +25,45 @@ var (
 	BotCommandMp   map[string]BotCommand
 )
 
+// Constructs the usage text of a registered command.
+func commandUsage(name string) string {
+	botCmd, ok := BotCommandMp[name]
+	if !ok {
+		return ""
+	}
+
+	usage := "/" + name
+	if botCmd.Usage != "" {
+		usage += " " + botCmd.Usage
+	}
+	return usage
+}
+
+// Constructs a reply with the usage of a command, along with the reason the
+// command was rejected.
+func usageReply(msg *tgbotapi.Message, name string, reason string) tgbotapi.Chattable {
+	return tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("%s\nusage: %s", reason, commandUsage(name)))
+}
+
+// Constructs the help menu from the registered commands.
+func helpMessage() string {
+	names := []string{}
+	for name := range BotCommandMp {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	helpMessage := "Bot Commands are prefixed with '/'. Supported Commands:\n"
+	for _, name := range names {
+		description := BotCommandMp[name].Description
+		if BotCommandMp[name].AdminOnly {
+			description += " (admin)"
+		}
+		helpMessage += fmt.Sprintf("%s - %s\n", commandUsage(name), description)
+	}
+	return helpMessage
+}
+
 // Sets up the BotCommandMap with supported commands.
 func setupCommands() error {
 	if len(BotCommandMp) > 0 {
@@ -27,121 +72,195 @@ func setupCommands() error {
 
 	BotCommandMp = map[string]BotCommand{
 		"help": {
-			MethodHandler: func(msg *tgbotapi.Message) tgbotapi.Chattable {
-				helpMessage := "Bot Commands are prefixed with '/'. Supported Commands:\n"
-				helpMessage += "/help - Prints help menu\n"
-				helpMessage += "/parking - Prints the last known altitude of vehicle\n"
-				helpMessage += "/snap - Takes snapshot of existing cameras"
-				return tgbotapi.NewMessage(msg.Chat.ID, helpMessage)
+			Description: "Prints help menu",
+			MethodHandler: func(msg *tgbotapi.Message, cmd *ParsedCommand) tgbotapi.Chattable {
+				return tgbotapi.NewMessage(msg.Chat.ID, helpMessage())
 			},
 		},
-		"parking": {
-			MethodHandler: func(msg *tgbotapi.Message) tgbotapi.Chattable {
-				// TODO: Handle various NodeIDs.
-				nodeId := uint64(1)
-				barEntry, err := parking.GetLastKnownBarometerEntry(nodeId)
+		"alerts": {
+			Description:   "Prints active alerts",
+			PostgresOnly:  true,
+			MethodHandler: handleAlertsCommand,
+		},
+		"nodes": {
+			Description: "Prints the liveness of all nodes",
+			MethodHandler: func(msg *tgbotapi.Message, cmd *ParsedCommand) tgbotapi.Chattable {
+				nodesLiveness, err := node.GetNodesLiveness()
 				if err != nil {
 					return tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("internal failure: %v", err))
 				}
 
-				// Map the altitude to a known parking floor.
-				parkingFloor, err := parking.GetParkingFloor(barEntry.Altitude)
-				parkingFloorMessage := ""
-				if err != nil {
-					parkingFloor = 0
-					parkingFloorMessage = fmt.Sprintf(" - %v", err)
+				replyMsg := ""
+				for _, liveness := range nodesLiveness {
+					replyMsg += formatNodeLiveness(liveness)
 				}
-
-				// Construct nice message.
-				replyMsg := "%v:\n"
-				replyMsg += "  Entry ID: %d\n"
-				replyMsg += "  Node ID: %d\n"
-				replyMsg += "  Altitude: %.2f\n"
-				replyMsg += "  Floor: %d%s"
-
-				return tgbotapi.NewMessage(
-					msg.Chat.ID,
-					fmt.Sprintf(
-						replyMsg,
-						barEntry.Timestamp,
-						barEntry.Id,
-						nodeId,
-						barEntry.Altitude,
-						parkingFloor,
-						parkingFloorMessage,
-					),
-				)
+				if replyMsg == "" {
+					replyMsg = "No nodes found."
+				}
+				return tgbotapi.NewMessage(msg.Chat.ID, replyMsg)
 			},
 		},
+		"parking": {
+			Usage:         "[history] [node id]",
+			Description:   "Prints the last known altitude and floor of a vehicle, or its latest parking sessions. Lists vehicles without a node id",
+			PostgresOnly:  true,
+			MethodHandler: handleParkingCommand,
+		},
+		"calibrate": {
+			Usage:         "[floor] [garage] [node=<id>]",
+			Description:   "Calibrates the vehicle's current altitude as the given floor. Lists calibrated floors without a floor",
+			PostgresOnly:  true,
+			MethodHandler: handleCalibrateCommand,
+		},
+		"reference": {
+			Usage:         "<node id> [garage]",
+			Description:   "Sets the garage's reference barometer node. A node id of 0 removes it",
+			PostgresOnly:  true,
+			MethodHandler: handleReferenceCommand,
+		},
+		"allow": {
+			Usage:         "user=<id> | chat=<id|here> [role=admin]",
+			Description:   "Allows a user or chat to use the bot",
+			AdminOnly:     true,
+			PostgresOnly:  true,
+			MethodHandler: handleAllowCommand,
+		},
+		"deny": {
+			Usage:         "user=<id> | chat=<id|here>",
+			Description:   "Revokes a user or chat's access to the bot",
+			AdminOnly:     true,
+			PostgresOnly:  true,
+			MethodHandler: handleDenyCommand,
+		},
+		"acl": {
+			Description:   "Lists the users and chats allowed to use the bot",
+			AdminOnly:     true,
+			PostgresOnly:  true,
+			MethodHandler: handleAclCommand,
+		},
+		"schedule": {
+			Usage:         "[list | add <report> <cron> [node=<id>] [route=<name>] | remove <id> | run <id>]",
+			Description:   "Manages scheduled reports (" + strings.Join(schedule.ReportNames(), ", ") + "). Adding, removing and running schedules is restricted to admins",
+			PostgresOnly:  true,
+			MethodHandler: handleScheduleCommand,
+		},
 		"snap": {
-			MethodHandler: func(msg *tgbotapi.Message) tgbotapi.Chattable {
-				camPoller := camera.CameraPollerInstance
-				images := []interface{}{}
-				snapshotInfo := ""
-
-				for _, entry := range camPoller.PollWorkers {
-					snapshot := entry.GetSnapshot()
-					image := tgbotapi.NewInputMediaPhoto(tgbotapi.FileBytes{
-						Name:  entry.Name,
-						Bytes: snapshot.ImageData,
-					})
-					images = append(images, image)
-					snapshotInfo += fmt.Sprintf("Node[%s]: %v\n", entry.Name, snapshot.LastUpdated)
-				}
-				BOT.Send(tgbotapi.NewMediaGroup(msg.Chat.ID, images))
-				return tgbotapi.NewMessage(
-					msg.Chat.ID,
-					snapshotInfo+"Done.",
-				)
-			},
+			Usage:         "[camera name | all | collage] [id=<id>] [group=<name>] [tag=<tag>]",
+			Description:   "Takes a snapshot of the given camera, of all cameras, or a collage of all cameras, optionally limited to a group. Lists cameras without a name",
+			MethodHandler: handleSnapCommand,
 		},
 	}
 
+	// Register the commands with telegram for auto-completion.
+	commands := []tgbotapi.BotCommand{}
+	for name, botCmd := range BotCommandMp {
+		commands = append(commands, tgbotapi.BotCommand{
+			Command:     name,
+			Description: botCmd.Description,
+		})
+	}
+	sort.Slice(commands, func(i, j int) bool { return commands[i].Command < commands[j].Command })
+	if _, err := BOT.Request(tgbotapi.NewSetMyCommands(commands...)); err != nil {
+		log.Printf("Failed to register bot commands with telegram: %v\n", err)
+	}
+
 	return nil
 }
 
 func StartBot() {
 	// Ensure a single instance of the bot is running.
 	if BOT_IS_RUNNING {
-		log.Printf("Bot '%s' is already running\n", BOT.Self.UserName)
+		log.Printf("Bot '%s' is already running\n", BOT.UserName())
 		return
 	}
 	BOT_IS_RUNNING = true
-	log.Printf("Starting Bot '%s'\n", BOT.Self.UserName)
+	log.Printf("Starting Bot '%s'\n", BOT.UserName())
 
 	if err := setupCommands(); err != nil {
 		log.Printf("Failed to setup bot commands: %v\n", err)
 	}
 
 	// Listen.
-	u := tgbotapi.NewUpdate(0)
-	u.Timeout = 60
+	var updates tgbotapi.UpdatesChannel
+	if IsWebhookMode() {
+		log.Printf("Bot '%s' receiving updates through webhook\n", BOT.UserName())
+		updates = webhookUpdates
+	} else {
+		// Long polling is rejected while a webhook is set.
+		if _, err := BOT.Request(tgbotapi.DeleteWebhookConfig{}); err != nil {
+			log.Printf("Failed to delete bot webhook: %v\n", err)
+		}
+
+		u := tgbotapi.NewUpdate(0)
+		u.Timeout = 60
+		updates = BOT.GetUpdatesChan(u)
+	}
 
-	updates := BOT.GetUpdatesChan(u)
 	for update := range updates {
-		if update.Message != nil {
-			log.Printf("[+] Bot '%s' New Message from '%s': %s\n", BOT.Self.UserName, update.Message.From.String(), update.Message.Text)
-
-			// Handle supported commands.
-			if strings.HasPrefix(update.Message.Text, "/") {
-				userCmd := strings.TrimSpace(update.Message.Text[1:])
-				log.Printf("[+] Handling user command '%s'\n", userCmd)
-
-				// Obtain the respective bot command handler.
-				if botCmd, ok := BotCommandMp[userCmd]; ok {
-					botReplyMsg := botCmd.MethodHandler(update.Message)
-					BOT.Send(botReplyMsg)
-					continue
-				}
+		handleUpdate(update)
+	}
+}
 
-				// Unknown command.
-				helpReply := BotCommandMp["help"].MethodHandler(update.Message)
-				BOT.Send(tgbotapi.NewMessage(
-					update.Message.Chat.ID,
-					fmt.Sprintf("Unknown command '%s'", userCmd),
-				))
-				BOT.Send(helpReply)
+// handleUpdate handles an incoming update, regardless of whether it was polled
+// or delivered through the webhook.
+func handleUpdate(update tgbotapi.Update) {
+	if update.CallbackQuery != nil {
+		handleCallbackQuery(update.CallbackQuery)
+		return
+	}
+
+	if update.Message != nil {
+		log.Printf("[+] Bot '%s' New Message from '%s': %s\n", BOT.UserName(), update.Message.From.String(), update.Message.Text)
+		handleCommand(update.Message)
+	}
+}
+
+// handleCommand dispatches a message's supported command to its handler, replying
+// with the handler's result. Messages which aren't commands are ignored.
+func handleCommand(msg *tgbotapi.Message) {
+	if !strings.HasPrefix(msg.Text, "/") {
+		return
+	}
+
+	// Only serve allowed users & chats, without revealing the bot to others.
+	if !isAuthorized(msg) {
+		rejectUnauthorized(msg)
+		return
+	}
+
+	userCmd, err := ParseCommand(msg.Text, BOT.UserName())
+	if err == ErrCommandNotAddressed {
+		return
+	} else if err != nil {
+		BOT.Send(tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("Invalid command: %v", err)))
+		return
+	}
+	log.Printf("[+] Handling user command '%s' with args %v %v\n", userCmd.Name, userCmd.Args, userCmd.KwArgs)
+
+	// Obtain the respective bot command handler.
+	if botCmd, ok := BotCommandMp[userCmd.Name]; ok {
+		if botCmd.AdminOnly && !isAdmin(msg.From) {
+			log.Printf("[!] Rejected admin command '%s' from user '%s'\n", userCmd.Name, msg.From.String())
+			BOT.Send(tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("Command '%s' is restricted to admins.", userCmd.Name)))
+			return
+		}
+
+		if botCmd.PostgresOnly {
+			if err := database.RequirePostgres(); err != nil {
+				BOT.Send(tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("Command '%s' %v.", userCmd.Name, err)))
+				return
 			}
 		}
+
+		botReplyMsg := botCmd.MethodHandler(msg, userCmd)
+		BOT.Send(botReplyMsg)
+		return
 	}
+
+	// Unknown command.
+	BOT.Send(tgbotapi.NewMessage(
+		msg.Chat.ID,
+		fmt.Sprintf("Unknown command '%s'", userCmd.Name),
+	))
+	BOT.Send(tgbotapi.NewMessage(msg.Chat.ID, helpMessage()))
 }
@@ -0,0 +1,130 @@
+package telegram
+
+import (
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const testAdminId = 42
+
+var (
+	fakeBot     *FakeBot
+	fakeBotOnce sync.Once
+
+	// Last chat used by a test, so each test inspects only its own replies.
+	lastChatId int64 = 1000
+)
+
+// initFakeBot sets up the bot once for the package's tests, backed by a FakeBot
+// with a single admin.
+func initFakeBot(t *testing.T) *FakeBot {
+	t.Helper()
+	fakeBotOnce.Do(func() {
+		os.Setenv("TELEGRAM_ADMIN_IDS", "42")
+		os.Unsetenv("TELEGRAM_WEBHOOK_URL")
+		os.Unsetenv("TELEGRAM_ALERT_CHAT_ID")
+
+		fakeBot = NewFakeBot("fakebot")
+		if err := InitWithBot(fakeBot); err != nil {
+			t.Fatalf("failed to init bot: %v", err)
+		}
+	})
+	if fakeBot == nil {
+		t.Fatal("bot was not initialized")
+	}
+	return fakeBot
+}
+
+// nextChatId returns a chat no other test has used.
+func nextChatId() int64 {
+	return atomic.AddInt64(&lastChatId, 1)
+}
+
+// sendCommand pushes a message from the given user onto the bot's updates.
+func sendCommand(bot *FakeBot, userId int64, chatId int64, text string) {
+	bot.Updates <- tgbotapi.Update{
+		Message: &tgbotapi.Message{
+			From: &tgbotapi.User{ID: userId, UserName: "tester"},
+			Chat: &tgbotapi.Chat{ID: chatId},
+			Text: text,
+		},
+	}
+}
+
+// waitForMessages waits until the bot sent the given number of messages to the
+// chat, returning their texts.
+func waitForMessages(t *testing.T, bot *FakeBot, chatId int64, count int) []string {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		texts := []string{}
+		for _, c := range bot.GetSent() {
+			if msg, ok := c.(tgbotapi.MessageConfig); ok && msg.ChatID == chatId {
+				texts = append(texts, msg.Text)
+			}
+		}
+		if len(texts) >= count {
+			return texts
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d messages to chat %d, got %v", count, chatId, texts)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestHandleCommandDispatchesToHandler(t *testing.T) {
+	bot := initFakeBot(t)
+	chatId := nextChatId()
+
+	sendCommand(bot, testAdminId, chatId, "/help@fakebot")
+	texts := waitForMessages(t, bot, chatId, 1)
+	if !strings.HasPrefix(texts[0], "Bot Commands are prefixed with '/'") {
+		t.Errorf("expected the help menu, got %q", texts[0])
+	}
+	if !strings.Contains(texts[0], "/snap") {
+		t.Errorf("expected the help menu to list /snap, got %q", texts[0])
+	}
+}
+
+func TestHandleCommandRepliesToUnknownCommand(t *testing.T) {
+	bot := initFakeBot(t)
+	chatId := nextChatId()
+
+	sendCommand(bot, testAdminId, chatId, "/bogus")
+	texts := waitForMessages(t, bot, chatId, 2)
+	if texts[0] != "Unknown command 'bogus'" {
+		t.Errorf("expected an unknown command reply, got %q", texts[0])
+	}
+}
+
+func TestHandleCommandIgnoresUnauthorizedSender(t *testing.T) {
+	bot := initFakeBot(t)
+	chatId := nextChatId()
+
+	// Followed by an admin's command, so the ignored one was surely handled.
+	sendCommand(bot, testAdminId+1, chatId, "/help")
+	sendCommand(bot, testAdminId, chatId, "/bogus")
+	texts := waitForMessages(t, bot, chatId, 2)
+	if len(texts) != 2 || texts[0] != "Unknown command 'bogus'" {
+		t.Errorf("expected only replies to the admin, got %v", texts)
+	}
+}
+
+func TestHandleCommandIgnoresCommandsAddressedToOtherBots(t *testing.T) {
+	bot := initFakeBot(t)
+	chatId := nextChatId()
+
+	sendCommand(bot, testAdminId, chatId, "/help@otherbot")
+	sendCommand(bot, testAdminId, chatId, "/bogus")
+	texts := waitForMessages(t, bot, chatId, 2)
+	if len(texts) != 2 || texts[0] != "Unknown command 'bogus'" {
+		t.Errorf("expected only replies to the bot's own commands, got %v", texts)
+	}
+}
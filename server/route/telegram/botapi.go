@@ -0,0 +1,121 @@
+package telegram
+
+import (
+	"fmt"
+	"sync"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Returned by notifiers & handlers while the bot is disabled.
+var ErrBotDisabled = fmt.Errorf("telegram bot is disabled")
+
+// Bot is the subset of the telegram bot api used by the server, allowing the
+// bot to be disabled or faked.
+type Bot interface {
+	UserName() string
+	Send(c tgbotapi.Chattable) (tgbotapi.Message, error)
+	SendMediaGroup(c tgbotapi.MediaGroupConfig) ([]tgbotapi.Message, error)
+	Request(c tgbotapi.Chattable) (*tgbotapi.APIResponse, error)
+	MakeRequest(endpoint string, params tgbotapi.Params) (*tgbotapi.APIResponse, error)
+	GetUpdatesChan(config tgbotapi.UpdateConfig) tgbotapi.UpdatesChannel
+}
+
+// Bot backed by the telegram bot api.
+type apiBot struct {
+	*tgbotapi.BotAPI
+}
+
+func (bot *apiBot) UserName() string {
+	return bot.Self.UserName
+}
+
+// Bot which discards everything sent through it, used while disabled.
+type NoopBot struct{}
+
+func (bot *NoopBot) UserName() string {
+	return ""
+}
+
+func (bot *NoopBot) Send(c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	return tgbotapi.Message{}, nil
+}
+
+func (bot *NoopBot) SendMediaGroup(c tgbotapi.MediaGroupConfig) ([]tgbotapi.Message, error) {
+	return []tgbotapi.Message{}, nil
+}
+
+func (bot *NoopBot) Request(c tgbotapi.Chattable) (*tgbotapi.APIResponse, error) {
+	return &tgbotapi.APIResponse{Ok: true}, nil
+}
+
+func (bot *NoopBot) MakeRequest(endpoint string, params tgbotapi.Params) (*tgbotapi.APIResponse, error) {
+	return &tgbotapi.APIResponse{Ok: true}, nil
+}
+
+func (bot *NoopBot) GetUpdatesChan(config tgbotapi.UpdateConfig) tgbotapi.UpdatesChannel {
+	return make(chan tgbotapi.Update)
+}
+
+// In-memory bot which records everything sent through it, and delivers updates
+// pushed onto its Updates channel. Intended for exercising handlers without
+// reaching telegram.
+type FakeBot struct {
+	Name    string
+	Updates chan tgbotapi.Update
+
+	// Chattables sent & requests made through the bot, in order.
+	Sent     []tgbotapi.Chattable
+	Requests []string
+	mutex    *sync.Mutex
+}
+
+// NewFakeBot creates a new in-memory bot with the given user name.
+func NewFakeBot(name string) *FakeBot {
+	return &FakeBot{
+		Name:     name,
+		Updates:  make(chan tgbotapi.Update, 100),
+		Sent:     []tgbotapi.Chattable{},
+		Requests: []string{},
+		mutex:    &sync.Mutex{},
+	}
+}
+
+func (bot *FakeBot) UserName() string {
+	return bot.Name
+}
+
+func (bot *FakeBot) Send(c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	bot.mutex.Lock()
+	defer bot.mutex.Unlock()
+	bot.Sent = append(bot.Sent, c)
+	return tgbotapi.Message{MessageID: len(bot.Sent)}, nil
+}
+
+func (bot *FakeBot) SendMediaGroup(c tgbotapi.MediaGroupConfig) ([]tgbotapi.Message, error) {
+	msg, err := bot.Send(c)
+	return []tgbotapi.Message{msg}, err
+}
+
+func (bot *FakeBot) Request(c tgbotapi.Chattable) (*tgbotapi.APIResponse, error) {
+	bot.Send(c)
+	return &tgbotapi.APIResponse{Ok: true}, nil
+}
+
+func (bot *FakeBot) MakeRequest(endpoint string, params tgbotapi.Params) (*tgbotapi.APIResponse, error) {
+	bot.mutex.Lock()
+	defer bot.mutex.Unlock()
+	bot.Requests = append(bot.Requests, endpoint)
+	return &tgbotapi.APIResponse{Ok: true}, nil
+}
+
+func (bot *FakeBot) GetUpdatesChan(config tgbotapi.UpdateConfig) tgbotapi.UpdatesChannel {
+	return bot.Updates
+}
+
+// GetSent returns a copy of the chattables sent through the bot.
+func (bot *FakeBot) GetSent() []tgbotapi.Chattable {
+	bot.mutex.Lock()
+	defer bot.mutex.Unlock()
+	return append([]tgbotapi.Chattable{}, bot.Sent...)
+}
@@ -0,0 +1,172 @@
+package telegram
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"4bit.api/v0/database"
+	"4bit.api/v0/pkg/camera"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// Largest number of photos telegram accepts within a media group.
+	MAX_MEDIA_GROUP_SIZE = 10
+)
+
+// Retrieves the poll workers of all cameras, or of those within the given group
+// and/or tag, sorted by name.
+func getCameraWorkers(group string, tag string) ([]*camera.CameraPollWorker, error) {
+	workerMp, err := camera.CameraPollerInstance.GetWorkers(group, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	workers := []*camera.CameraPollWorker{}
+	for _, worker := range workerMp {
+		workers = append(workers, worker)
+	}
+	sort.Slice(workers, func(i, j int) bool {
+		if workers[i].Name == workers[j].Name {
+			return workers[i].Id < workers[j].Id
+		}
+		return workers[i].Name < workers[j].Name
+	})
+	return workers, nil
+}
+
+// Constructs a photo message of a camera's snapshot.
+func snapshotPhoto(chatId int64, worker *camera.CameraPollWorker) tgbotapi.PhotoConfig {
+	snapshot := worker.GetSnapshot()
+	photo := tgbotapi.NewPhoto(chatId, tgbotapi.FileBytes{
+		Name:  worker.Name,
+		Bytes: snapshot.ImageData,
+	})
+	photo.Caption = fmt.Sprintf("Node[%s]: %v", worker.Name, snapshot.LastUpdated)
+	return photo
+}
+
+// Sends the snapshots of the given cameras as media groups, returning a summary
+// of when each snapshot was taken.
+func sendSnapshots(chatId int64, workers []*camera.CameraPollWorker) string {
+	snapshotInfo := ""
+	for start := 0; start < len(workers); start += MAX_MEDIA_GROUP_SIZE {
+		end := start + MAX_MEDIA_GROUP_SIZE
+		if end > len(workers) {
+			end = len(workers)
+		}
+
+		// Media groups require multiple photos.
+		if end-start == 1 {
+			photo := snapshotPhoto(chatId, workers[start])
+			if _, err := BOT.Send(photo); err != nil {
+				log.Printf("Failed to send camera snapshot: %v\n", err)
+			}
+			snapshotInfo += photo.Caption + "\n"
+			continue
+		}
+
+		images := []interface{}{}
+		for _, worker := range workers[start:end] {
+			snapshot := worker.GetSnapshot()
+			images = append(images, tgbotapi.NewInputMediaPhoto(tgbotapi.FileBytes{
+				Name:  worker.Name,
+				Bytes: snapshot.ImageData,
+			}))
+			snapshotInfo += fmt.Sprintf("Node[%s]: %v\n", worker.Name, snapshot.LastUpdated)
+		}
+		if _, err := BOT.SendMediaGroup(tgbotapi.NewMediaGroup(chatId, images)); err != nil {
+			log.Printf("Failed to send camera snapshots: %v\n", err)
+		}
+	}
+	return snapshotInfo
+}
+
+// Handles taking snapshots of cameras, optionally limited to a group or tag.
+// Replies with a keyboard of cameras & groups to choose from when no camera is
+// given.
+func handleSnapCommand(msg *tgbotapi.Message, cmd *ParsedCommand) tgbotapi.Chattable {
+	group := cmd.KwArg("group", "")
+	tag := cmd.KwArg("tag", "")
+	workers, err := getCameraWorkers(group, tag)
+	if err != nil {
+		return usageReply(msg, cmd.Name, err.Error())
+	}
+	if len(workers) == 0 {
+		return tgbotapi.NewMessage(msg.Chat.ID, "No cameras found.")
+	}
+
+	// List the cameras & groups to choose from.
+	cameraName := strings.Join(cmd.Args, " ")
+	cameraIp := cmd.KwArg("ip", "")
+	cameraId, err := cmd.Uint64KwArg("id", 0)
+	if err != nil {
+		return usageReply(msg, cmd.Name, err.Error())
+	}
+	if cameraName == "" && cameraIp == "" && cameraId == 0 && group == "" && tag == "" {
+		buttons := []commandButton{}
+		for _, worker := range workers {
+			buttons = append(buttons, commandButton{
+				Label:   worker.Name,
+				Command: fmt.Sprintf("/snap id=%d", worker.Id),
+			})
+		}
+
+		groups, err := database.StoreInstance.Cameras.ListGroups()
+		if err != nil {
+			log.Printf("Failed to query camera groups: %v\n", err)
+		}
+		for _, group := range groups {
+			buttons = append(buttons, commandButton{
+				Label:   "Group: " + group.Name,
+				Command: "/snap group=" + group.Name,
+			})
+		}
+
+		buttons = append(buttons, commandButton{Label: "All", Command: "/snap all"})
+		buttons = append(buttons, commandButton{Label: "Collage", Command: "/snap collage"})
+		return keyboardReply(msg, "Choose a camera:", buttons)
+	}
+
+	// Tile the cameras into a single image.
+	if cameraName == "collage" {
+		collage, err := camera.NewCollage(workers, camera.CollageOptions{})
+		if err != nil {
+			return tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("internal failure: %v", err))
+		}
+		return tgbotapi.NewPhoto(msg.Chat.ID, tgbotapi.FileBytes{
+			Name:  "collage",
+			Bytes: collage,
+		})
+	}
+
+	// Filter on the camera's ID, IP or name.
+	selected := []*camera.CameraPollWorker{}
+	for _, worker := range workers {
+		if cameraId != 0 && worker.Id != cameraId {
+			continue
+		}
+		if cameraIp != "" && worker.IP != cameraIp {
+			continue
+		}
+		if cameraName != "" && cameraName != "all" && !strings.EqualFold(worker.Name, cameraName) {
+			continue
+		}
+		selected = append(selected, worker)
+	}
+
+	switch {
+	case len(selected) == 0 && cameraId != 0:
+		return usageReply(msg, cmd.Name, fmt.Sprintf("No camera with ID %d.", cameraId))
+	case len(selected) == 0 && cameraIp != "":
+		return usageReply(msg, cmd.Name, fmt.Sprintf("No camera with IP '%s'.", cameraIp))
+	case len(selected) == 0:
+		return usageReply(msg, cmd.Name, fmt.Sprintf("No camera named '%s'.", cameraName))
+	case len(selected) == 1:
+		return snapshotPhoto(msg.Chat.ID, selected[0])
+	}
+
+	return tgbotapi.NewMessage(msg.Chat.ID, sendSnapshots(msg.Chat.ID, selected)+"Done.")
+}
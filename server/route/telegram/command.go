@@ -0,0 +1,128 @@
+package telegram
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Returned when parsing a command addressed to a different bot.
+var ErrCommandNotAddressed = fmt.Errorf("command addressed to another bot")
+
+// Bot command parsed from a message, ie. "/snap@MyBot "front door" limit=2".
+type ParsedCommand struct {
+	Name   string
+	Args   []string          // Positional arguments.
+	KwArgs map[string]string // key=value arguments.
+}
+
+// splitArguments splits text into arguments on whitespace, keeping double-quoted
+// arguments whole.
+func splitArguments(text string) ([]string, error) {
+	args := []string{}
+	current := strings.Builder{}
+	inQuotes := false
+	hasArg := false
+
+	for _, r := range text {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+			hasArg = true
+		case unicode.IsSpace(r) && !inQuotes:
+			if hasArg {
+				args = append(args, current.String())
+				current.Reset()
+				hasArg = false
+			}
+		default:
+			current.WriteRune(r)
+			hasArg = true
+		}
+	}
+
+	if inQuotes {
+		return nil, fmt.Errorf("unterminated quote")
+	}
+	if hasArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
+
+// ParseCommand parses a message's text into a bot command. Commands suffixed
+// with a bot name, ie. "/parking@MyBot", are only accepted if addressed to the
+// given bot name.
+// It returns the parsed command along with an error reflecting the failure state.
+func ParseCommand(text string, botName string) (*ParsedCommand, error) {
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(text, "/") {
+		return nil, fmt.Errorf("commands are prefixed with '/'")
+	}
+
+	tokens, err := splitArguments(text[1:])
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty command")
+	}
+
+	// Strip the bot name suffix.
+	name := tokens[0]
+	if idx := strings.Index(name, "@"); idx >= 0 {
+		if !strings.EqualFold(name[idx+1:], botName) {
+			return nil, ErrCommandNotAddressed
+		}
+		name = name[:idx]
+	}
+
+	cmd := &ParsedCommand{
+		Name:   strings.ToLower(name),
+		Args:   []string{},
+		KwArgs: map[string]string{},
+	}
+	for _, token := range tokens[1:] {
+		if kv := strings.SplitN(token, "=", 2); len(kv) == 2 && kv[0] != "" {
+			cmd.KwArgs[strings.ToLower(kv[0])] = kv[1]
+			continue
+		}
+		cmd.Args = append(cmd.Args, token)
+	}
+
+	return cmd, nil
+}
+
+// Arg returns the positional argument at the given index, or an empty string if
+// there is none.
+func (cmd *ParsedCommand) Arg(idx int) string {
+	if idx < 0 || idx >= len(cmd.Args) {
+		return ""
+	}
+	return cmd.Args[idx]
+}
+
+// KwArg returns the value of the given key=value argument, or the fallback value
+// if absent.
+func (cmd *ParsedCommand) KwArg(key string, fallback string) string {
+	if value, ok := cmd.KwArgs[key]; ok {
+		return value
+	}
+	return fallback
+}
+
+// Uint64KwArg parses the given key=value argument as an unsigned integer, returning
+// the fallback value if absent.
+func (cmd *ParsedCommand) Uint64KwArg(key string, fallback uint64) (uint64, error) {
+	value, ok := cmd.KwArgs[key]
+	if !ok {
+		return fallback, nil
+	}
+
+	parsed, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s '%s'", key, value)
+	}
+	return parsed, nil
+}
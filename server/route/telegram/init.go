@@ -4,16 +4,36 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strconv"
 
+	"4bit.api/v0/pkg/alert"
+	"4bit.api/v0/pkg/notify"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
 var (
 	TOKEN string
-	BOT   *tgbotapi.BotAPI
+	BOT   Bot = &NoopBot{}
+
+	// Whether the bot is backed by a working bot, rather than the no-op one.
+	ENABLED bool = false
+
+	// Optional chat for which alerts are delivered to.
+	ALERT_CHAT_ID int64
 )
 
-func Init() error {
+// Init sets up the telegram bot, unless disabled in which case everything sent
+// through the bot is discarded.
+// It returns an error reflecting the failure state.
+func Init(enabled bool) error {
+	// Expose the bot as a notification route kind, failing while disabled.
+	notify.RegisterKind(notify.KIND_TELEGRAM, NewTelegramNotifier)
+
+	if !enabled {
+		log.Println("Telegram bot disabled")
+		return nil
+	}
+
 	// Extract telegram bot's token from .env file.
 	TOKEN = os.Getenv("TELEGRAM_TOKEN")
 	if TOKEN == "" {
@@ -21,13 +41,60 @@ func Init() error {
 	}
 
 	// Create a telegram bot api instance.
-	var err error
-	BOT, err = tgbotapi.NewBotAPI(TOKEN)
+	botApi, err := tgbotapi.NewBotAPI(TOKEN)
 	if err != nil {
 		return fmt.Errorf("failed to create a new bot api: %v", err)
 	}
-	log.Printf("Authorized on account %s", BOT.Self.UserName)
+	log.Printf("Authorized on account %s", botApi.Self.UserName)
+
+	return InitWithBot(&apiBot{botApi})
+}
+
+// InitWithBot sets up the telegram bot backed by the given bot, such as a FakeBot.
+// It returns an error reflecting the failure state.
+func InitWithBot(bot Bot) error {
+	BOT = bot
+	ENABLED = true
+
+	var err error
+
+	// Extract the admins allowed to manage the bot's allowlist from .env.
+	ADMIN_IDS, err = parseAdminIds(os.Getenv("TELEGRAM_ADMIN_IDS"))
+	if err != nil {
+		return fmt.Errorf("failed to parse TELEGRAM_ADMIN_IDS: %v", err)
+	}
+	if len(ADMIN_IDS) == 0 {
+		log.Println("TELEGRAM_ADMIN_IDS not set, the bot's allowlist can only be managed through the database")
+	}
+
+	// Extract the optional webhook from .env, falling back on long polling.
+	WEBHOOK_URL = os.Getenv("TELEGRAM_WEBHOOK_URL")
+	if IsWebhookMode() {
+		WEBHOOK_SECRET = os.Getenv("TELEGRAM_WEBHOOK_SECRET")
+		if !webhookSecretPattern.MatchString(WEBHOOK_SECRET) {
+			return fmt.Errorf("TELEGRAM_WEBHOOK_SECRET must be 1-256 characters of A-Z, a-z, 0-9, _ and -")
+		}
+		if err := setWebhook(); err != nil {
+			return err
+		}
+		webhookUpdates = make(chan tgbotapi.Update, WEBHOOK_BUFFER_SIZE)
+	}
+
 	go StartBot()
 
+	// Deliver alerts through the bot.
+	alert.RegisterHandler(sendAlertEvent)
+
+	// Extract the optional alert chat from .env.
+	if alertChatId := os.Getenv("TELEGRAM_ALERT_CHAT_ID"); alertChatId != "" {
+		ALERT_CHAT_ID, err = strconv.ParseInt(alertChatId, 10, 64)
+		if err != nil {
+			return fmt.Errorf("failed to parse TELEGRAM_ALERT_CHAT_ID: %v", err)
+		}
+		go watchNodeLiveness()
+	} else {
+		log.Println("TELEGRAM_ALERT_CHAT_ID not set, node liveness alerts disabled")
+	}
+
 	return nil
 }
@@ -0,0 +1,71 @@
+package telegram
+
+import (
+	"log"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// Number of buttons laid out on each row of an inline keyboard.
+	KEYBOARD_ROW_SIZE = 2
+
+	// Largest callback data telegram accepts, in bytes.
+	MAX_CALLBACK_DATA_SIZE = 64
+)
+
+// Inline keyboard button which issues a bot command once tapped.
+type commandButton struct {
+	Label   string
+	Command string // Command text, ie. "/parking 2".
+}
+
+// Constructs an inline keyboard out of command buttons.
+func newCommandKeyboard(buttons []commandButton) tgbotapi.InlineKeyboardMarkup {
+	rows := [][]tgbotapi.InlineKeyboardButton{}
+	row := []tgbotapi.InlineKeyboardButton{}
+	for _, button := range buttons {
+		if len(button.Command) > MAX_CALLBACK_DATA_SIZE {
+			log.Printf("Skipping keyboard button '%s', command exceeds %d bytes: %s\n", button.Label, MAX_CALLBACK_DATA_SIZE, button.Command)
+			continue
+		}
+
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(button.Label, button.Command))
+		if len(row) == KEYBOARD_ROW_SIZE {
+			rows = append(rows, row)
+			row = []tgbotapi.InlineKeyboardButton{}
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// Constructs a reply with an inline keyboard of command buttons.
+func keyboardReply(msg *tgbotapi.Message, text string, buttons []commandButton) tgbotapi.Chattable {
+	reply := tgbotapi.NewMessage(msg.Chat.ID, text)
+	reply.ReplyMarkup = newCommandKeyboard(buttons)
+	return reply
+}
+
+// handleCallbackQuery handles a tapped command button, issuing its command as if
+// the user sent it in the keyboard's chat.
+func handleCallbackQuery(query *tgbotapi.CallbackQuery) {
+	log.Printf("[+] Bot '%s' New Callback from '%s': %s\n", BOT.UserName(), query.From.String(), query.Data)
+
+	// Acknowledge the query, stopping the button's loading indicator.
+	if _, err := BOT.Request(tgbotapi.NewCallback(query.ID, "")); err != nil {
+		log.Printf("Failed to answer callback query: %v\n", err)
+	}
+
+	// Inline mode queries aren't attached to a chat.
+	if query.Message == nil {
+		return
+	}
+
+	msg := *query.Message
+	msg.From = query.From
+	msg.Text = query.Data
+	handleCommand(&msg)
+}
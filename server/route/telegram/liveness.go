@@ -0,0 +1,88 @@
+package telegram
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"4bit.api/v0/server/route/node"
+	"4bit.api/v0/server/route/node/interfaces"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// Rate at which node liveness is checked for stale nodes.
+	LIVENESS_POLL_INTERVAL = 1 * time.Minute
+)
+
+// Constructs a human readable summary of a node's liveness.
+func formatNodeLiveness(liveness interfaces.NodeLiveness) string {
+	if liveness.LastSeen == nil {
+		return fmt.Sprintf("Node[%d]: No heartbeat\n", liveness.NodeId)
+	}
+
+	status := "Alive"
+	if liveness.IsStale {
+		status = "Stale"
+	}
+
+	msg := fmt.Sprintf("Node[%d]: %s\n", liveness.NodeId, status)
+	msg += fmt.Sprintf("  Last Seen: %v\n", liveness.LastSeen.Local())
+	msg += fmt.Sprintf("  Firmware: %s\n", liveness.LastHeartbeat.FirmwareVersion)
+	msg += fmt.Sprintf("  IP: %s\n", liveness.LastHeartbeat.IP)
+	msg += fmt.Sprintf("  Uptime: %v\n", time.Duration(liveness.LastHeartbeat.Uptime)*time.Second)
+	msg += fmt.Sprintf("  Free Memory: %dB\n", liveness.LastHeartbeat.FreeMemory)
+	return msg
+}
+
+// nodeLivenessTransitions records whether each node is stale, returning the
+// nodes which went stale or came back online since last recorded.
+func nodeLivenessTransitions(staleNodes map[uint64]bool, nodesLiveness []interfaces.NodeLiveness) []interfaces.NodeLiveness {
+	transitions := []interfaces.NodeLiveness{}
+	for _, liveness := range nodesLiveness {
+		if liveness.IsStale == staleNodes[liveness.NodeId] {
+			continue
+		}
+		staleNodes[liveness.NodeId] = liveness.IsStale
+		transitions = append(transitions, liveness)
+	}
+	return transitions
+}
+
+// watchNodeLiveness is intended to run in a goroutine which alerts the alert chat
+// whenever a node goes stale or comes back online. Nodes which are already stale
+// on the first poll aren't alerted on, as they were prior to a restart.
+func watchNodeLiveness() {
+	tick := time.NewTicker(LIVENESS_POLL_INTERVAL)
+	defer tick.Stop()
+
+	staleNodes := map[uint64]bool{}
+	initialized := false
+	for range tick.C {
+		nodesLiveness, err := node.GetNodesLiveness()
+		if err != nil {
+			log.Printf("Failed to query nodes liveness: %v\n", err)
+			continue
+		}
+
+		transitions := nodeLivenessTransitions(staleNodes, nodesLiveness)
+		if !initialized {
+			initialized = true
+			log.Printf("Watching liveness of %d nodes, %d of which are stale\n", len(nodesLiveness), len(transitions))
+			continue
+		}
+
+		for _, liveness := range transitions {
+			alertMsg := fmt.Sprintf("⚠️ Node[%d] went silent.\n", liveness.NodeId)
+			if !liveness.IsStale {
+				alertMsg = fmt.Sprintf("✅ Node[%d] is back online.\n", liveness.NodeId)
+			}
+			alertMsg += formatNodeLiveness(liveness)
+
+			log.Printf("Node[%d] liveness changed, stale=%v\n", liveness.NodeId, liveness.IsStale)
+			if _, err := BOT.Send(tgbotapi.NewMessage(ALERT_CHAT_ID, alertMsg)); err != nil {
+				log.Printf("Failed to send node liveness alert: %v\n", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package telegram
+
+import (
+	"testing"
+
+	"4bit.api/v0/server/route/node/interfaces"
+)
+
+func TestNodeLivenessTransitions(t *testing.T) {
+	staleNodes := map[uint64]bool{}
+
+	// The first poll records the stale nodes.
+	transitions := nodeLivenessTransitions(staleNodes, []interfaces.NodeLiveness{
+		{NodeId: 1, IsStale: true},
+		{NodeId: 2, IsStale: false},
+	})
+	if len(transitions) != 1 || transitions[0].NodeId != 1 {
+		t.Errorf("expected node[1] to be recorded as stale, got %+v", transitions)
+	}
+
+	// Unchanged nodes don't transition again.
+	transitions = nodeLivenessTransitions(staleNodes, []interfaces.NodeLiveness{
+		{NodeId: 1, IsStale: true},
+		{NodeId: 2, IsStale: false},
+	})
+	if len(transitions) != 0 {
+		t.Errorf("expected no transitions, got %+v", transitions)
+	}
+
+	transitions = nodeLivenessTransitions(staleNodes, []interfaces.NodeLiveness{
+		{NodeId: 1, IsStale: false},
+		{NodeId: 2, IsStale: true},
+	})
+	if len(transitions) != 2 || transitions[0].IsStale || !transitions[1].IsStale {
+		t.Errorf("expected node[1] back online & node[2] stale, got %+v", transitions)
+	}
+}
@@ -8,10 +8,13 @@ import (
 	"log"
 	"net/http"
 
-	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"4bit.api/v0/pkg/notify"
+
 	"github.com/gorilla/mux"
 )
 
+// Request of the telegram message endpoint, kept for compatibility with the
+// generic /notify endpoint.
 type TelegramMessageRequest struct {
 	ChatID  int64  `json:"chatId"`
 	Message string `json:"message"`
@@ -21,6 +24,11 @@ type TelegramMessageRequest struct {
 }
 
 func messageHandler(w http.ResponseWriter, r *http.Request) {
+	if !ENABLED {
+		http.Error(w, "telegram bot is disabled", http.StatusServiceUnavailable)
+		return
+	}
+
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		log.Printf("telegram/message: failed to parse request body -> %v", err)
@@ -48,33 +56,21 @@ func messageHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Construct and invoke telegram message based on the supplied chat id.
-	msg := tgbotapi.NewMessage(tlgmMsg.ChatID, tlgmMsg.Message)
-	if _, err := BOT.Send(msg); err != nil {
-		log.Printf("telegram/message: failed to send message -> %v", err)
-		http.Error(w, "failed to send message", http.StatusBadRequest)
-		return
-	}
-
-	// Decode, construct, and upload a given image to telegram.
+	// Decode the optional base64-encoded image.
+	img := []byte{}
 	if len(tlgmMsg.Image) == 0 {
 		log.Printf("telegram/message: Skipping image. No image in request to upload")
-		return
-	}
-	img, err := base64.StdEncoding.DecodeString(tlgmMsg.Image)
-	if err != nil {
+	} else if img, err = base64.StdEncoding.DecodeString(tlgmMsg.Image); err != nil {
 		log.Printf("telegram/message: base64-encoded image expected, failed to decode -> %v", err)
 		http.Error(w, "failed to decode image", http.StatusBadRequest)
 		return
 	}
 
-	photoFileBytes := tgbotapi.FileBytes{
-		Name:  "image",
-		Bytes: img,
-	}
-	if _, err := BOT.Send(tgbotapi.NewPhoto(tlgmMsg.ChatID, photoFileBytes)); err != nil {
-		log.Printf("telegram/message: failed to upload the image -> %v", err)
-		http.Error(w, "failed to upload the image", http.StatusBadRequest)
+	// Deliver the message through the notifier of the supplied chat id.
+	notifier := &TelegramNotifier{ChatId: tlgmMsg.ChatID}
+	if err := notifier.Notify(notify.Message{Body: tlgmMsg.Message, Image: img}); err != nil {
+		log.Printf("telegram/message: failed to notify -> %v", err)
+		http.Error(w, "failed to send message", http.StatusBadRequest)
 		return
 	}
 	w.WriteHeader(http.StatusOK)
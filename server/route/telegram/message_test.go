@@ -0,0 +1,64 @@
+package telegram
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func postMessage(body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest("POST", "/telegram/message", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	messageHandler(w, r)
+	return w
+}
+
+func TestMessageHandlerRejectsWhileDisabled(t *testing.T) {
+	initFakeBot(t)
+	ENABLED = false
+	defer func() { ENABLED = true }()
+
+	w := postMessage(`{"chatId": 1, "message": "hello"}`)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected %d while disabled, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+}
+
+func TestMessageHandlerValidatesRequest(t *testing.T) {
+	initFakeBot(t)
+
+	for body, reason := range map[string]string{
+		`{`:                    "malformed body",
+		`{"chatId": 1}`:        "empty message",
+		`{"message": "hello"}`: "missing chat id",
+		`{"chatId": 1, "message": "a", "image": "!"}`: "invalid image",
+	} {
+		if w := postMessage(body); w.Code != http.StatusBadRequest {
+			t.Errorf("expected %d for %s, got %d", http.StatusBadRequest, reason, w.Code)
+		}
+	}
+}
+
+func TestMessageHandlerSendsMessage(t *testing.T) {
+	bot := initFakeBot(t)
+	chatId := nextChatId()
+
+	w := postMessage(fmt.Sprintf(`{"chatId": %d, "message": "hello"}`, chatId))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	found := false
+	for _, c := range bot.GetSent() {
+		if msg, ok := c.(tgbotapi.MessageConfig); ok && msg.ChatID == chatId && msg.Text == "hello" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected the message to be sent to chat %d", chatId)
+	}
+}
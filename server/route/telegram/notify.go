@@ -0,0 +1,62 @@
+package telegram
+
+import (
+	"fmt"
+	"strconv"
+
+	"4bit.api/v0/pkg/notify"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// Longest caption telegram accepts on a photo.
+	MAX_CAPTION_LENGTH = 1024
+)
+
+// Notifier which delivers messages to a telegram chat through the bot.
+type TelegramNotifier struct {
+	ChatId int64
+}
+
+// NewTelegramNotifier constructs a telegram notifier given its "chat_id" option,
+// falling back on the alert chat.
+func NewTelegramNotifier(config map[string]string) (notify.Notifier, error) {
+	chatId := ALERT_CHAT_ID
+	if value := config["chat_id"]; value != "" {
+		var err error
+		if chatId, err = strconv.ParseInt(value, 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid chat_id '%s'", value)
+		}
+	}
+	if chatId == 0 {
+		return nil, fmt.Errorf("missing 'chat_id' option")
+	}
+	return &TelegramNotifier{ChatId: chatId}, nil
+}
+
+func (notifier *TelegramNotifier) Notify(msg notify.Message) error {
+	if !ENABLED {
+		return ErrBotDisabled
+	}
+
+	// Caption the image with the text when possible.
+	text := msg.Text()
+	if text != "" && (len(msg.Image) == 0 || len(text) > MAX_CAPTION_LENGTH) {
+		if _, err := BOT.Send(tgbotapi.NewMessage(notifier.ChatId, text)); err != nil {
+			return fmt.Errorf("failed to send message: %v", err)
+		}
+		text = ""
+	}
+
+	if len(msg.Image) > 0 {
+		photo := tgbotapi.NewPhoto(notifier.ChatId, tgbotapi.FileBytes{
+			Name:  "image",
+			Bytes: msg.Image,
+		})
+		photo.Caption = text
+		if _, err := BOT.Send(photo); err != nil {
+			return fmt.Errorf("failed to upload the image: %v", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,258 @@
+package telegram
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"4bit.api/v0/server/route/parking"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// Vehicle node used when a command doesn't specify one.
+	DEFAULT_VEHICLE_NODE_ID = 1
+)
+
+// Parses the vehicle node id from the given argument, falling back on the
+// node=<id> argument and then on the default vehicle node.
+func parseVehicleNodeId(cmd *ParsedCommand, arg string) (uint64, error) {
+	if arg != "" {
+		nodeId, err := strconv.ParseUint(arg, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid node id '%s'", arg)
+		}
+		return nodeId, nil
+	}
+	return cmd.Uint64KwArg("node", DEFAULT_VEHICLE_NODE_ID)
+}
+
+// Constructs a reply with a keyboard of vehicle nodes to report the parking
+// status or history of. Returns nil if there are no known vehicles.
+func vehicleKeyboardReply(msg *tgbotapi.Message, isHistory bool) tgbotapi.Chattable {
+	nodeIds, err := parking.GetVehicleNodeIds()
+	if err != nil {
+		return tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("internal failure: %v", err))
+	}
+	if len(nodeIds) == 0 {
+		return nil
+	}
+
+	command := "/parking "
+	if isHistory {
+		command += "history "
+	}
+
+	buttons := []commandButton{}
+	for _, nodeId := range nodeIds {
+		buttons = append(buttons, commandButton{
+			Label:   fmt.Sprintf("Node %d", nodeId),
+			Command: fmt.Sprintf("%s%d", command, nodeId),
+		})
+	}
+	return keyboardReply(msg, "Choose a vehicle:", buttons)
+}
+
+// Constructs a reply listing the vehicle's latest parking sessions.
+func handleParkingHistoryCommand(msg *tgbotapi.Message, nodeId uint64) tgbotapi.Chattable {
+	sessions, err := parking.GetSessions(nodeId, 10)
+	if err != nil {
+		return tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("internal failure: %v", err))
+	}
+	if len(sessions) == 0 {
+		return tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("No parking sessions for Node %d.", nodeId))
+	}
+
+	replyMsg := fmt.Sprintf("Parking history of Node %d:\n", nodeId)
+	for _, session := range sessions {
+		floor := "Unknown floor"
+		if session.Floor != nil {
+			floor = fmt.Sprintf("Floor %d (%s, %.0f%%)", *session.Floor, session.Garage.Name, session.Confidence*100)
+		}
+
+		duration := "ongoing"
+		if session.EndedAt != nil {
+			duration = session.EndedAt.Sub(session.StartedAt).Round(time.Minute).String()
+		}
+
+		replyMsg += fmt.Sprintf("  %v: %s [%s]\n", session.StartedAt.Local().Format(time.RFC822), floor, duration)
+	}
+	return tgbotapi.NewMessage(msg.Chat.ID, replyMsg)
+}
+
+// Handles reporting the vehicle's last known altitude along with its inferred floor.
+func handleParkingCommand(msg *tgbotapi.Message, cmd *ParsedCommand) tgbotapi.Chattable {
+	args := cmd.Args
+	isHistory := len(args) > 0 && args[0] == "history"
+	if isHistory {
+		args = args[1:]
+	}
+	if len(args) > 1 {
+		return usageReply(msg, cmd.Name, "Too many arguments.")
+	}
+
+	// List the vehicles to choose from, when there's no node given.
+	if len(args) == 0 && cmd.KwArg("node", "") == "" {
+		if reply := vehicleKeyboardReply(msg, isHistory); reply != nil {
+			return reply
+		}
+	}
+
+	nodeId, err := parseVehicleNodeId(cmd, strings.Join(args, ""))
+	if err != nil {
+		return usageReply(msg, cmd.Name, err.Error())
+	}
+
+	if isHistory {
+		return handleParkingHistoryCommand(msg, nodeId)
+	}
+
+	barEntry, err := parking.GetLastKnownBarometerEntry(nodeId)
+	if err != nil {
+		return tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("internal failure: %v", err))
+	}
+
+	// Map the altitude to the nearest calibrated parking floor.
+	parkingFloorMessage := ""
+	if estimate, err := parking.GetParkingFloor(barEntry); err != nil {
+		parkingFloorMessage = fmt.Sprintf("  Floor: Unknown - %v", err)
+	} else {
+		parkingFloorMessage = fmt.Sprintf("  Floor: %d (%s)\n", estimate.Band.Floor, estimate.Band.Garage.Name)
+		parkingFloorMessage += fmt.Sprintf("  Confidence: %.0f%%\n", estimate.Confidence*100)
+		if estimate.Reference != nil {
+			parkingFloorMessage += fmt.Sprintf(
+				"  Reference: Node %d at %v (%.2fm relative)",
+				estimate.Reference.NodeId,
+				estimate.Reference.Timestamp,
+				estimate.Altitude,
+			)
+		} else {
+			parkingFloorMessage += "  Reference: None (absolute altitude)"
+		}
+	}
+
+	// Construct nice message.
+	replyMsg := "%v:\n"
+	replyMsg += "  Entry ID: %d\n"
+	replyMsg += "  Node ID: %d\n"
+	replyMsg += "  Altitude: %.2f\n"
+	replyMsg += "  Pressure: %.2f\n"
+	replyMsg += "%s"
+
+	return tgbotapi.NewMessage(
+		msg.Chat.ID,
+		fmt.Sprintf(
+			replyMsg,
+			barEntry.Timestamp,
+			barEntry.Id,
+			nodeId,
+			barEntry.Altitude,
+			barEntry.Pressure,
+			parkingFloorMessage,
+		),
+	)
+}
+
+// Handles setting the reference barometer node of a garage, used to compensate
+// for pressure drift.
+func handleReferenceCommand(msg *tgbotapi.Message, cmd *ParsedCommand) tgbotapi.Chattable {
+	if len(cmd.Args) == 0 {
+		return usageReply(msg, cmd.Name, "Missing reference node id.")
+	}
+
+	referenceNodeId, err := strconv.ParseUint(cmd.Arg(0), 10, 64)
+	if err != nil {
+		return usageReply(msg, cmd.Name, fmt.Sprintf("Invalid node id '%s'.", cmd.Arg(0)))
+	}
+
+	garageName := cmd.KwArg("garage", parking.DEFAULT_GARAGE)
+	if len(cmd.Args) > 1 {
+		garageName = strings.Join(cmd.Args[1:], " ")
+	}
+
+	garage, err := parking.SetGarageReference(garageName, referenceNodeId)
+	if err != nil {
+		return tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("failed to set reference: %v", err))
+	}
+
+	if garage.ReferenceNodeId == 0 {
+		return tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("Removed the reference of garage '%s'.", garage.Name))
+	}
+	return tgbotapi.NewMessage(
+		msg.Chat.ID,
+		fmt.Sprintf(
+			"Garage '%s' now references Node %d. Re-calibrate its floors to compensate for drift.",
+			garage.Name,
+			garage.ReferenceNodeId,
+		),
+	)
+}
+
+// Handles calibrating the vehicle's current altitude as a given garage floor.
+// Lists the calibrated floors when no floor is given.
+func handleCalibrateCommand(msg *tgbotapi.Message, cmd *ParsedCommand) tgbotapi.Chattable {
+	nodeId, err := parseVehicleNodeId(cmd, "")
+	if err != nil {
+		return usageReply(msg, cmd.Name, err.Error())
+	}
+
+	if len(cmd.Args) == 0 {
+		bands, err := parking.GetFloorBands(nodeId)
+		if err != nil {
+			return tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("internal failure: %v", err))
+		}
+		if len(bands) == 0 {
+			return tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("Node %d has no calibrated floors.", nodeId))
+		}
+
+		replyMsg := fmt.Sprintf("Calibrated floors of Node %d:\n", nodeId)
+		for _, band := range bands {
+			replyMsg += fmt.Sprintf(
+				"  [%s] Floor %d: %.2fm (%.2f-%.2fm, %d samples)\n",
+				band.Garage.Name,
+				band.Floor,
+				band.Altitude,
+				band.MinAltitude,
+				band.MaxAltitude,
+				band.Samples,
+			)
+			if band.RelativeSamples > 0 {
+				replyMsg += fmt.Sprintf(
+					"    Relative: %.2fm (%.2f-%.2fm, %d samples)\n",
+					band.RelativeAltitude,
+					band.MinRelativeAltitude,
+					band.MaxRelativeAltitude,
+					band.RelativeSamples,
+				)
+			}
+		}
+		return tgbotapi.NewMessage(msg.Chat.ID, replyMsg)
+	}
+
+	floor, err := strconv.Atoi(cmd.Arg(0))
+	if err != nil {
+		return usageReply(msg, cmd.Name, fmt.Sprintf("Invalid floor '%s'.", cmd.Arg(0)))
+	}
+
+	garageName := cmd.KwArg("garage", parking.DEFAULT_GARAGE)
+	if len(cmd.Args) > 1 {
+		garageName = strings.Join(cmd.Args[1:], " ")
+	}
+
+	band, err := parking.CalibrateFloor(nodeId, garageName, floor)
+	if err != nil {
+		return tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("failed to calibrate floor: %v", err))
+	}
+
+	return tgbotapi.NewMessage(
+		msg.Chat.ID,
+		fmt.Sprintf(
+			"Calibrated [%s] Floor %d at %.2fm (%d samples).",
+			band.Garage.Name,
+			band.Floor,
+			band.Altitude,
+			band.Samples,
+		),
+	)
+}
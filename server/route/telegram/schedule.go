@@ -0,0 +1,162 @@
+package telegram
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"4bit.api/v0/database"
+	"4bit.api/v0/pkg/schedule"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Constructs a human readable description of a schedule.
+func formatSchedule(entry database.Schedule) string {
+	description := fmt.Sprintf("Schedule[%d] %s '%s'", entry.Id, entry.Report, entry.Cron)
+	if entry.NodeId != 0 {
+		description += fmt.Sprintf(" for Node %d", entry.NodeId)
+	}
+	if entry.Route != "" {
+		description += fmt.Sprintf(" to route '%s'", entry.Route)
+	} else {
+		description += fmt.Sprintf(" to chat %d", entry.ChatId)
+	}
+
+	if cron, err := schedule.ParseCron(entry.Cron); err == nil {
+		if next := cron.Next(time.Now()); !next.IsZero() {
+			description += fmt.Sprintf("\n  Next: %v", next.Format(time.RFC822))
+		}
+	}
+	return description
+}
+
+// parseScheduleId parses the schedule id argument of a sub-command.
+func parseScheduleId(cmd *ParsedCommand) (uint64, error) {
+	if len(cmd.Args) < 2 {
+		return 0, fmt.Errorf("missing schedule id")
+	}
+	scheduleId, err := strconv.ParseUint(cmd.Arg(1), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid schedule id '%s'", cmd.Arg(1))
+	}
+	return scheduleId, nil
+}
+
+// Constructs a reply listing all schedules.
+func handleScheduleListCommand(msg *tgbotapi.Message) tgbotapi.Chattable {
+	schedules, err := schedule.GetSchedules()
+	if err != nil {
+		return tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("internal failure: %v", err))
+	}
+	if len(schedules) == 0 {
+		return tgbotapi.NewMessage(msg.Chat.ID, "No schedules found.")
+	}
+
+	replyMsg := ""
+	for _, entry := range schedules {
+		replyMsg += formatSchedule(entry) + "\n"
+	}
+	return tgbotapi.NewMessage(msg.Chat.ID, replyMsg)
+}
+
+// Handles adding a schedule delivering a report to the current chat, or to the
+// given notification route.
+func handleScheduleAddCommand(msg *tgbotapi.Message, cmd *ParsedCommand) tgbotapi.Chattable {
+	if len(cmd.Args) < 3 {
+		return usageReply(msg, cmd.Name, "Missing report or cron expression.")
+	}
+
+	nodeId, err := cmd.Uint64KwArg("node", 0)
+	if err != nil {
+		return usageReply(msg, cmd.Name, err.Error())
+	}
+
+	entry := database.Schedule{
+		Report: strings.ToLower(cmd.Arg(1)),
+		Cron:   strings.Join(cmd.Args[2:], " "),
+		NodeId: nodeId,
+		Route:  cmd.KwArg("route", ""),
+		ChatId: msg.Chat.ID,
+	}
+	entry.Timestamp = time.Now().UTC()
+	if err := schedule.Validate(&entry); err != nil {
+		return usageReply(msg, cmd.Name, fmt.Sprintf("Invalid schedule: %v.", err))
+	}
+
+	db := database.DbInstance
+	if _, err := db.Model(&entry).Insert(); err != nil {
+		log.Printf("Failed to add schedule: %v\n", err)
+		return tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("internal failure: %v", err))
+	}
+	log.Printf("User[%d] added schedule[%d] %s '%s'\n", msg.From.ID, entry.Id, entry.Report, entry.Cron)
+
+	return tgbotapi.NewMessage(msg.Chat.ID, "Added "+formatSchedule(entry))
+}
+
+// Handles removing a schedule.
+func handleScheduleRemoveCommand(msg *tgbotapi.Message, cmd *ParsedCommand) tgbotapi.Chattable {
+	scheduleId, err := parseScheduleId(cmd)
+	if err != nil {
+		return usageReply(msg, cmd.Name, err.Error())
+	}
+
+	db := database.DbInstance
+	res, err := db.Model((*database.Schedule)(nil)).Where("id = ?", scheduleId).Delete()
+	if err != nil {
+		log.Printf("Failed to remove schedule[%d]: %v\n", scheduleId, err)
+		return tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("internal failure: %v", err))
+	}
+	if res.RowsAffected() == 0 {
+		return tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("Schedule %d not found.", scheduleId))
+	}
+	log.Printf("User[%d] removed schedule[%d]\n", msg.From.ID, scheduleId)
+
+	return tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("Removed schedule %d.", scheduleId))
+}
+
+// Handles delivering a schedule's report right away.
+func handleScheduleRunCommand(msg *tgbotapi.Message, cmd *ParsedCommand) tgbotapi.Chattable {
+	scheduleId, err := parseScheduleId(cmd)
+	if err != nil {
+		return usageReply(msg, cmd.Name, err.Error())
+	}
+
+	entry := database.Schedule{}
+	db := database.DbInstance
+	if err := db.Model(&entry).Where("id = ?", scheduleId).First(); err != nil {
+		return tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("Schedule %d not found.", scheduleId))
+	}
+
+	if err := schedule.Run(entry); err != nil {
+		log.Printf("Failed to run schedule[%d]: %v\n", scheduleId, err)
+		return tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("Failed to run schedule %d: %v", scheduleId, err))
+	}
+	return tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("Ran schedule %d.", scheduleId))
+}
+
+// Handles managing the scheduled reports.
+func handleScheduleCommand(msg *tgbotapi.Message, cmd *ParsedCommand) tgbotapi.Chattable {
+	// Schedules deliver camera snapshots & telemetry to any route, so only admins
+	// may manage or run them.
+	switch cmd.Arg(0) {
+	case "add", "remove", "run":
+		if !isAdmin(msg.From) {
+			log.Printf("[!] Rejected schedule sub-command '%s' from user '%s'\n", cmd.Arg(0), msg.From.String())
+			return tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("Sub-command '%s' is restricted to admins.", cmd.Arg(0)))
+		}
+	}
+
+	switch cmd.Arg(0) {
+	case "", "list":
+		return handleScheduleListCommand(msg)
+	case "add":
+		return handleScheduleAddCommand(msg, cmd)
+	case "remove":
+		return handleScheduleRemoveCommand(msg, cmd)
+	case "run":
+		return handleScheduleRunCommand(msg, cmd)
+	}
+	return usageReply(msg, cmd.Name, fmt.Sprintf("Unknown sub-command '%s'.", cmd.Arg(0)))
+}
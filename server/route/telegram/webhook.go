@@ -0,0 +1,103 @@
+package telegram
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"regexp"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/gorilla/mux"
+)
+
+const (
+	// Header telegram delivers the webhook's secret token in.
+	WEBHOOK_SECRET_HEADER = "X-Telegram-Bot-Api-Secret-Token"
+
+	// Number of webhook updates queued up for the bot.
+	WEBHOOK_BUFFER_SIZE = 100
+)
+
+var (
+	// Public URL telegram delivers updates to. Updates are long polled when empty.
+	WEBHOOK_URL string
+
+	// Secret token telegram includes in each webhook request.
+	WEBHOOK_SECRET string
+
+	// Updates received through the webhook, consumed by the bot.
+	webhookUpdates chan tgbotapi.Update
+
+	// Characters telegram accepts within a webhook's secret token.
+	webhookSecretPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,256}$`)
+)
+
+// IsWebhookMode checks whether updates are delivered through the webhook,
+// rather than long polled.
+func IsWebhookMode() bool {
+	return WEBHOOK_URL != ""
+}
+
+// setWebhook registers the webhook with telegram. The request is constructed
+// manually, as the bot api's webhook config lacks the secret token.
+// It returns an error reflecting the failure state.
+func setWebhook() error {
+	params := tgbotapi.Params{
+		"url":          WEBHOOK_URL,
+		"secret_token": WEBHOOK_SECRET,
+	}
+	if err := params.AddInterface("allowed_updates", []string{"message", "callback_query"}); err != nil {
+		return fmt.Errorf("failed to construct webhook params: %v", err)
+	}
+
+	if _, err := BOT.MakeRequest("setWebhook", params); err != nil {
+		return fmt.Errorf("failed to set webhook: %v", err)
+	}
+	log.Printf("Telegram webhook set to %s\n", WEBHOOK_URL)
+	return nil
+}
+
+func webhookHandler(w http.ResponseWriter, r *http.Request) {
+	secret := r.Header.Get(WEBHOOK_SECRET_HEADER)
+	if subtle.ConstantTimeCompare([]byte(secret), []byte(WEBHOOK_SECRET)) != 1 {
+		log.Printf("telegram/webhook: invalid secret token from %s", r.RemoteAddr)
+		http.Error(w, "invalid secret token", http.StatusUnauthorized)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Printf("telegram/webhook: failed to parse request body -> %v", err)
+		http.Error(w, "failed to parse request body", http.StatusInternalServerError)
+		return
+	}
+
+	var update tgbotapi.Update
+	if err := json.Unmarshal(body, &update); err != nil {
+		log.Printf("telegram/webhook: invalid request body -> %v", err)
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Hand off the update to the bot, acknowledging it right away. Updates are
+	// rejected while the bot is behind, such that telegram retries them later
+	// rather than holding requests open.
+	select {
+	case webhookUpdates <- update:
+	default:
+		log.Printf("telegram/webhook: update buffer full, rejecting update %d", update.UpdateID)
+		http.Error(w, "busy", http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// CreateWebhookRoute adds the route telegram delivers updates to. Intended to be
+// served on a listener which doesn't require client certificates.
+func CreateWebhookRoute(ctx *context.Context, r *mux.Router) {
+	r.HandleFunc("/webhook", webhookHandler).Methods("POST")
+}
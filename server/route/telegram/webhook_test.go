@@ -0,0 +1,42 @@
+package telegram
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func postWebhook(secret string, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest("POST", "/telegram/webhook", strings.NewReader(body))
+	r.Header.Set(WEBHOOK_SECRET_HEADER, secret)
+	w := httptest.NewRecorder()
+	webhookHandler(w, r)
+	return w
+}
+
+func TestWebhookHandlerRejectsWhileBufferFull(t *testing.T) {
+	WEBHOOK_SECRET = "secret"
+	webhookUpdates = make(chan tgbotapi.Update, 1)
+	defer func() {
+		WEBHOOK_SECRET = ""
+		webhookUpdates = nil
+	}()
+
+	if w := postWebhook("wrong", `{"update_id": 1}`); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected %d for an invalid secret, got %d", http.StatusUnauthorized, w.Code)
+	}
+	if w := postWebhook("secret", `{"update_id": 1}`); w.Code != http.StatusOK {
+		t.Errorf("expected %d, got %d", http.StatusOK, w.Code)
+	}
+
+	// The buffer is full, as nothing consumes the updates.
+	if w := postWebhook("secret", `{"update_id": 2}`); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected %d while the buffer is full, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+	if update := <-webhookUpdates; update.UpdateID != 1 {
+		t.Errorf("expected update 1 to be queued, got update %d", update.UpdateID)
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"4bit.api/v0/internal/utils"
 	"4bit.api/v0/server/middleware"
 	"4bit.api/v0/server/route"
+	"4bit.api/v0/server/route/telegram"
 	fileio "4bit.api/v0/utils/fileIO"
 	"github.com/gorilla/mux"
 )
@@ -27,6 +28,11 @@ type ServerOpts struct {
 	CACrl               string
 	HostEndpoint        string
 	PortEndpoint        uint16
+
+	// Endpoint of the telegram webhook listener, served without client
+	// certificates when the bot is in webhook mode.
+	WebhookHostEndpoint string
+	WebhookPortEndpoint uint16
 }
 
 func createPeerCertificateVerification(trustedCerts []x509.Certificate) func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
@@ -169,10 +175,44 @@ func Run(ctx *context.Context, opts *ServerOpts) error {
 	}
 	http.Handle("/", router)
 
-	log.Printf("Listening on %s:%d.\n", opts.HostEndpoint, opts.PortEndpoint)
-	if err := server.ListenAndServeTLS(opts.ServerCertificate, opts.ServerKey); err != nil {
-		return fmt.Errorf("failed to start server: %v", err)
+	// Serve both listeners, stopping on either one failing.
+	serverErrors := make(chan error, 2)
+	if telegram.IsWebhookMode() {
+		webhookServer := createWebhookServer(ctx, opts)
+		go func() {
+			log.Printf("Telegram webhook listening on %s:%d.\n", opts.WebhookHostEndpoint, opts.WebhookPortEndpoint)
+			if err := webhookServer.ListenAndServeTLS(opts.ServerCertificate, opts.ServerKey); err != nil {
+				serverErrors <- fmt.Errorf("failed to start telegram webhook server: %v", err)
+			}
+		}()
 	}
 
-	return nil
+	go func() {
+		log.Printf("Listening on %s:%d.\n", opts.HostEndpoint, opts.PortEndpoint)
+		if err := server.ListenAndServeTLS(opts.ServerCertificate, opts.ServerKey); err != nil {
+			serverErrors <- fmt.Errorf("failed to start server: %v", err)
+		}
+	}()
+
+	return <-serverErrors
+}
+
+// createWebhookServer creates the server telegram delivers updates to. Telegram
+// doesn't present a client certificate, so requests are instead validated
+// against the webhook's secret token.
+func createWebhookServer(ctx *context.Context, opts *ServerOpts) *http.Server {
+	router := mux.NewRouter()
+	router.Use(middleware.BasicLogger)
+	route.InitWebhookRoute(ctx, router)
+
+	return &http.Server{
+		Addr:         fmt.Sprintf("%s:%d", opts.WebhookHostEndpoint, opts.WebhookPortEndpoint),
+		Handler:      router,
+		ReadTimeout:  1 * time.Minute,
+		WriteTimeout: 1 * time.Minute,
+		TLSConfig: &tls.Config{
+			ServerName: opts.ServerName,
+			MinVersion: tls.VersionTLS12,
+		},
+	}
 }